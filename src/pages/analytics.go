@@ -0,0 +1,455 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const DEFAULT_REPORT_DAYS = 30
+
+// Report dimensions, in the order they are shown.
+var reportDimensions = []struct {
+	name  string
+	group func(BidRecord) string
+}{
+	{"service type", func(r BidRecord) string { return orEmpty(r.ServiceType, "unknown") }},
+	{"deadline", func(r BidRecord) string { return deadlineBucket(r.DeadlineHours) }},
+	{"pages", func(r BidRecord) string { return pagesBucket(r.Pages) }},
+	{"strategy", func(r BidRecord) string { return orEmpty(r.Strategy, "unknown") }},
+	{"message", func(r BidRecord) string { return messageTemplateLabel(r.Message) }},
+}
+
+// ReportGroup summarizes the bids in one group of a dimension.
+type ReportGroup struct {
+	Dimension string  `json:"dimension"`
+	Group     string  `json:"group"`
+	Bids      int     `json:"bids"`
+	Decided   int     `json:"decided"`
+	Won       int     `json:"won"`
+	WinRate   float64 `json:"win_rate"` // won / decided
+	AvgBid    Money   `json:"avg_bid"`  // over bids with an amount
+
+	amountSum   Money
+	amountCount int64
+}
+
+func (g *ReportGroup) add(rec BidRecord, outcome Outcome) {
+	g.Bids++
+	if outcome != "" {
+		g.Decided++
+	}
+	if outcome == OutcomeWon {
+		g.Won++
+	}
+	if rec.Amount > 0 {
+		g.amountSum += rec.Amount
+		g.amountCount++
+	}
+}
+
+func (g *ReportGroup) finish() {
+	if g.Decided > 0 {
+		g.WinRate = float64(g.Won) / float64(g.Decided)
+	}
+	if g.amountCount > 0 {
+		g.AvgBid = Money(divRound(int64(g.amountSum), g.amountCount, RoundHalfUp))
+	}
+}
+
+// LatencyStats are percentiles of the time from an order first being
+// listed to the bid on it.
+type LatencyStats struct {
+	Samples int   `json:"samples"`
+	P50Ms   int64 `json:"p50_ms"`
+	P90Ms   int64 `json:"p90_ms"`
+	P99Ms   int64 `json:"p99_ms"`
+	MaxMs   int64 `json:"max_ms"`
+}
+
+type HourlyBids struct {
+	Hour time.Time `json:"hour"`
+	Bids int       `json:"bids"`
+}
+
+// BidReport is the analytics report over the live bids of a period.
+type BidReport struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Overall     ReportGroup   `json:"overall"`
+	Groups      []ReportGroup `json:"groups"`
+	Latency     LatencyStats  `json:"discovery_to_bid_latency"`
+	BidsPerHour []HourlyBids  `json:"bids_per_hour"`
+}
+
+func orEmpty(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func deadlineBucket(hours float64) string {
+	switch {
+	case hours <= 0:
+		return "unknown"
+	case hours < 12:
+		return "under 12h"
+	case hours < 24:
+		return "12-24h"
+	case hours < 72:
+		return "1-3 days"
+	case hours < 168:
+		return "3-7 days"
+	}
+	return "over 7 days"
+}
+
+func pagesBucket(pages int) string {
+	switch {
+	case pages <= 0:
+		return "unknown"
+	case pages == 1:
+		return "1"
+	case pages <= 5:
+		return "2-5"
+	case pages <= 10:
+		return "6-10"
+	case pages <= 20:
+		return "11-20"
+	}
+	return "over 20"
+}
+
+// messageTemplateLabel identifies a message template by its first line.
+func messageTemplateLabel(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	if line == "" {
+		return "no message"
+	}
+	if runes := []rune(line); len(runes) > 40 {
+		return string(runes[:40]) + "..."
+	}
+	return line
+}
+
+// buildBidReport summarizes the records placed in [from, to), joined with
+// the outcomes recorded for them.
+func buildBidReport(records []BidRecord, decided map[string]BidOutcome, from, to time.Time) BidReport {
+	report := BidReport{GeneratedAt: time.Now(), From: from, To: to, Overall: ReportGroup{Dimension: "overall", Group: "all bids"}}
+	groups := make(map[string]*ReportGroup)
+	hourly := make(map[int64]int) // by Unix hour start
+	var latencies []time.Duration
+
+	for _, rec := range records {
+		if rec.Simulated || rec.PlacedAt.Before(from) || !rec.PlacedAt.Before(to) {
+			continue
+		}
+		outcome := decided[orderID(rec.OrderURL)].Outcome
+		report.Overall.add(rec, outcome)
+		for _, dim := range reportDimensions {
+			name := dim.group(rec)
+			key := dim.name + "\x00" + name
+			g, ok := groups[key]
+			if !ok {
+				g = &ReportGroup{Dimension: dim.name, Group: name}
+				groups[key] = g
+			}
+			g.add(rec, outcome)
+		}
+		hourly[rec.PlacedAt.Truncate(time.Hour).Unix()]++
+		if !rec.DiscoveredAt.IsZero() && rec.PlacedAt.After(rec.DiscoveredAt) {
+			latencies = append(latencies, rec.PlacedAt.Sub(rec.DiscoveredAt))
+		}
+	}
+
+	report.Overall.finish()
+	for _, dim := range reportDimensions {
+		var dimGroups []ReportGroup
+		for _, g := range groups {
+			if g.Dimension == dim.name {
+				g.finish()
+				dimGroups = append(dimGroups, *g)
+			}
+		}
+		sort.Slice(dimGroups, func(i, j int) bool {
+			if dimGroups[i].Bids != dimGroups[j].Bids {
+				return dimGroups[i].Bids > dimGroups[j].Bids
+			}
+			return dimGroups[i].Group < dimGroups[j].Group
+		})
+		report.Groups = append(report.Groups, dimGroups...)
+	}
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		report.Latency = LatencyStats{
+			Samples: len(latencies),
+			P50Ms:   percentile(latencies, 50).Milliseconds(),
+			P90Ms:   percentile(latencies, 90).Milliseconds(),
+			P99Ms:   percentile(latencies, 99).Milliseconds(),
+			MaxMs:   latencies[len(latencies)-1].Milliseconds(),
+		}
+	}
+
+	for hour := from.Truncate(time.Hour); hour.Before(to); hour = hour.Add(time.Hour) {
+		report.BidsPerHour = append(report.BidsPerHour, HourlyBids{Hour: hour, Bids: hourly[hour.Unix()]})
+	}
+	return report
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// loadBidReport builds the report for the last days days from disk.
+func loadBidReport(days int) (BidReport, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+	records, err := readBidHistory(from)
+	if err != nil {
+		return BidReport{}, fmt.Errorf("error reading bids: %w", err)
+	}
+	decided, err := readOutcomes()
+	if err != nil {
+		return BidReport{}, fmt.Errorf("error reading outcomes: %w", err)
+	}
+	return buildBidReport(records, decided, from, to), nil
+}
+
+func formatWinRate(g ReportGroup) string {
+	if g.Decided == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", g.WinRate*100)
+}
+
+func (r BidReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes every figure as one row of a single table.
+func (r BidReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"section", "dimension", "group", "bids", "decided", "won", "win_rate", "avg_bid", "value"})
+	for _, g := range append([]ReportGroup{r.Overall}, r.Groups...) {
+		cw.Write([]string{"breakdown", g.Dimension, g.Group, strconv.Itoa(g.Bids), strconv.Itoa(g.Decided),
+			strconv.Itoa(g.Won), strconv.FormatFloat(g.WinRate, 'f', 4, 64), g.AvgBid.Decimal(), ""})
+	}
+	for _, l := range []struct {
+		name string
+		ms   int64
+	}{{"p50_ms", r.Latency.P50Ms}, {"p90_ms", r.Latency.P90Ms}, {"p99_ms", r.Latency.P99Ms}, {"max_ms", r.Latency.MaxMs}} {
+		cw.Write([]string{"latency", "discovery to bid", l.name, strconv.Itoa(r.Latency.Samples), "", "", "", "", strconv.FormatInt(l.ms, 10)})
+	}
+	for _, h := range r.BidsPerHour {
+		cw.Write([]string{"bids_per_hour", "hour", h.Hour.Format(time.RFC3339), strconv.Itoa(h.Bids), "", "", "", "", strconv.Itoa(h.Bids)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Text renders the report as a plain table, with the last day of the
+// hourly series.
+func (r BidReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Bids %s - %s\n\n", r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "%-14s %-30s %5s %7s %4s %7s %9s\n", "DIMENSION", "GROUP", "BIDS", "DECIDED", "WON", "WIN", "AVG BID")
+	for _, g := range append([]ReportGroup{r.Overall}, r.Groups...) {
+		fmt.Fprintf(&b, "%-14s %-30s %5d %7d %4d %7s %9s\n", g.Dimension, g.Group, g.Bids, g.Decided, g.Won, formatWinRate(g), g.AvgBid)
+	}
+	fmt.Fprintf(&b, "\nDiscovery to bid (%d bids): p50 %dms, p90 %dms, p99 %dms, max %dms\n",
+		r.Latency.Samples, r.Latency.P50Ms, r.Latency.P90Ms, r.Latency.P99Ms, r.Latency.MaxMs)
+
+	b.WriteString("\nBids per hour (last 24h):\n")
+	hours := r.BidsPerHour
+	if len(hours) > 24 {
+		hours = hours[len(hours)-24:]
+	}
+	for _, h := range hours {
+		fmt.Fprintf(&b, "%s %3d %s\n", h.Hour.Format("Jan 2 15:00"), h.Bids, strings.Repeat("#", h.Bids))
+	}
+	return b.String()
+}
+
+var reportHTMLTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"winRate": formatWinRate,
+	"percent": func(n, max int) float64 {
+		if max == 0 {
+			return 0
+		}
+		return float64(n) * 100 / float64(max)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Bidding Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: right; }
+th:first-child, td:first-child, th:nth-child(2), td:nth-child(2) { text-align: left; }
+th { background: #f0f0f0; }
+.chart { display: flex; align-items: flex-end; height: 160px; gap: 1px; border-bottom: 1px solid #999; }
+.bar { flex: 1; background: #4a7bd0; min-width: 1px; }
+</style>
+</head>
+<body>
+<h1>Bidding Report</h1>
+<p>Bids placed {{.Report.From.Format "2006-01-02 15:04"}} to {{.Report.To.Format "2006-01-02 15:04"}}, generated {{.Report.GeneratedAt.Format "2006-01-02 15:04"}}.</p>
+<h2>Win rate and average bid</h2>
+<table>
+<tr><th>Dimension</th><th>Group</th><th>Bids</th><th>Decided</th><th>Won</th><th>Win rate</th><th>Avg bid</th></tr>
+<tr><td>{{.Report.Overall.Dimension}}</td><td>{{.Report.Overall.Group}}</td><td>{{.Report.Overall.Bids}}</td><td>{{.Report.Overall.Decided}}</td><td>{{.Report.Overall.Won}}</td><td>{{winRate .Report.Overall}}</td><td>{{.Report.Overall.AvgBid}}</td></tr>
+{{range .Report.Groups}}<tr><td>{{.Dimension}}</td><td>{{.Group}}</td><td>{{.Bids}}</td><td>{{.Decided}}</td><td>{{.Won}}</td><td>{{winRate .}}</td><td>{{.AvgBid}}</td></tr>
+{{end}}</table>
+<h2>Discovery to bid latency</h2>
+<table>
+<tr><th>Samples</th><th>p50</th><th>p90</th><th>p99</th><th>Max</th></tr>
+<tr><td>{{.Report.Latency.Samples}}</td><td>{{.Report.Latency.P50Ms}} ms</td><td>{{.Report.Latency.P90Ms}} ms</td><td>{{.Report.Latency.P99Ms}} ms</td><td>{{.Report.Latency.MaxMs}} ms</td></tr>
+</table>
+<h2>Bids per hour</h2>
+<p>Peak: {{.MaxHourly}} bids in an hour.</p>
+<div class="chart">{{$max := .MaxHourly}}{{range .Report.BidsPerHour}}<div class="bar" style="height: {{percent .Bids $max}}%" title="{{.Hour.Format "2006-01-02 15:00"}}: {{.Bids}}"></div>{{end}}</div>
+</body>
+</html>
+`))
+
+// WriteHTML writes a self-contained page with no external resources.
+func (r BidReport) WriteHTML(w io.Writer) error {
+	maxHourly := 0
+	for _, h := range r.BidsPerHour {
+		if h.Bids > maxHourly {
+			maxHourly = h.Bids
+		}
+	}
+	return reportHTMLTemplate.Execute(w, struct {
+		Report    BidReport
+		MaxHourly int
+	}{r, maxHourly})
+}
+
+// writeReportFormat writes the report as "csv", "json" or "html".
+func (r BidReport) writeReportFormat(w io.Writer, format string) error {
+	switch format {
+	case "csv":
+		return r.WriteCSV(w)
+	case "json":
+		return r.WriteJSON(w)
+	case "html", "htm":
+		return r.WriteHTML(w)
+	}
+	return fmt.Errorf("unknown report format %q, expected csv, json or html", format)
+}
+
+// exportableReport is a report that can be shown as text and exported.
+type exportableReport interface {
+	Text() string
+	writeReportFormat(w io.Writer, format string) error
+}
+
+func loadBidReportView(days int) (exportableReport, error) { return loadBidReport(days) }
+
+// runReportCommand writes the report load builds to path, picking the
+// format from its extension, and returns the process exit code.
+func runReportCommand(path string, days int, load func(days int) (exportableReport, error)) int {
+	report, err := load(days)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating report: %v\n", err)
+		return 1
+	}
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	err = report.writeReportFormat(f, format)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote report over %d days to %s\n", days, path)
+	return 0
+}
+
+// reportContent builds a report screen with a period selector and one
+// export button per format.
+func reportContent(w fyne.Window, name string, formats []string, load func(days int) (exportableReport, error)) fyne.CanvasObject {
+	daysSelect := widget.NewSelect([]string{"1", "7", "30", "90"}, nil)
+	daysSelect.SetSelected(strconv.Itoa(DEFAULT_REPORT_DAYS))
+	reportGrid := widget.NewTextGrid()
+
+	var current exportableReport
+	refresh := func() {
+		days, _ := strconv.Atoi(daysSelect.Selected)
+		report, err := load(days)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		current = report
+		reportGrid.SetText(report.Text())
+	}
+	daysSelect.OnChanged = func(string) { refresh() }
+
+	buttons := container.NewHBox(
+		widget.NewLabel("Days:"), daysSelect,
+		widget.NewButton("Refresh", refresh),
+	)
+	for _, format := range formats {
+		format := format
+		buttons.Add(widget.NewButton("Export "+strings.ToUpper(format), func() {
+			if current == nil {
+				return
+			}
+			save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+				if err != nil || writer == nil {
+					return
+				}
+				defer writer.Close()
+				if err := current.writeReportFormat(writer, format); err != nil {
+					dialog.ShowError(err, w)
+				}
+			}, w)
+			save.SetFileName(name + "." + format)
+			save.Show()
+		}))
+	}
+
+	refresh()
+	// The screen sits in a VBox, which would shrink the scroll to nothing.
+	scroll := container.NewScroll(reportGrid)
+	scroll.SetMinSize(fyne.NewSize(760, 480))
+	return container.NewBorder(buttons, nil, nil, nil, scroll)
+}
+
+// analyticsContent builds the Analytics screen.
+func analyticsContent(w fyne.Window) fyne.CanvasObject {
+	return reportContent(w, "bidding-report", []string{"csv", "json", "html"}, loadBidReportView)
+}
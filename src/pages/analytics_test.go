@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var reportStart = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+// reportBid is a live bid on order 100000000+n placed the given minutes
+// after reportStart.
+func reportBid(n int, minutes float64, service string, amount Money) BidRecord {
+	return BidRecord{
+		OrderURL:    fmt.Sprintf("https://essayshark.com/writer/orders/%d.html", 100000000+n),
+		Amount:      amount,
+		PlacedAt:    reportStart.Add(time.Duration(minutes * float64(time.Minute))),
+		ServiceType: service,
+	}
+}
+
+func reportGroup(report BidReport, dimension, group string) (ReportGroup, bool) {
+	for _, g := range report.Groups {
+		if g.Dimension == dimension && g.Group == group {
+			return g, true
+		}
+	}
+	return ReportGroup{}, false
+}
+
+func TestBuildBidReport(t *testing.T) {
+	discovered := func(rec BidRecord, before time.Duration) BidRecord {
+		rec.DiscoveredAt = rec.PlacedAt.Add(-before)
+		return rec
+	}
+	simulated := reportBid(4, 90, "Essay writing", 5000)
+	simulated.Simulated = true
+	records := []BidRecord{
+		reportBid(7, 0, "Editing", 1000), // at the start of the period
+		discovered(reportBid(1, 5, "Editing", 1200), time.Second),
+		discovered(reportBid(2, 30, "Editing", 1400), 3*time.Second),
+		discovered(reportBid(3, 60, "Essay writing", 2000), 2*time.Second),
+		simulated,
+		reportBid(5, -1.0/60, "Editing", 3000), // a second before the period
+		reportBid(6, 180, "Editing", 3000),     // at its end
+	}
+	decided := map[string]BidOutcome{
+		"100000001": {Outcome: OutcomeWon},
+		"100000002": {Outcome: OutcomeLost},
+		"100000007": {Outcome: OutcomeExpired},
+		"100000004": {Outcome: OutcomeWon}, // simulated, not counted
+	}
+	report := buildBidReport(records, decided, reportStart, reportStart.Add(3*time.Hour))
+
+	o := report.Overall
+	if o.Bids != 4 || o.Decided != 3 || o.Won != 1 || o.AvgBid != 1400 {
+		t.Errorf("overall = %+v, want 4 bids, 3 decided, 1 won, $14.00 average", o)
+	}
+	if o.WinRate < 0.333 || o.WinRate > 0.334 {
+		t.Errorf("win rate = %v, want 1/3", o.WinRate)
+	}
+
+	editing, _ := reportGroup(report, "service type", "Editing")
+	essays, _ := reportGroup(report, "service type", "Essay writing")
+	if editing.Bids != 3 || editing.Decided != 3 || editing.Won != 1 || editing.AvgBid != 1200 {
+		t.Errorf("Editing = %+v, want 3 bids, 3 decided, 1 won, $12.00 average", editing)
+	}
+	if essays.Bids != 1 || essays.Decided != 0 || essays.WinRate != 0 {
+		t.Errorf("Essay writing = %+v, want 1 undecided bid", essays)
+	}
+	if g := report.Groups[0]; g.Dimension != "service type" || g.Group != "Editing" {
+		t.Errorf("first group = %s %s, want the service type with most bids", g.Dimension, g.Group)
+	}
+	if g, ok := reportGroup(report, "pages", "unknown"); !ok || g.Bids != 4 {
+		t.Errorf("pages unknown = %+v, want every bid", g)
+	}
+
+	want := LatencyStats{Samples: 3, P50Ms: 2000, P90Ms: 3000, P99Ms: 3000, MaxMs: 3000}
+	if report.Latency != want {
+		t.Errorf("latency = %+v, want %+v", report.Latency, want)
+	}
+
+	if len(report.BidsPerHour) != 3 {
+		t.Fatalf("bids per hour = %+v, want the 3 hours of the period", report.BidsPerHour)
+	}
+	for i, bids := range []int{3, 1, 0} {
+		if h := report.BidsPerHour[i]; h.Bids != bids || !h.Hour.Equal(reportStart.Add(time.Duration(i)*time.Hour)) {
+			t.Errorf("hour %d = %+v, want %d bids", i, h, bids)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var values []time.Duration
+	for i := 1; i <= 10; i++ {
+		values = append(values, time.Duration(i))
+	}
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{1, 1},
+		{10, 1},
+		{11, 2},
+		{50, 5},
+		{90, 9},
+		{91, 10},
+		{99, 10},
+		{100, 10},
+	}
+	for _, tt := range tests {
+		if got := percentile(values, tt.p); got != tt.want {
+			t.Errorf("percentile(1..10, %d) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile([]time.Duration{7}, 50); got != 7 {
+		t.Errorf("percentile of one value = %d, want 7", got)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	BIDS_FILE_NAME       = "bids.jsonl"
+	BID_MEMORY_RETENTION = 48 * time.Hour
+)
+
+// BidRecord is one bid or fixed-price application made by the bot, or
+// simulated by it during a dry run.
+type BidRecord struct {
+	OrderURL   string    `json:"order_url"`
+	Thread     int       `json:"thread"`
+	Amount     Money     `json:"amount"`
+	FixedPrice bool      `json:"fixed_price"`
+	Message    string    `json:"message,omitempty"`
+	Simulated  bool      `json:"simulated,omitempty"`
+	PlacedAt   time.Time `json:"placed_at"`
+
+	// Order details kept for the analytics report.
+	ServiceType   string    `json:"service_type,omitempty"`
+	DeadlineHours float64   `json:"deadline_hours,omitempty"`
+	Pages         int       `json:"pages,omitempty"`
+	Strategy      string    `json:"strategy,omitempty"`
+	DiscoveredAt  time.Time `json:"discovered_at"`
+}
+
+// BidLedger keeps recent bids in memory for cap checks and appends every
+// bid to sysfiles/bids.jsonl.
+type BidLedger struct {
+	mu      sync.Mutex
+	records []BidRecord
+}
+
+var bidLedger = &BidLedger{}
+
+func getBidsFilePath() string {
+	return filepath.Join(getSysfilesDir(), BIDS_FILE_NAME)
+}
+
+// Load reads recent bids from disk so caps survive a restart.
+func (l *BidLedger) Load() {
+	records, err := readBidHistory(time.Now().Add(-BID_MEMORY_RETENTION))
+	if err != nil {
+		debugLogger.Printf("Bid ledger read error: %v", err)
+	}
+
+	l.mu.Lock()
+	l.records = records
+	l.mu.Unlock()
+}
+
+// Record stores a new bid in memory and on disk.
+func (l *BidLedger) Record(rec BidRecord) {
+	if rec.PlacedAt.IsZero() {
+		rec.PlacedAt = time.Now()
+	}
+
+	l.mu.Lock()
+	cutoff := time.Now().Add(-BID_MEMORY_RETENTION)
+	kept := l.records[:0]
+	for _, r := range l.records {
+		if r.PlacedAt.After(cutoff) {
+			kept = append(kept, r)
+		}
+	}
+	l.records = append(kept, rec)
+	l.mu.Unlock()
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		debugLogger.Printf("Bid record marshal error: %v", err)
+		return
+	}
+	f, err := os.OpenFile(getBidsFilePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		debugLogger.Printf("Bid ledger write error: %v", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+// Records returns a copy of the bids in memory of the given kind.
+func (l *BidLedger) Records(simulated bool) []BidRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var records []BidRecord
+	for _, r := range l.records {
+		if r.Simulated == simulated {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// Since returns the number and total value of bids placed at or after t.
+// Only records of the given kind count, so dry runs and live runs never
+// use up each other's caps.
+func (l *BidLedger) Since(t time.Time, simulated bool) (int, Money) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	count, value := 0, Money(0)
+	for _, r := range l.records {
+		if r.Simulated == simulated && !r.PlacedAt.Before(t) {
+			count++
+			value += r.Amount
+		}
+	}
+	return count, value
+}
+
+// readBidHistory reads every bid placed after since from disk.
+func readBidHistory(since time.Time) ([]BidRecord, error) {
+	f, err := os.Open(getBidsFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []BidRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec BidRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			debugLogger.Printf("Bid ledger skipping bad line: %v", err)
+			continue
+		}
+		if rec.PlacedAt.After(since) {
+			records = append(records, rec)
+		}
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdLog "log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	DEFAULT_BENCHMARK_ROUNDS = 5
+	MAX_BENCHMARK_ROUNDS     = 20
+	BENCHMARK_LOAD_TIMEOUT   = 30 * time.Second
+
+	// SAMPLE_ORDER_PAGE_URL stands for every order page when checking that
+	// URL patterns leave the site's pages alone.
+	SAMPLE_ORDER_PAGE_URL = ORDERS_PAGE_URL + "123456789.html"
+)
+
+// RequestBlocking lists the requests pages may not make. URL patterns use
+// CDP wildcards: '*' is any run of characters and '?' one character.
+type RequestBlocking struct {
+	Enabled       bool     `json:"enabled"`
+	ResourceTypes []string `json:"resource_types"`
+	URLPatterns   []string `json:"url_patterns"`
+}
+
+func defaultRequestBlocking() RequestBlocking {
+	return RequestBlocking{
+		ResourceTypes: []string{
+			string(network.ResourceTypeImage),
+			string(network.ResourceTypeFont),
+			string(network.ResourceTypeMedia),
+		},
+		URLPatterns: []string{
+			"*google-analytics.com*",
+			"*googletagmanager.com*",
+			"*doubleclick.net*",
+			"*connect.facebook.net*",
+			"*hotjar.com*",
+		},
+	}
+}
+
+// blockableResourceTypes are the resource types that may be blocked.
+// Documents are left out: blocking them would block the pages themselves.
+var blockableResourceTypes = []network.ResourceType{
+	network.ResourceTypeStylesheet,
+	network.ResourceTypeImage,
+	network.ResourceTypeMedia,
+	network.ResourceTypeFont,
+	network.ResourceTypeScript,
+	network.ResourceTypeTextTrack,
+	network.ResourceTypeXHR,
+	network.ResourceTypeFetch,
+	network.ResourceTypePrefetch,
+	network.ResourceTypeEventSource,
+	network.ResourceTypeWebSocket,
+	network.ResourceTypeManifest,
+	network.ResourceTypePing,
+	network.ResourceTypeOther,
+}
+
+// riskyResourceTypes may be blocked, but the site's pages need them to work.
+var riskyResourceTypes = map[network.ResourceType]string{
+	network.ResourceTypeScript: "the site's own JavaScript, which the bid form runs on",
+	network.ResourceTypeXHR:    "the requests the site's pages make in the background, such as checking a bid",
+	network.ResourceTypeFetch:  "the requests the site's pages make in the background, such as checking a bid",
+}
+
+// sitePages are the pages the bot loads, which no URL pattern may block.
+var sitePages = []string{ORDERS_PAGE_URL, SAMPLE_ORDER_PAGE_URL, MY_BIDS_PAGE_URL, ACTIVE_ORDERS_PAGE_URL, LOGIN_PAGE_URL}
+
+// Validate normalizes the resource type names and rejects patterns that
+// would block the site's own pages.
+func (r *RequestBlocking) Validate() error {
+	for i, name := range r.ResourceTypes {
+		found := false
+		for _, t := range blockableResourceTypes {
+			if strings.EqualFold(name, string(t)) {
+				r.ResourceTypes[i] = string(t)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown or unblockable resource type %q", name)
+		}
+	}
+	for _, pattern := range r.URLPatterns {
+		for _, page := range sitePages {
+			if wildcardMatch(pattern, page) {
+				return fmt.Errorf("URL pattern %q would block %s", pattern, page)
+			}
+		}
+	}
+	return nil
+}
+
+// Warnings describes the blocked resource types that are likely to break
+// the site. Call it after Validate.
+func (r RequestBlocking) Warnings() []string {
+	var warnings []string
+	for _, name := range r.ResourceTypes {
+		if what, ok := riskyResourceTypes[network.ResourceType(name)]; ok {
+			warnings = append(warnings, fmt.Sprintf("Blocking %s blocks %s.", name, what))
+		}
+	}
+	return warnings
+}
+
+func (r RequestBlocking) active() bool {
+	return r.Enabled && len(r.ResourceTypes)+len(r.URLPatterns) > 0
+}
+
+func (r RequestBlocking) patterns() []*fetch.RequestPattern {
+	var patterns []*fetch.RequestPattern
+	for _, t := range r.ResourceTypes {
+		patterns = append(patterns, &fetch.RequestPattern{ResourceType: network.ResourceType(t)})
+	}
+	for _, p := range r.URLPatterns {
+		patterns = append(patterns, &fetch.RequestPattern{URLPattern: p})
+	}
+	return patterns
+}
+
+// wildcardMatch matches s against a CDP wildcard pattern.
+func wildcardMatch(pattern, s string) bool {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	re, err := regexp.Compile(expr.String())
+	return err == nil && re.MatchString(s)
+}
+
+// requestBlocker fails the requests of a page that match its rules. Every
+// page has one; rules are applied with apply.
+type requestBlocker struct {
+	blocked atomic.Int64
+}
+
+type blockerKey struct{}
+
+// newRequestBlocker starts failing the paused requests of the page in
+// tabCtx. Only requests matching the applied rules are paused.
+func newRequestBlocker(tabCtx context.Context) *requestBlocker {
+	b := &requestBlocker{}
+	chromedp.ListenTarget(tabCtx, func(ev interface{}) {
+		if ev, ok := ev.(*fetch.EventRequestPaused); ok {
+			b.blocked.Add(1)
+			go func() {
+				if err := chromedp.Run(tabCtx, fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient)); err != nil && tabCtx.Err() == nil {
+					debugLogger.Printf("Blocking %s failed: %v", ev.Request.URL, err)
+				}
+			}()
+		}
+	})
+	return b
+}
+
+// apply replaces the rules of the page in ctx; inactive rules turn
+// blocking off.
+func (b *requestBlocker) apply(ctx context.Context, rules RequestBlocking) error {
+	if !rules.active() {
+		return chromedp.Run(ctx, fetch.Disable())
+	}
+	return chromedp.Run(ctx, fetch.Enable().WithPatterns(rules.patterns()))
+}
+
+func withBlocker(ctx context.Context, b *requestBlocker) context.Context {
+	return context.WithValue(ctx, blockerKey{}, b)
+}
+
+// blockerFrom returns the request blocker of the page in ctx, or nil.
+func blockerFrom(ctx context.Context) *requestBlocker {
+	b, _ := ctx.Value(blockerKey{}).(*requestBlocker)
+	return b
+}
+
+// LoadBenchmark holds the page-ready times of the orders page with and
+// without request blocking.
+type LoadBenchmark struct {
+	Without []time.Duration
+	With    []time.Duration
+	Blocked int64 // requests blocked over all loads with blocking
+}
+
+func median(ds []time.Duration) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+func (b LoadBenchmark) String() string {
+	without, with := median(b.Without), median(b.With)
+	text := fmt.Sprintf("Orders page ready, median of %d loads: %v without blocking, %v with blocking",
+		len(b.With), without.Round(time.Millisecond), with.Round(time.Millisecond))
+	if without > 0 {
+		text += fmt.Sprintf(" (%.0f%% faster)", 100*float64(without-with)/float64(without))
+	}
+	if len(b.With) > 0 {
+		text += fmt.Sprintf(", %d requests blocked per load", b.Blocked/int64(len(b.With)))
+	}
+	return text + "."
+}
+
+// benchmarkPage returns a page to benchmark in: a tab of the running
+// pool, or a page of a browser started for the benchmark.
+func benchmarkPage(ctx context.Context) (context.Context, func(), error) {
+	if pool := sharedPool; pool != nil {
+		pageCtx, release, err := pool.Lease(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pageCtx, func() { release(false) }, nil
+	}
+	var browser Browser = chromeBrowser{opts: chromeOptions(chrome.Path)}
+	if browserMode() == BROWSER_MODE_REMOTE {
+		browser = remoteBrowser
+	}
+	pageCtx, cancel, err := browser.NewPage(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pageCtx, cancel, nil
+}
+
+// runLoadBenchmark loads the orders page rounds times with and rounds
+// times without the configured blocking, alternating so both see the same
+// conditions, with the browser cache off. It needs the shared session, so
+// the bot must have logged in since the app started.
+func runLoadBenchmark(ctx context.Context, rounds int) (LoadBenchmark, error) {
+	var result LoadBenchmark
+	rules := cfg.RequestBlocking
+	rules.Enabled = true
+	if !rules.active() {
+		return result, errors.New("no resource types or URL patterns to block")
+	}
+	_, cookies := session.Current()
+	if len(cookies) == 0 {
+		return result, errors.New("no session yet: start the bot once so it logs in")
+	}
+
+	pageCtx, release, err := benchmarkPage(ctx)
+	if err != nil {
+		return result, fmt.Errorf("error opening benchmark page: %w", err)
+	}
+	defer release()
+	blocker := blockerFrom(pageCtx)
+	if blocker == nil {
+		return result, errors.New("page has no request blocker")
+	}
+	defer blocker.apply(pageCtx, cfg.RequestBlocking)
+	if err := setSiteCookies(pageCtx, cookies); err != nil {
+		return result, err
+	}
+	if err := chromedp.Run(pageCtx, network.Enable(), network.SetCacheDisabled(true)); err != nil {
+		return result, fmt.Errorf("error disabling cache: %w", err)
+	}
+	defer chromedp.Run(pageCtx, network.SetCacheDisabled(false))
+
+	load := func(rules RequestBlocking) (time.Duration, error) {
+		if err := blocker.apply(pageCtx, rules); err != nil {
+			return 0, fmt.Errorf("error applying blocking rules: %w", err)
+		}
+		if !scheduler.AcquirePageLoad(ctx) {
+			return 0, ctx.Err()
+		}
+		loadCtx, cancel := context.WithTimeout(pageCtx, BENCHMARK_LOAD_TIMEOUT)
+		defer cancel()
+		start := time.Now()
+		if err := loadPage(loadCtx, StageList, ORDERS_PAGE_URL); err != nil {
+			return 0, err
+		}
+		if err := pageFrom(pageCtx).WaitVisible(loadCtx, `#available_orders_list_container`); err != nil {
+			return 0, err
+		}
+		return time.Since(start), nil
+	}
+
+	for i := 0; i < rounds; i++ {
+		d, err := load(RequestBlocking{})
+		if err != nil {
+			return result, fmt.Errorf("error loading without blocking: %w", err)
+		}
+		result.Without = append(result.Without, d)
+
+		before := blocker.blocked.Load()
+		d, err = load(rules)
+		if err != nil {
+			return result, fmt.Errorf("error loading with blocking: %w", err)
+		}
+		result.With = append(result.With, d)
+		result.Blocked += blocker.blocked.Load() - before
+	}
+	return result, nil
+}
+
+func splitList(text string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// blockingContent is the Page Loads screen: blocking rules and the
+// benchmark.
+func blockingContent(w fyne.Window) fyne.CanvasObject {
+	enabledCheck := widget.NewCheck("Block Requests (applies to pages opened after saving)", func(v bool) {})
+	enabledCheck.SetChecked(cfg.RequestBlocking.Enabled)
+
+	typesEntry := widget.NewEntry()
+	typesEntry.SetPlaceHolder("Image, Font, Media")
+	typesEntry.SetText(strings.Join(cfg.RequestBlocking.ResourceTypes, ", "))
+
+	patternsArea := widget.NewMultiLineEntry()
+	patternsArea.SetPlaceHolder("*google-analytics.com*")
+	patternsArea.SetText(strings.Join(cfg.RequestBlocking.URLPatterns, "\n"))
+
+	saveButton := widget.NewButton("Save Blocking Rules", func() {
+		rules := RequestBlocking{
+			Enabled:       enabledCheck.Checked,
+			ResourceTypes: splitList(typesEntry.Text),
+			URLPatterns:   splitList(patternsArea.Text),
+		}
+		if err := rules.Validate(); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		save := func() {
+			cfg.RequestBlocking = rules
+			typesEntry.SetText(strings.Join(rules.ResourceTypes, ", "))
+			saveConfig()
+			dialog.ShowInformation("Settings Saved", "The blocking rules have been saved.", w)
+		}
+		if warnings := rules.Warnings(); len(warnings) > 0 {
+			dialog.ShowConfirm("Blocking May Break the Site", strings.Join(warnings, "\n")+"\n\nSave these rules anyway?", func(ok bool) {
+				if ok {
+					save()
+				}
+			}, w)
+			return
+		}
+		save()
+	})
+
+	roundsEntry := widget.NewEntry()
+	roundsEntry.SetText(strconv.Itoa(DEFAULT_BENCHMARK_ROUNDS))
+	resultLabel := widget.NewLabel("")
+	resultLabel.Wrapping = fyne.TextWrapWord
+	var benchmarkButton *widget.Button
+	benchmarkButton = widget.NewButton("Run Benchmark", func() {
+		rounds, err := strconv.Atoi(roundsEntry.Text)
+		if err != nil || rounds < 1 || rounds > MAX_BENCHMARK_ROUNDS {
+			dialog.ShowError(fmt.Errorf("rounds must be between 1 and %d", MAX_BENCHMARK_ROUNDS), w)
+			return
+		}
+		benchmarkButton.Disable()
+		resultLabel.SetText(fmt.Sprintf("Loading the orders page %d times...", 2*rounds))
+		go func() {
+			result, err := runLoadBenchmark(context.Background(), rounds)
+			if err != nil {
+				stdLog.Printf("Page load benchmark failed: %v", err)
+				debugLogger.Printf("Benchmark error: %v", err)
+			} else {
+				stdLog.Printf("Page load benchmark: %s", result)
+				debugLogger.Printf("Benchmark without %v, with %v", result.Without, result.With)
+			}
+			fyne.Do(func() {
+				if err != nil {
+					resultLabel.SetText(fmt.Sprintf("Benchmark failed: %v", err))
+				} else {
+					resultLabel.SetText(result.String())
+				}
+				benchmarkButton.Enable()
+			})
+		}()
+	})
+
+	return container.NewVBox(
+		enabledCheck,
+		widget.NewLabel("Resource Types (comma separated, e.g. Image, Font, Media, Stylesheet, Script):"), typesEntry,
+		widget.NewLabel("URL Patterns (one per line, * and ? wildcards):"), patternsArea,
+		saveButton,
+		widget.NewSeparator(),
+		widget.NewLabel("Benchmark: time until the orders page is ready, with and without the rules above."),
+		container.NewBorder(nil, nil, widget.NewLabel("Rounds:"), benchmarkButton, roundsEntry),
+		resultLabel,
+	)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*.png", "https://cdn.example.com/a.png", true},
+		{"*.png", "https://cdn.example.com/a.png?v=2", false},
+		{"*.png*", "https://cdn.example.com/a.png?v=2", true},
+		{"*google-analytics.com/*", "https://www.google-analytics.com/collect", true},
+		{"https://cdn.example.com/*", "http://cdn.example.com/a.js", false},
+		{"*/img?.gif", "https://example.com/img1.gif", true},
+		{"*/img?.gif", "https://example.com/img12.gif", false},
+		{"*.js", "https://example.com/ajs", false},        // the dot is literal
+		{`*\?v=2`, "https://example.com/a.css?v=2", true}, // escaped wildcard
+		{`*\?v=2`, "https://example.com/a.cssxv=2", false},
+		{"*", "", true},
+	}
+	for _, tt := range tests {
+		if got := wildcardMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("wildcardMatch(%q, %q) = %t, want %t", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRequestBlockingValidate(t *testing.T) {
+	rules := RequestBlocking{ResourceTypes: []string{"image", "FONT"}, URLPatterns: []string{"*doubleclick.net/*"}}
+	if err := rules.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if got := strings.Join(rules.ResourceTypes, ","); got != "Image,Font" {
+		t.Errorf("resource types = %s, want the names CDP uses", got)
+	}
+	if warnings := rules.Warnings(); len(warnings) != 0 {
+		t.Errorf("warnings = %q, want none", warnings)
+	}
+
+	tests := []struct {
+		name  string
+		rules RequestBlocking
+	}{
+		{"unknown type", RequestBlocking{ResourceTypes: []string{"Video"}}},
+		{"page type", RequestBlocking{ResourceTypes: []string{"Document"}}},
+		{"order list", RequestBlocking{URLPatterns: []string{"*essayshark.com/writer/*"}}},
+		{"order page", RequestBlocking{URLPatterns: []string{"*/writer/orders/*.html"}}},
+		{"login page", RequestBlocking{URLPatterns: []string{"*/log-in.*"}}},
+	}
+	for _, tt := range tests {
+		if err := tt.rules.Validate(); err == nil {
+			t.Errorf("%s: Validate succeeded", tt.name)
+		}
+	}
+}
+
+func TestRequestBlockingWarnings(t *testing.T) {
+	rules := RequestBlocking{ResourceTypes: []string{"script", "Image", "xhr", "Fetch"}}
+	if err := rules.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	warnings := rules.Warnings()
+	if len(warnings) != 3 {
+		t.Fatalf("warnings = %q, want one each for Script, XHR and Fetch", warnings)
+	}
+	for i, name := range []string{"Script", "XHR", "Fetch"} {
+		if !strings.Contains(warnings[i], name) {
+			t.Errorf("warning %d = %q, want it to name %s", i, warnings[i], name)
+		}
+	}
+}
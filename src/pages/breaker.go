@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	stdLog "log"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_BREAKER_THRESHOLD    = 5
+	DEFAULT_BREAKER_COOLDOWN_SEC = 300
+	BREAKER_PAUSE_KEY            = "breaker"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+var stageRank = map[Stage]int{StageList: 0, StageOpen: 1, StageBid: 2, StageMessage: 3}
+
+// CircuitBreaker is shared by all workers. It counts consecutive failures
+// of the same class per stage and pauses the bot once a count reaches the
+// configured threshold. After the cooldown a single worker is let through
+// to probe; its outcome either closes the breaker or re-opens it.
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	state    breakerState
+	counts   map[Stage]int
+	classes  map[Stage]error
+	tripped  *PipelineError
+	probing  bool
+	reopenAt *time.Timer
+}
+
+var breaker = newCircuitBreaker()
+
+func newCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		counts:  make(map[Stage]int),
+		classes: make(map[Stage]error),
+	}
+}
+
+// countsTowardBreaker reports whether a failure class points at a systemic
+// problem. Gone orders, rejected bids, capped bids and orders skipped by
+// the decision script are normal per-order outcomes, a failing script
+// has its own alert and is no reason to stop scanning, and a stage cut
+// short by a stop did not fail.
+func countsTowardBreaker(class error) bool {
+	switch class {
+	case ErrOrderGone, ErrBidRejected, ErrCapReached, ErrScriptSkip, ErrScriptFailed, ErrStopped:
+		return false
+	}
+	return true
+}
+
+// Allow reports whether a worker may start another iteration. While the
+// breaker is half-open only one worker at a time gets through; it must call
+// Release when its iteration ends.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		return false
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// Release ends a half-open probe iteration so the next one can start.
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	if b.state == breakerHalfOpen {
+		b.probing = false
+	}
+	b.mu.Unlock()
+}
+
+// RecordSuccess notes that stage completed normally.
+func (b *CircuitBreaker) RecordSuccess(stage Stage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.counts[stage] = 0
+	delete(b.classes, stage)
+	if b.state == breakerHalfOpen && stageRank[stage] >= stageRank[b.tripped.Stage] {
+		stdLog.Printf("Circuit breaker closed: %s stage is working again.", stage)
+		debugLogger.Printf("Breaker closed after successful %s probe.", stage)
+		b.state = breakerClosed
+		b.tripped = nil
+		b.probing = false
+	}
+}
+
+// RecordFailure counts a final (post-retry) failure and trips the breaker
+// when the threshold is reached.
+func (b *CircuitBreaker) RecordFailure(err error) {
+	var pe *PipelineError
+	if !errors.As(err, &pe) || !countsTowardBreaker(pe.Class) {
+		return
+	}
+
+	b.mu.Lock()
+	var why string
+	switch b.state {
+	case breakerOpen:
+		b.mu.Unlock()
+		return
+	case breakerHalfOpen:
+		if pe.Stage != b.tripped.Stage {
+			b.mu.Unlock()
+			return
+		}
+		why = "probe failed"
+	default:
+		if b.classes[pe.Stage] != pe.Class {
+			b.classes[pe.Stage] = pe.Class
+			b.counts[pe.Stage] = 0
+		}
+		b.counts[pe.Stage]++
+		count := b.counts[pe.Stage]
+		threshold := breakerThreshold()
+		debugLogger.Printf("Breaker: %d/%d consecutive %v failures in %s stage.", count, threshold, pe.Class, pe.Stage)
+		if count < threshold {
+			b.mu.Unlock()
+			return
+		}
+		why = fmt.Sprintf("%d consecutive failures", count)
+	}
+	// Opening under the same lock as the count means only one of several
+	// workers failing at once trips the breaker and raises the alert.
+	cooldown := b.open(pe)
+	b.mu.Unlock()
+
+	where := fmt.Sprintf("%s stage", pe.Stage)
+	if pe.Selector != "" {
+		where += fmt.Sprintf(", selector %s", pe.Selector)
+	}
+	pauseBot(BREAKER_PAUSE_KEY, fmt.Sprintf("circuit breaker open (%v in %s)", pe.Class, where))
+	raiseAlert(EventBreakerTripped, "Circuit Breaker Tripped",
+		fmt.Sprintf("%s: %v in %s. Bot paused, retrying in %v.\n\nLast error: %v", why, pe.Class, where, cooldown, pe.Err))
+}
+
+// open trips the breaker on pe and returns the cooldown before the probe.
+// b.mu must be held.
+func (b *CircuitBreaker) open(pe *PipelineError) time.Duration {
+	cooldown := breakerCooldown()
+	b.state = breakerOpen
+	b.tripped = pe
+	b.probing = false
+	b.counts = make(map[Stage]int)
+	b.classes = make(map[Stage]error)
+	if b.reopenAt != nil {
+		b.reopenAt.Stop()
+	}
+	b.reopenAt = time.AfterFunc(cooldown, b.halfOpen)
+	return cooldown
+}
+
+func (b *CircuitBreaker) halfOpen() {
+	b.mu.Lock()
+	if b.state != breakerOpen {
+		b.mu.Unlock()
+		return
+	}
+	b.state = breakerHalfOpen
+	b.probing = false
+	stage := b.tripped.Stage
+	b.mu.Unlock()
+
+	stdLog.Printf("Circuit breaker half-open: letting one worker probe the %s stage.", stage)
+	debugLogger.Printf("Breaker half-open for %s stage.", stage)
+	resumeBot(BREAKER_PAUSE_KEY)
+}
+
+// Reset closes the breaker and forgets all counts, e.g. when the bot is
+// restarted by the user.
+func (b *CircuitBreaker) Reset() {
+	b.mu.Lock()
+	if b.reopenAt != nil {
+		b.reopenAt.Stop()
+		b.reopenAt = nil
+	}
+	b.state = breakerClosed
+	b.tripped = nil
+	b.probing = false
+	b.counts = make(map[Stage]int)
+	b.classes = make(map[Stage]error)
+	b.mu.Unlock()
+	resumeBot(BREAKER_PAUSE_KEY)
+}
+
+func breakerThreshold() int {
+	if cfg.BreakerThreshold <= 0 {
+		return DEFAULT_BREAKER_THRESHOLD
+	}
+	return cfg.BreakerThreshold
+}
+
+func breakerCooldown() time.Duration {
+	if cfg.BreakerCooldownSec <= 0 {
+		return DEFAULT_BREAKER_COOLDOWN_SEC * time.Second
+	}
+	return time.Duration(cfg.BreakerCooldownSec) * time.Second
+}
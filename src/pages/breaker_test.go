@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	stdLog "log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// setupBreakerTest starts from a closed breaker with a threshold of 3 and
+// returns the bot's log output.
+func setupBreakerTest(t *testing.T) *bytes.Buffer {
+	setupOrderTest(t)
+	cfg.BreakerThreshold = 3
+	t.Cleanup(breaker.Reset)
+
+	var logs bytes.Buffer
+	stdLog.SetOutput(&logs)
+	t.Cleanup(func() { stdLog.SetOutput(os.Stderr) })
+	return &logs
+}
+
+func stageFailure(stage Stage, class error) error {
+	return &PipelineError{Stage: stage, Class: class, Err: errors.New("boom")}
+}
+
+func TestCountsTowardBreaker(t *testing.T) {
+	for _, class := range []error{ErrOrderGone, ErrBidRejected, ErrCapReached, ErrScriptSkip, ErrScriptFailed} {
+		if countsTowardBreaker(class) {
+			t.Errorf("%v counts toward the breaker", class)
+		}
+	}
+	if !countsTowardBreaker(ErrTimeout) {
+		t.Errorf("%v does not count toward the breaker", ErrTimeout)
+	}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	setupBreakerTest(t)
+	b := breaker
+
+	// Failures of another class, or an ignored one, restart the count.
+	b.RecordFailure(stageFailure(StageOpen, ErrTimeout))
+	b.RecordFailure(stageFailure(StageOpen, ErrTimeout))
+	b.RecordFailure(stageFailure(StageOpen, ErrOrderGone))
+	b.RecordFailure(stageFailure(StageOpen, ErrBidRejected)) // not counted
+	b.RecordSuccess(StageOpen)
+	b.RecordFailure(stageFailure(StageOpen, ErrTimeout))
+	b.RecordFailure(stageFailure(StageOpen, ErrTimeout))
+	if b.state != breakerClosed || isPaused() {
+		t.Fatalf("state = %d, paused = %t; want closed below the threshold", b.state, isPaused())
+	}
+
+	b.RecordFailure(stageFailure(StageOpen, ErrTimeout))
+	if b.state != breakerOpen || !isPaused() || b.Allow() {
+		t.Fatalf("state = %d, paused = %t; want open and paused at the threshold", b.state, isPaused())
+	}
+
+	// After the cooldown one worker probes at a time.
+	b.halfOpen()
+	if b.state != breakerHalfOpen || isPaused() {
+		t.Fatalf("state = %d, paused = %t; want half-open and running", b.state, isPaused())
+	}
+	if !b.Allow() || b.Allow() {
+		t.Fatal("want exactly one probe let through")
+	}
+	b.Release()
+	if !b.Allow() {
+		t.Fatal("no probe let through after Release")
+	}
+
+	// A failing probe re-opens the breaker...
+	b.RecordFailure(stageFailure(StageList, ErrTimeout)) // another stage, ignored
+	if b.state != breakerHalfOpen {
+		t.Fatalf("state = %d after a failure in another stage, want half-open", b.state)
+	}
+	b.RecordFailure(stageFailure(StageOpen, ErrTimeout))
+	if b.state != breakerOpen || !isPaused() {
+		t.Fatalf("state = %d after a failed probe, want open", b.state)
+	}
+
+	// ...and only getting past the tripped stage closes it.
+	b.halfOpen()
+	b.Allow()
+	b.RecordSuccess(StageList)
+	if b.state != breakerHalfOpen {
+		t.Fatalf("state = %d after an earlier stage worked, want half-open", b.state)
+	}
+	b.RecordSuccess(StageBid)
+	if b.state != breakerClosed || !b.Allow() || !b.Allow() {
+		t.Fatalf("state = %d after the probe got past the stage, want closed", b.state)
+	}
+}
+
+func TestBreakerTripsOnce(t *testing.T) {
+	logs := setupBreakerTest(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			breaker.RecordFailure(stageFailure(StageBid, ErrTimeout))
+		}()
+	}
+	wg.Wait()
+
+	if got := strings.Count(logs.String(), "ALERT: Circuit Breaker Tripped"); got != 1 {
+		t.Errorf("breaker tripped %d times, want once", got)
+	}
+}
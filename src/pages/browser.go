@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	chromelog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// CONSOLE_BUFFER_SIZE is how many console messages a page keeps for
+// failure snapshots.
+const CONSOLE_BUFFER_SIZE = 100
+
+// Page is one browser tab, as far as the bidding logic needs it. Selectors
+// are CSS selectors. Text and Click wait for the node to be visible.
+type Page interface {
+	Navigate(ctx context.Context, url string) error
+	WaitReady(ctx context.Context, selector string) error
+	WaitVisible(ctx context.Context, selector string) error
+	Evaluate(ctx context.Context, expression string, result interface{}) error
+	Text(ctx context.Context, selector string) (string, error)
+	SetValue(ctx context.Context, selector, value string) error
+	Click(ctx context.Context, selector string) error
+	// Screenshot returns a PNG of the whole page.
+	Screenshot(ctx context.Context) ([]byte, error)
+}
+
+// Browser opens pages. The context NewPage returns carries the page, the
+// same way a chromedp context carries its tab, and cancel closes it.
+type Browser interface {
+	NewPage(ctx context.Context) (context.Context, context.CancelFunc, error)
+}
+
+type pageKey struct{}
+
+// withPage returns a context whose page is p.
+func withPage(ctx context.Context, p Page) context.Context {
+	return context.WithValue(ctx, pageKey{}, p)
+}
+
+// pageFrom returns the page ctx carries. A context without one is assumed
+// to be a chromedp tab context.
+func pageFrom(ctx context.Context) Page {
+	if p, ok := ctx.Value(pageKey{}).(Page); ok {
+		return p
+	}
+	return chromedpPage{}
+}
+
+// consoleBuffer keeps the latest console messages of a page.
+type consoleBuffer struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (b *consoleBuffer) add(level, text string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lines = append(b.lines, fmt.Sprintf("%s [%s] %s", time.Now().Format("15:04:05.000"), level, text))
+	if len(b.lines) > CONSOLE_BUFFER_SIZE {
+		b.lines = b.lines[len(b.lines)-CONSOLE_BUFFER_SIZE:]
+	}
+}
+
+// recent returns the buffered messages, oldest first.
+func (b *consoleBuffer) recent() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.lines...)
+}
+
+type consoleKey struct{}
+
+func withConsole(ctx context.Context, b *consoleBuffer) context.Context {
+	return context.WithValue(ctx, consoleKey{}, b)
+}
+
+// consoleFrom returns the console buffer of the page in ctx, or nil.
+func consoleFrom(ctx context.Context) *consoleBuffer {
+	b, _ := ctx.Value(consoleKey{}).(*consoleBuffer)
+	return b
+}
+
+// consoleArgs joins the arguments of a console API call the way the
+// DevTools console would show them.
+func consoleArgs(args []*runtime.RemoteObject) string {
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch {
+		case arg.Value != nil:
+			parts = append(parts, strings.Trim(string(arg.Value), `"`))
+		case arg.Description != "":
+			parts = append(parts, arg.Description)
+		default:
+			parts = append(parts, string(arg.Type))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// chromedpPage runs each call on the chromedp tab of the context it gets.
+type chromedpPage struct{}
+
+func (chromedpPage) Navigate(ctx context.Context, url string) error {
+	return chromedp.Run(ctx, chromedp.Navigate(url))
+}
+
+func (chromedpPage) WaitReady(ctx context.Context, selector string) error {
+	return chromedp.Run(ctx, chromedp.WaitReady(selector, chromedp.ByQuery))
+}
+
+func (chromedpPage) WaitVisible(ctx context.Context, selector string) error {
+	return chromedp.Run(ctx, chromedp.WaitVisible(selector, chromedp.ByQuery))
+}
+
+func (chromedpPage) Evaluate(ctx context.Context, expression string, result interface{}) error {
+	return chromedp.Run(ctx, chromedp.Evaluate(expression, result))
+}
+
+func (chromedpPage) Text(ctx context.Context, selector string) (string, error) {
+	var text string
+	err := chromedp.Run(ctx, chromedp.Text(selector, &text, chromedp.NodeVisible, chromedp.ByQuery))
+	return text, err
+}
+
+func (chromedpPage) SetValue(ctx context.Context, selector, value string) error {
+	return chromedp.Run(ctx, chromedp.SetValue(selector, value, chromedp.ByQuery))
+}
+
+func (chromedpPage) Click(ctx context.Context, selector string) error {
+	return chromedp.Run(ctx, chromedp.Click(selector, chromedp.NodeVisible, chromedp.ByQuery))
+}
+
+func (chromedpPage) Screenshot(ctx context.Context) ([]byte, error) {
+	var buf []byte
+	err := chromedp.Run(ctx, chromedp.FullScreenshot(&buf, 100))
+	return buf, err
+}
+
+// chromeBrowser launches one Chrome process per page with the given
+// options.
+type chromeBrowser struct {
+	opts []chromedp.ExecAllocatorOption
+}
+
+func (b chromeBrowser) NewPage(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	allocCtx, allocCancel := chromedp.NewExecAllocator(ctx, b.opts...)
+	taskCtx, taskCancel, err := openTab(allocCtx)
+	if err != nil {
+		allocCancel()
+		return nil, nil, fmt.Errorf("error starting Chrome: %w", err)
+	}
+	unregister := registerBrowser(taskCtx)
+	return taskCtx, func() {
+		unregister()
+		taskCancel()
+		allocCancel()
+	}, nil
+}
+
+// openTab opens a page on parent: a new browser if parent is an allocator
+// context, a new tab if it is a page of a running browser.
+func openTab(parent context.Context) (context.Context, context.CancelFunc, error) {
+	tabCtx, cancel := chromedp.NewContext(parent,
+		chromedp.WithLogf(debugLogger.Printf),
+	)
+	if err := chromedp.Run(tabCtx); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	console := &consoleBuffer{}
+	chromedp.ListenTarget(tabCtx, func(ev interface{}) {
+		switch ev := ev.(type) {
+		case *chromelog.EventEntryAdded:
+			debugLogger.Printf("Chromedp Log: %s", ev.Entry.Text)
+			console.add(string(ev.Entry.Level), ev.Entry.Text)
+		case *runtime.EventConsoleAPICalled:
+			console.add(string(ev.Type), consoleArgs(ev.Args))
+		}
+	})
+	blocker := newRequestBlocker(tabCtx)
+	if err := blocker.apply(tabCtx, cfg.RequestBlocking); err != nil {
+		debugLogger.Printf("Request blocking not applied: %v", err)
+	}
+	return withBlocker(withConsole(tabCtx, console), blocker), cancel, nil
+}
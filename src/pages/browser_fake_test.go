@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+)
+
+// fakePage is a scriptable in-memory Page. A test describes the document
+// of each URL with fakeDocument; Navigate switches to it. Nodes exist when
+// they have a text or a value, clicks run the handler set for the selector,
+// and Evaluate answers from the first result whose substring the expression
+// contains, in the order they were scripted.
+type fakePage struct {
+	mu      sync.Mutex
+	docs    map[string]*fakeDocument
+	doc     *fakeDocument
+	url     string
+	actions []string
+}
+
+type fakeDocument struct {
+	texts   map[string]string
+	values  map[string]string
+	onClick map[string]func(d *fakeDocument)
+	evals   []fakeEval
+	// fail holds the outcomes of the next actions with the given key, e.g.
+	// "click #apply_order"; a nil entry lets that action succeed. Actions
+	// succeed once their queue is empty.
+	fail map[string][]error
+}
+
+type fakeEval struct {
+	substring string
+	fn        func(d *fakeDocument) interface{}
+}
+
+func newFakeDocument() *fakeDocument {
+	return &fakeDocument{
+		texts:   make(map[string]string),
+		values:  make(map[string]string),
+		onClick: make(map[string]func(d *fakeDocument)),
+		fail:    make(map[string][]error),
+	}
+}
+
+// newFakePage returns a page showing doc at url.
+func newFakePage(url string, doc *fakeDocument) *fakePage {
+	return &fakePage{docs: map[string]*fakeDocument{url: doc}, doc: doc, url: url}
+}
+
+func (d *fakeDocument) text(selector, text string) *fakeDocument {
+	d.texts[selector] = text
+	return d
+}
+
+func (d *fakeDocument) click(selector string, fn func(d *fakeDocument)) *fakeDocument {
+	d.onClick[selector] = fn
+	return d
+}
+
+func (d *fakeDocument) eval(substring string, result interface{}) *fakeDocument {
+	return d.evalFunc(substring, func(*fakeDocument) interface{} { return result })
+}
+
+// evalFunc scripts fn for expressions containing substring, replacing an
+// earlier script for the same substring.
+func (d *fakeDocument) evalFunc(substring string, fn func(d *fakeDocument) interface{}) *fakeDocument {
+	for i := range d.evals {
+		if d.evals[i].substring == substring {
+			d.evals[i].fn = fn
+			return d
+		}
+	}
+	d.evals = append(d.evals, fakeEval{substring: substring, fn: fn})
+	return d
+}
+
+// failNext queues the outcomes of the next actions with key.
+func (d *fakeDocument) failNext(key string, errs ...error) *fakeDocument {
+	d.fail[key] = append(d.fail[key], errs...)
+	return d
+}
+
+func (d *fakeDocument) has(selector string) bool {
+	_, text := d.texts[selector]
+	_, value := d.values[selector]
+	return text || value
+}
+
+// input adds an empty form field.
+func (d *fakeDocument) input(selector string) *fakeDocument {
+	d.values[selector] = ""
+	return d
+}
+
+// record notes an action and returns the failure scripted for it. Set
+// actions are keyed without their value, e.g. "set #id_bid4".
+func (p *fakePage) record(action, target string) error {
+	key := action
+	if target != "" {
+		key += " " + target
+	}
+	p.actions = append(p.actions, key)
+	key = strings.SplitN(key, "=", 2)[0]
+	queue := p.doc.fail[key]
+	if len(queue) == 0 {
+		return nil
+	}
+	p.doc.fail[key] = queue[1:]
+	return queue[0]
+}
+
+// count reports how often the page saw an action.
+func (p *fakePage) count(action string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, a := range p.actions {
+		if a == action {
+			n++
+		}
+	}
+	return n
+}
+
+// did reports whether the page saw an action, e.g. "click #apply_order".
+func (p *fakePage) did(action string) bool {
+	return p.count(action) > 0
+}
+
+func (p *fakePage) value(selector string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.doc.values[selector]
+}
+
+func (p *fakePage) Navigate(ctx context.Context, url string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.record("navigate", url); err != nil {
+		return err
+	}
+	doc, ok := p.docs[url]
+	if !ok {
+		doc = newFakeDocument()
+		p.docs[url] = doc
+	}
+	p.doc, p.url = doc, url
+	return nil
+}
+
+func (p *fakePage) WaitReady(ctx context.Context, selector string) error {
+	return p.WaitVisible(ctx, selector)
+}
+
+func (p *fakePage) WaitVisible(ctx context.Context, selector string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.record("wait", selector); err != nil {
+		return err
+	}
+	if selector != "body" && !p.doc.has(selector) {
+		return fmt.Errorf("fake: waiting for %s: %w", selector, context.DeadlineExceeded)
+	}
+	return nil
+}
+
+func (p *fakePage) Evaluate(ctx context.Context, expression string, result interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.record("evaluate", ""); err != nil {
+		return err
+	}
+	for _, e := range p.doc.evals {
+		if !strings.Contains(expression, e.substring) {
+			continue
+		}
+		value := e.fn(p.doc)
+		if result == nil {
+			return nil
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, result)
+	}
+	return fmt.Errorf("fake: no result scripted for expression %.60q", strings.TrimSpace(expression))
+}
+
+func (p *fakePage) Text(ctx context.Context, selector string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.record("text", selector); err != nil {
+		return "", err
+	}
+	text, ok := p.doc.texts[selector]
+	if !ok {
+		return "", fmt.Errorf("fake: %s: %w", selector, chromedp.ErrNotVisible)
+	}
+	return text, nil
+}
+
+func (p *fakePage) SetValue(ctx context.Context, selector, value string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.record("set", selector+"="+value); err != nil {
+		return err
+	}
+	if !p.doc.has(selector) {
+		return fmt.Errorf("fake: %s: %w", selector, chromedp.ErrNoResults)
+	}
+	p.doc.values[selector] = value
+	return nil
+}
+
+func (p *fakePage) Click(ctx context.Context, selector string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.record("click", selector); err != nil {
+		return err
+	}
+	if !p.doc.has(selector) {
+		return fmt.Errorf("fake: %s: %w", selector, chromedp.ErrNotVisible)
+	}
+	if fn := p.doc.onClick[selector]; fn != nil {
+		fn(p.doc)
+	}
+	return nil
+}
+
+func (p *fakePage) Screenshot(ctx context.Context) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return []byte("fake screenshot of " + p.url), p.record("screenshot", "")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	stdLog "log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ACTIVE_ORDERS_PAGE_URL       = "https://essayshark.com/writer/orders/in_progress/"
+	ACTIVE_ORDERS_CHECK_INTERVAL = 10 * time.Minute
+)
+
+var (
+	// activeOrderCount is the number of orders currently assigned to the
+	// writer, or -1 before the first check.
+	activeOrderCount int32 = -1
+
+	bidCapState = struct {
+		sync.Mutex
+		reason string
+	}{}
+)
+
+// bidCapReason returns why a bid of amount may not be placed now, or "" if
+// it may. Pass amount 0 to only check whether any bid is possible. Hourly
+// and daily caps reset on calendar boundaries in the schedule's timezone.
+func bidCapReason(now time.Time, amount Money) string {
+	local := now.In(cfg.Schedule.location())
+	hourStart := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, local.Location())
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+
+	hourCount, _ := bidLedger.Since(hourStart, isDryRun())
+	dayCount, dayValue := bidLedger.Since(dayStart, isDryRun())
+	active := int(atomic.LoadInt32(&activeOrderCount))
+
+	switch {
+	case cfg.MaxBidsPerHour > 0 && hourCount >= cfg.MaxBidsPerHour:
+		return fmt.Sprintf("hourly bid cap of %d reached, resets at %s",
+			cfg.MaxBidsPerHour, hourStart.Add(time.Hour).Format("15:04"))
+	case cfg.MaxBidsPerDay > 0 && dayCount >= cfg.MaxBidsPerDay:
+		return fmt.Sprintf("daily bid cap of %d reached, resets at midnight", cfg.MaxBidsPerDay)
+	case cfg.MaxBidValuePerDay > 0 && dayValue >= cfg.MaxBidValuePerDay:
+		return fmt.Sprintf("daily bid value cap of %s reached, resets at midnight", cfg.MaxBidValuePerDay)
+	case cfg.MaxBidValuePerDay > 0 && amount > 0 && dayValue+amount > cfg.MaxBidValuePerDay:
+		return fmt.Sprintf("bid of %s would exceed the daily value cap (%s of %s used)",
+			amount, dayValue, cfg.MaxBidValuePerDay)
+	case cfg.MaxActiveOrders > 0 && active >= cfg.MaxActiveOrders:
+		return fmt.Sprintf("active order limit of %d reached (%d in progress)", cfg.MaxActiveOrders, active)
+	}
+	return ""
+}
+
+// updateBidCapStatus re-evaluates the caps, logs when bidding pauses or
+// resumes and returns the current reason ("" while bidding is allowed).
+func updateBidCapStatus() string {
+	reason := bidCapReason(time.Now(), 0)
+
+	bidCapState.Lock()
+	previous := bidCapState.reason
+	bidCapState.reason = reason
+	bidCapState.Unlock()
+
+	if reason != previous {
+		if reason != "" {
+			stdLog.Printf("Bidding paused: %s. Scanning continues.", reason)
+			debugLogger.Printf("Bid cap reached: %s", reason)
+			notify(EventCapReached, "Bid Cap Reached", reason)
+		} else {
+			stdLog.Println("Bid caps cleared, bidding resumed.")
+			debugLogger.Println("Bid caps cleared.")
+		}
+		refreshStatus()
+	}
+	return reason
+}
+
+func bidCapStatusText() string {
+	bidCapState.Lock()
+	defer bidCapState.Unlock()
+	if bidCapState.reason == "" {
+		return ""
+	}
+	return "Bidding paused: " + bidCapState.reason
+}
+
+// refreshActiveOrders counts the orders currently in progress for the
+// active-order limit.
+func refreshActiveOrders(ctx context.Context) error {
+	links, err := readOrderLinks(ctx, ACTIVE_ORDERS_PAGE_URL)
+	if err != nil {
+		return fmt.Errorf("error counting active orders: %w", err)
+	}
+	setActiveOrderCount(len(links))
+	return nil
+}
+
+func setActiveOrderCount(count int) {
+	atomic.StoreInt32(&activeOrderCount, int32(count))
+	debugLogger.Printf("Active orders: %d", count)
+	updateBidCapStatus()
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	stdLog "log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	CAPTURES_FOLDER      = "captures"
+	CAPTURE_INDEX_FILE   = "index.jsonl"
+	CAPTURE_CONFIG_FILE  = "config.json"
+	CAPTURE_SESSION_TIME = "20060102-150405"
+)
+
+// CaptureKind names the kind of page saved by the recorder.
+type CaptureKind string
+
+const (
+	CaptureList  CaptureKind = "list"
+	CaptureOrder CaptureKind = "order"
+	CaptureBid   CaptureKind = "bid" // order page showing the minimum bid
+)
+
+// CaptureEntry is one line of a session's index.jsonl.
+type CaptureEntry struct {
+	Seq        int         `json:"seq"`
+	Kind       CaptureKind `json:"kind"`
+	URL        string      `json:"url"`
+	Thread     int         `json:"thread"`
+	CapturedAt time.Time   `json:"captured_at"`
+	File       string      `json:"file"`
+}
+
+// Recorder saves the HTML of the pages the bot parses into a session
+// directory that the replay driver can feed back offline.
+type Recorder struct {
+	mu  sync.Mutex
+	dir string // "" while not recording
+	seq int
+}
+
+var recorder = &Recorder{}
+
+func getCapturesDir() string {
+	if cfg.CaptureDir != "" {
+		return cfg.CaptureDir
+	}
+	return filepath.Join(getSysfilesDir(), CAPTURES_FOLDER)
+}
+
+// Start opens a new session directory and snapshots the config used for
+// the run.
+func (r *Recorder) Start() error {
+	dir := filepath.Join(getCapturesDir(), "session-"+time.Now().Format(CAPTURE_SESSION_TIME))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating capture directory: %w", err)
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling config snapshot: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, CAPTURE_CONFIG_FILE), data, 0644); err != nil {
+		return fmt.Errorf("error writing config snapshot: %w", err)
+	}
+
+	r.mu.Lock()
+	r.dir = dir
+	r.seq = 0
+	r.mu.Unlock()
+	stdLog.Printf("Capturing pages to %s", dir)
+	return nil
+}
+
+func (r *Recorder) Stop() {
+	r.mu.Lock()
+	r.dir = ""
+	r.mu.Unlock()
+}
+
+// Capture saves the current page. Failures are only logged: recording
+// must never get in the way of bidding.
+func (r *Recorder) Capture(ctx context.Context, kind CaptureKind, threadIndex int) {
+	r.mu.Lock()
+	recording := r.dir != ""
+	r.mu.Unlock()
+	if !recording {
+		return
+	}
+
+	ctxCapture, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var page struct {
+		URL  string `json:"url"`
+		HTML string `json:"html"`
+	}
+	err := pageFrom(ctx).Evaluate(ctxCapture,
+		`({url: location.href, html: document.documentElement.outerHTML})`, &page)
+	if err != nil {
+		debugLogger.Printf("Thread %d: Capture of %s page failed: %v", threadIndex, kind, err)
+		return
+	}
+	capturedAt := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.dir == "" {
+		return
+	}
+	r.seq++
+	entry := CaptureEntry{
+		Seq:        r.seq,
+		Kind:       kind,
+		URL:        page.URL,
+		Thread:     threadIndex,
+		CapturedAt: capturedAt,
+		File:       fmt.Sprintf("%06d-%s.html", r.seq, kind),
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, entry.File), []byte(page.HTML), 0644); err != nil {
+		debugLogger.Printf("Capture write error: %v", err)
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		debugLogger.Printf("Capture index marshal error: %v", err)
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(r.dir, CAPTURE_INDEX_FILE), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		debugLogger.Printf("Capture index write error: %v", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+// readCaptureIndex loads the entries of a session directory in order.
+func readCaptureIndex(dir string) ([]CaptureEntry, error) {
+	f, err := os.Open(filepath.Join(dir, CAPTURE_INDEX_FILE))
+	if err != nil {
+		return nil, fmt.Errorf("error opening capture index: %w", err)
+	}
+	defer f.Close()
+
+	var entries []CaptureEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry CaptureEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("error parsing capture index: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CHROME_PATH_ENV        = "CHROME_PATH"
+	CHROME_VERSION_TIMEOUT = 5 * time.Second
+	MIN_CHROME_WINDOW_SIZE = 200
+)
+
+// Where a Chrome executable was found.
+const (
+	CHROME_FROM_CONFIG = "config"
+	CHROME_FROM_ENV    = CHROME_PATH_ENV
+	CHROME_FROM_PATH   = "PATH"
+	CHROME_FROM_KNOWN  = "known location"
+)
+
+// ChromeInstall is the Chrome executable the bot launches.
+type ChromeInstall struct {
+	Path    string
+	Source  string
+	Version string // empty if it could not be read
+}
+
+func (c ChromeInstall) String() string {
+	version := c.Version
+	if version == "" {
+		version = "Chrome (unknown version)"
+	}
+	return fmt.Sprintf("%s at %s (from %s)", version, c.Path, c.Source)
+}
+
+// chrome is the install found at startup.
+var chrome ChromeInstall
+
+func isWindows() bool {
+	return runtime.GOOS == "windows"
+}
+
+func isMac() bool {
+	return runtime.GOOS == "darwin"
+}
+
+// chromeCommands are the names Chrome and Chromium go by on PATH.
+func chromeCommands() []string {
+	if isWindows() {
+		return []string{"chrome.exe", "chromium.exe"}
+	}
+	if isMac() {
+		return []string{"google-chrome", "chromium"}
+	}
+	return []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser", "chrome"}
+}
+
+// knownChromePaths are the usual install locations of Chrome and Chromium.
+func knownChromePaths() []string {
+	home, _ := os.UserHomeDir()
+	if isWindows() {
+		var paths []string
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)", "LocalAppData"} {
+			if dir := os.Getenv(env); dir != "" {
+				paths = append(paths,
+					filepath.Join(dir, `Google\Chrome\Application\chrome.exe`),
+					filepath.Join(dir, `Chromium\Application\chrome.exe`))
+			}
+		}
+		return append(paths,
+			`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+			`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`)
+	}
+	if isMac() {
+		var paths []string
+		for _, dir := range []string{"/Applications", filepath.Join(home, "Applications")} {
+			paths = append(paths,
+				filepath.Join(dir, "Google Chrome.app/Contents/MacOS/Google Chrome"),
+				filepath.Join(dir, "Chromium.app/Contents/MacOS/Chromium"))
+		}
+		return paths
+	}
+	return []string{
+		"/usr/bin/google-chrome",
+		"/usr/bin/google-chrome-stable",
+		"/usr/bin/chromium",
+		"/usr/bin/chromium-browser",
+		"/usr/bin/chrome",
+		"/opt/google/chrome/chrome",
+		"/snap/bin/chromium",
+		"/var/lib/flatpak/exports/bin/com.google.Chrome",
+		"/var/lib/flatpak/exports/bin/org.chromium.Chromium",
+		filepath.Join(home, ".local/share/flatpak/exports/bin/com.google.Chrome"),
+		filepath.Join(home, ".local/share/flatpak/exports/bin/org.chromium.Chromium"),
+	}
+}
+
+// findChromeExecutable looks for Chrome in the config, then CHROME_PATH,
+// then PATH, then the known install locations. A path that is set in the
+// config or CHROME_PATH but does not exist is an error rather than being
+// skipped, so a typo does not silently pick another browser.
+func findChromeExecutable() (ChromeInstall, error) {
+	if p := strings.TrimSpace(cfg.ChromePath); p != "" {
+		if !fileExists(p) {
+			return ChromeInstall{}, fmt.Errorf("Chrome path %q from the config does not exist", p)
+		}
+		return ChromeInstall{Path: p, Source: CHROME_FROM_CONFIG}, nil
+	}
+	if p := strings.TrimSpace(os.Getenv(CHROME_PATH_ENV)); p != "" {
+		if !fileExists(p) {
+			return ChromeInstall{}, fmt.Errorf("Chrome path %q from %s does not exist", p, CHROME_PATH_ENV)
+		}
+		return ChromeInstall{Path: p, Source: CHROME_FROM_ENV}, nil
+	}
+	for _, name := range chromeCommands() {
+		if p, err := exec.LookPath(name); err == nil {
+			return ChromeInstall{Path: p, Source: CHROME_FROM_PATH}, nil
+		}
+	}
+	for _, p := range knownChromePaths() {
+		if fileExists(p) {
+			return ChromeInstall{Path: p, Source: CHROME_FROM_KNOWN}, nil
+		}
+	}
+	return ChromeInstall{}, fmt.Errorf("Chrome executable not found; set chrome_path in the config or %s", CHROME_PATH_ENV)
+}
+
+var chromeVersionPattern = regexp.MustCompile(`^\d+(\.\d+){3}$`)
+
+// chromeVersion returns e.g. "Google Chrome 126.0.6478.126". chrome.exe
+// prints nothing for --version, so on Windows the version is taken from
+// the versioned folder next to it instead.
+func chromeVersion(path string) (string, error) {
+	if isWindows() {
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if err != nil {
+			return "", fmt.Errorf("error reading Chrome folder: %w", err)
+		}
+		var folders []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				folders = append(folders, entry.Name())
+			}
+		}
+		if version := newestChromeVersion(folders); version != "" {
+			return "Chrome " + version, nil
+		}
+		return "", fmt.Errorf("no version folder next to %s", path)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CHROME_VERSION_TIMEOUT)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("error running %s --version: %w", path, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// newestChromeVersion returns the highest of the names that are Chrome
+// versions. An update leaves the old version's folder behind for a while,
+// and the components are compared as numbers: 126.0.6478.9 is older than
+// 126.0.6478.126 even though it sorts after it as text.
+func newestChromeVersion(names []string) string {
+	var newest string
+	var newestParts [4]int
+	for _, name := range names {
+		if !chromeVersionPattern.MatchString(name) {
+			continue
+		}
+		var parts [4]int
+		for i, field := range strings.Split(name, ".") {
+			parts[i], _ = strconv.Atoi(field)
+		}
+		if newest == "" || slices.Compare(parts[:], newestParts[:]) > 0 {
+			newest, newestParts = name, parts
+		}
+	}
+	return newest
+}
+
+// chromeWindowSize is the configured window size, or the default if unset
+// or too small to render the site.
+func chromeWindowSize() (int, int) {
+	width, height := cfg.ChromeWindowWidth, cfg.ChromeWindowHeight
+	if width < MIN_CHROME_WINDOW_SIZE || height < MIN_CHROME_WINDOW_SIZE {
+		return CHROME_WINDOW_WIDTH, CHROME_WINDOW_HEIGHT
+	}
+	return width, height
+}
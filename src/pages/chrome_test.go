@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestNewestChromeVersion(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{[]string{"126.0.6478.126", "126.0.6478.9"}, "126.0.6478.126"},
+		{[]string{"99.0.4844.84", "100.0.4896.60"}, "100.0.4896.60"},
+		{[]string{"127.0.6533.72", "SetupMetrics", "126.0.6478.127", "Dictionaries"}, "127.0.6533.72"},
+		{[]string{"126.0.6478", "chrome.exe"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := newestChromeVersion(tt.names); got != tt.want {
+			t.Errorf("newestChromeVersion(%q) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+}
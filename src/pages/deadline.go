@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// What to do with an order whose deadline cannot be parsed.
+const (
+	DEADLINE_POLICY_SKIP   = "skip"
+	DEADLINE_POLICY_ACCEPT = "accept"
+)
+
+// Deadline is a parsed order deadline.
+type Deadline struct {
+	Remaining time.Duration // zero or negative once the deadline has passed
+	Due       time.Time
+}
+
+func (d Deadline) Overdue() bool {
+	return d.Remaining <= 0
+}
+
+var errUnparsedDeadline = errors.New("unrecognized deadline")
+
+var (
+	clockDeadlinePattern = regexp.MustCompile(`^(\d+):([0-5]\d)(?::([0-5]\d))?$`)
+	deadlinePartPattern  = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([a-z]+)`)
+	zoneAbbrevPattern    = regexp.MustCompile(`\s([A-Z]{2,5})$`)
+)
+
+var deadlineUnits = map[string]time.Duration{
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+}
+
+// Zone abbreviations are ambiguous in general, so only these are accepted
+// and mapped to fixed offsets.
+var zoneOffsets = map[string]string{
+	"UTC": "+0000", "GMT": "+0000",
+	"EST": "-0500", "EDT": "-0400",
+	"CST": "-0600", "CDT": "-0500",
+	"MST": "-0700", "MDT": "-0600",
+	"PST": "-0800", "PDT": "-0700",
+	"BST": "+0100", "CET": "+0100", "CEST": "+0200",
+	"EET": "+0200", "EEST": "+0300",
+}
+
+var absoluteDeadlineLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04 -0700",
+	"2006-01-02 15:04:05 -0700",
+	"Jan 2, 2006 15:04 -0700",
+	"Jan 2, 2006 3:04 PM -0700",
+	"Jan 2, 2006, 3:04 PM -0700",
+	"2 Jan 2006 15:04 -0700",
+	"01/02/2006 15:04 -0700",
+}
+
+// parseDeadline understands the relative forms shown on the orders list
+// ("2d 5h", "5h 30m", "3 days", "04:30", "overdue") and absolute dates
+// that carry a timezone. now anchors relative deadlines.
+func parseDeadline(text string, now time.Time) (Deadline, error) {
+	text = strings.TrimSpace(text)
+	lower := strings.ToLower(text)
+	if lower == "" {
+		return Deadline{}, fmt.Errorf("%w: empty", errUnparsedDeadline)
+	}
+	if strings.Contains(lower, "overdue") || strings.Contains(lower, "expired") {
+		return Deadline{Remaining: 0, Due: now}, nil
+	}
+
+	if remaining, ok := parseRelativeDeadline(lower); ok {
+		return Deadline{Remaining: remaining, Due: now.Add(remaining)}, nil
+	}
+	if due, ok := parseAbsoluteDeadline(text); ok {
+		return Deadline{Remaining: due.Sub(now), Due: due}, nil
+	}
+	return Deadline{}, fmt.Errorf("%w: %q", errUnparsedDeadline, text)
+}
+
+func parseRelativeDeadline(text string) (time.Duration, bool) {
+	text = strings.TrimPrefix(text, "in ")
+	text = strings.TrimSuffix(text, " left")
+	text = strings.TrimSuffix(text, " remaining")
+	text = strings.TrimSpace(text)
+
+	if m := clockDeadlinePattern.FindStringSubmatch(text); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		mins, _ := strconv.Atoi(m[2])
+		secs, _ := strconv.Atoi("0" + m[3])
+		return time.Duration(h)*time.Hour + time.Duration(mins)*time.Minute + time.Duration(secs)*time.Second, true
+	}
+
+	parts := deadlinePartPattern.FindAllStringSubmatch(text, -1)
+	if len(parts) == 0 {
+		return 0, false
+	}
+	var total time.Duration
+	for _, part := range parts {
+		unit, ok := deadlineUnits[part[2]]
+		if !ok {
+			return 0, false
+		}
+		value, err := strconv.ParseFloat(part[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		total += time.Duration(value * float64(unit))
+	}
+	// Anything left besides separators means the text is not purely relative.
+	rest := deadlinePartPattern.ReplaceAllString(text, "")
+	rest = strings.ReplaceAll(rest, "and", "")
+	if strings.Trim(rest, " ,") != "" {
+		return 0, false
+	}
+	return total, true
+}
+
+func parseAbsoluteDeadline(text string) (time.Time, bool) {
+	if m := zoneAbbrevPattern.FindStringSubmatchIndex(text); m != nil {
+		offset, ok := zoneOffsets[text[m[2]:m[3]]]
+		if !ok {
+			return time.Time{}, false
+		}
+		text = text[:m[2]] + offset
+	}
+	for _, layout := range absoluteDeadlineLayouts {
+		if due, err := time.Parse(layout, text); err == nil {
+			return due, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDeadline(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		text      string
+		remaining time.Duration
+		wantErr   bool
+	}{
+		{text: "2d 5h", remaining: 53 * time.Hour},
+		{text: "2d5h", remaining: 53 * time.Hour},
+		{text: "0d 3h", remaining: 3 * time.Hour},
+		{text: "5h", remaining: 5 * time.Hour},
+		{text: "12 hours", remaining: 12 * time.Hour},
+		{text: "1 hour left", remaining: time.Hour},
+		{text: "5h 30m", remaining: 5*time.Hour + 30*time.Minute},
+		{text: "5 hours and 30 minutes", remaining: 5*time.Hour + 30*time.Minute},
+		{text: "45 min", remaining: 45 * time.Minute},
+		{text: "04:30", remaining: 4*time.Hour + 30*time.Minute},
+		{text: "1:05:30", remaining: time.Hour + 5*time.Minute + 30*time.Second},
+		{text: "3 days", remaining: 72 * time.Hour},
+		{text: "1 day", remaining: 24 * time.Hour},
+		{text: "1.5 days", remaining: 36 * time.Hour},
+		{text: "  2 Days, 4 Hours  ", remaining: 52 * time.Hour},
+		{text: "in 3h", remaining: 3 * time.Hour},
+		{text: "Overdue", remaining: 0},
+		{text: "overdue by 2h", remaining: 0},
+		{text: "Expired", remaining: 0},
+		{text: "2026-10-20T12:00:00Z", remaining: 48 * time.Hour},
+		{text: "2026-10-18T15:00:00+02:00", remaining: time.Hour},
+		{text: "2026-10-19 12:00 +0000", remaining: 24 * time.Hour},
+		{text: "2026-10-18 09:00 EST", remaining: 2 * time.Hour},
+		{text: "Oct 19, 2026 2:00 PM UTC", remaining: 26 * time.Hour},
+		{text: "19 Oct 2026 08:00 EDT", remaining: 24 * time.Hour},
+		{text: "2026-10-17 12:00 UTC", remaining: -24 * time.Hour},
+		{text: "", wantErr: true},
+		{text: "soon", wantErr: true},
+		{text: "5 weeks", wantErr: true},
+		{text: "2d 5h extra", wantErr: true},
+		{text: "2026-10-19 12:00", wantErr: true},     // no timezone
+		{text: "2026-10-19 12:00 XYZ", wantErr: true}, // unknown zone
+	}
+
+	for _, tt := range tests {
+		got, err := parseDeadline(tt.text, now)
+		if tt.wantErr {
+			if !errors.Is(err, errUnparsedDeadline) {
+				t.Errorf("parseDeadline(%q) error = %v, want errUnparsedDeadline", tt.text, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseDeadline(%q) unexpected error: %v", tt.text, err)
+			continue
+		}
+		if got.Remaining != tt.remaining {
+			t.Errorf("parseDeadline(%q).Remaining = %v, want %v", tt.text, got.Remaining, tt.remaining)
+		}
+		if want := now.Add(tt.remaining); !got.Due.Equal(want) {
+			t.Errorf("parseDeadline(%q).Due = %v, want %v", tt.text, got.Due, want)
+		}
+	}
+}
+
+func TestOrderDiscardReasonDeadlines(t *testing.T) {
+	saved := *cfg
+	defer func() { *cfg = saved }()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	cfg.MinDeadlineHours = 4
+	cfg.MaxDeadlineHours = 48
+
+	tests := []struct {
+		deadline string
+		policy   string
+		discard  bool
+	}{
+		{deadline: "1d 0h", discard: false},
+		{deadline: "3h", discard: true},
+		{deadline: "3 days", discard: true},
+		{deadline: "overdue", discard: true},
+		{deadline: "soon", policy: DEADLINE_POLICY_SKIP, discard: true},
+		{deadline: "soon", policy: "", discard: true},
+		{deadline: "soon", policy: DEADLINE_POLICY_ACCEPT, discard: false},
+	}
+
+	for _, tt := range tests {
+		cfg.UnparsedDeadlinePolicy = tt.policy
+		reason := orderDiscardReason("", tt.deadline, now)
+		if (reason != "") != tt.discard {
+			t.Errorf("orderDiscardReason(%q) with policy %q = %q, want discard %t",
+				tt.deadline, tt.policy, reason, tt.discard)
+		}
+	}
+}
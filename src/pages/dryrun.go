@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// dryRunActive is latched from Config.DryRun when the bot starts so a run
+// never switches modes halfway through an order.
+var dryRunActive int32
+
+// errDryRun is returned by the functions that submit anything to the site
+// if they are reached during a dry run.
+var errDryRun = errors.New("dry run: refusing to submit")
+
+func isDryRun() bool {
+	return atomic.LoadInt32(&dryRunActive) != 0
+}
+
+// triggerBidValidation makes the bid form validate its current value
+// without submitting it, so the minimum bid message can be read in a dry
+// run.
+func triggerBidValidation(ctx context.Context, page Page) error {
+	return page.Evaluate(ctx, `
+		(function(){
+			let el = document.querySelector("#id_bid4");
+			if (!el) return;
+			for (let type of ["input", "change", "blur"]) {
+				el.dispatchEvent(new Event(type, {bubbles: true}));
+			}
+		})()
+	`, nil)
+}
+
+// guardLiveAction must be called right before any click that submits a
+// bid, an application or a message.
+func guardLiveAction() error {
+	if isDryRun() {
+		return errDryRun
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Stage names a step of the per-order pipeline.
+type Stage string
+
+const (
+	StageList    Stage = "list"
+	StageOpen    Stage = "open"
+	StageBid     Stage = "bid"
+	StageMessage Stage = "message"
+)
+
+// Failure classes. Every PipelineError carries exactly one of these, so
+// callers can branch on them with errors.Is.
+var (
+	ErrLoggedOut       = errors.New("logged out")
+	ErrSelectorMissing = errors.New("selector missing")
+	ErrOrderGone       = errors.New("order no longer available")
+	ErrBidRejected     = errors.New("bid rejected")
+	ErrTimeout         = errors.New("timed out")
+	ErrBrowserDead     = errors.New("browser not responding")
+	ErrUnexpected      = errors.New("unexpected error")
+
+	// ErrCapReached is not a failure: the bid was held back by a bid cap.
+	ErrCapReached = errors.New("bid cap reached")
+	// ErrStopped is not a failure either: the bot or the worker was stopped
+	// while the stage ran.
+	ErrStopped = errors.New("stopped")
+)
+
+// Phrases on an order page meaning the order can no longer be taken.
+var orderGonePhrases = []string{
+	"order is no longer available",
+	"order has been assigned",
+	"order not found",
+	"page not found",
+}
+
+// PipelineError describes a classified failure in one pipeline stage.
+type PipelineError struct {
+	Stage    Stage
+	Class    error
+	Selector string
+	Err      error
+}
+
+func (e *PipelineError) Error() string {
+	msg := fmt.Sprintf("%s stage: %v", e.Stage, e.Class)
+	if e.Selector != "" {
+		msg += fmt.Sprintf(" (selector %s)", e.Selector)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *PipelineError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Class}
+	}
+	return []error{e.Class, e.Err}
+}
+
+// newPipelineError wraps err for the given stage, classifying it unless it
+// already is a PipelineError.
+func newPipelineError(stage Stage, selector string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pe *PipelineError
+	if errors.As(err, &pe) {
+		return err
+	}
+	return &PipelineError{Stage: stage, Class: classifyError(err), Selector: selector, Err: err}
+}
+
+// errorClass returns the failure class of err, or ErrUnexpected.
+func errorClass(err error) error {
+	var pe *PipelineError
+	if errors.As(err, &pe) {
+		return pe.Class
+	}
+	return classifyError(err)
+}
+
+// stoppedError reclassifies the failure err of stage as ErrStopped. A
+// cancelled context then says nothing about the page or the browser.
+func stoppedError(stage Stage, err error) error {
+	pe := &PipelineError{Stage: stage, Class: ErrStopped, Err: err}
+	var inner *PipelineError
+	if errors.As(err, &inner) {
+		pe.Stage, pe.Selector, pe.Err = inner.Stage, inner.Selector, inner.Err
+	}
+	return pe
+}
+
+// classifyError maps a raw chromedp/context error to a failure class. A
+// cancelled context is taken for a dead browser, since that is how
+// chromedp reports a browser that went away; callers that may have been
+// stopped check stopRequested first.
+func classifyError(err error) error {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case errors.Is(err, chromedp.ErrInvalidContext),
+		errors.Is(err, chromedp.ErrChannelClosed),
+		errors.Is(err, chromedp.ErrInvalidTarget),
+		errors.Is(err, context.Canceled),
+		strings.Contains(msg, "websocket"),
+		strings.Contains(msg, "target closed"),
+		strings.Contains(msg, "no target with given id"):
+		return ErrBrowserDead
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, chromedp.ErrNoResults),
+		errors.Is(err, chromedp.ErrNotVisible):
+		return ErrSelectorMissing
+	}
+	return ErrUnexpected
+}
+
+// isFatalForScan reports whether err means the current scan cannot go on
+// with other orders and must be handled by the worker loop.
+func isFatalForScan(err error) bool {
+	return errors.Is(err, ErrBrowserDead) || errors.Is(err, ErrLoggedOut) || errors.Is(err, ErrStopped)
+}
+
+// pageState is a cheap snapshot of the current page used to explain a
+// failed wait.
+type pageState struct {
+	URL       string `json:"url"`
+	Ready     string `json:"ready"`
+	LoginForm bool   `json:"loginForm"`
+	OrderList bool   `json:"orderList"`
+	Rows      int    `json:"rows"`
+	Text      string `json:"text"`
+}
+
+func probePage(ctx context.Context) (pageState, error) {
+	var state pageState
+	ctxProbe, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := pageFrom(ctx).Evaluate(ctxProbe, `
+		(function(){
+			return {
+				url: location.href,
+				ready: document.readyState,
+				loginForm: !!document.querySelector('input[name="password"]'),
+				orderList: !!document.querySelector("#available_orders_list_container"),
+				rows: document.querySelectorAll("tr.order_container").length,
+				text: document.body ? document.body.innerText.slice(0, 5000).toLowerCase() : ""
+			};
+		})()
+	`, &state)
+	return state, err
+}
+
+func (s pageState) loggedOut() bool {
+	return s.LoginForm || strings.Contains(s.URL, "log-in")
+}
+
+func (s pageState) orderGone() bool {
+	for _, phrase := range orderGonePhrases {
+		if strings.Contains(s.Text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPage navigates to url and fails at once with ErrLoggedOut if the
+// site sent the browser to the login page instead, rather than letting the
+// caller wait for content that will never appear. Other failures are
+// returned as they are for the caller to diagnose.
+func loadPage(ctx context.Context, stage Stage, url string) error {
+	if err := pageFrom(ctx).Navigate(ctx, url); err != nil {
+		return err
+	}
+	state, err := probePage(ctx)
+	if err != nil {
+		debugLogger.Printf("Page probe after loading %s failed: %v", url, err)
+		return nil
+	}
+	if state.loggedOut() {
+		return &PipelineError{Stage: stage, Class: ErrLoggedOut, Err: fmt.Errorf("redirected to %s", state.URL)}
+	}
+	return nil
+}
+
+// diagnoseFailure inspects the current page after cause and returns a
+// PipelineError with the most specific class it can find.
+func diagnoseFailure(ctx context.Context, stage Stage, selector string, cause error) error {
+	if classifyError(cause) == ErrBrowserDead {
+		return newPipelineError(stage, selector, cause)
+	}
+	state, err := probePage(ctx)
+	if err != nil {
+		return newPipelineError(stage, selector, cause)
+	}
+	switch {
+	case state.loggedOut():
+		return &PipelineError{Stage: stage, Class: ErrLoggedOut, Selector: selector, Err: cause}
+	case stage == StageOpen && state.orderGone():
+		return &PipelineError{Stage: stage, Class: ErrOrderGone, Err: cause}
+	case state.Ready == "complete" && errors.Is(cause, context.DeadlineExceeded):
+		return &PipelineError{Stage: stage, Class: ErrSelectorMissing, Selector: selector, Err: cause}
+	}
+	return newPipelineError(stage, selector, cause)
+}
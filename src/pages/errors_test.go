@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{err: context.Canceled, want: ErrBrowserDead},
+		{err: fmt.Errorf("navigating: %w", context.Canceled), want: ErrBrowserDead},
+		{err: chromedp.ErrInvalidContext, want: ErrBrowserDead},
+		{err: chromedp.ErrChannelClosed, want: ErrBrowserDead},
+		{err: errors.New("websocket: close 1006 (abnormal closure)"), want: ErrBrowserDead},
+		{err: errors.New("Target closed"), want: ErrBrowserDead},
+		{err: errors.New("No target with given id found"), want: ErrBrowserDead},
+		{err: context.DeadlineExceeded, want: ErrTimeout},
+		{err: fmt.Errorf("waiting for #x: %w", context.DeadlineExceeded), want: ErrTimeout},
+		{err: chromedp.ErrNoResults, want: ErrSelectorMissing},
+		{err: fmt.Errorf("click: %w", chromedp.ErrNotVisible), want: ErrSelectorMissing},
+		{err: errors.New("something odd"), want: ErrUnexpected},
+	}
+
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("classifyError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestPipelineErrorClass(t *testing.T) {
+	pe := &PipelineError{Stage: StageBid, Class: ErrBidRejected, Err: errors.New("too low")}
+	wrapped := fmt.Errorf("placing bid: %w", pe)
+
+	if got := errorClass(wrapped); got != ErrBidRejected {
+		t.Errorf("errorClass = %v, want %v", got, ErrBidRejected)
+	}
+	if !errors.Is(wrapped, ErrBidRejected) {
+		t.Error("errors.Is does not find the class")
+	}
+	if got := newPipelineError(StageOpen, "#x", wrapped); got != wrapped {
+		t.Errorf("newPipelineError reclassified %v as %v", wrapped, got)
+	}
+	if got := errorClass(newPipelineError(StageOpen, "#x", context.DeadlineExceeded)); got != ErrTimeout {
+		t.Errorf("errorClass of a raw timeout = %v, want %v", got, ErrTimeout)
+	}
+	if newPipelineError(StageOpen, "#x", nil) != nil {
+		t.Error("newPipelineError(nil) is not nil")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	timeout := &PipelineError{Stage: StageBid, Class: ErrTimeout}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "timeout", err: timeout, want: true},
+		{name: "selector missing", err: &PipelineError{Stage: StageBid, Class: ErrSelectorMissing}, want: true},
+		{name: "unexpected", err: errors.New("odd"), want: true},
+		{name: "logged out", err: &PipelineError{Stage: StageBid, Class: ErrLoggedOut}, want: false},
+		{name: "order gone", err: &PipelineError{Stage: StageOpen, Class: ErrOrderGone}, want: false},
+		{name: "bid rejected", err: &PipelineError{Stage: StageBid, Class: ErrBidRejected}, want: false},
+		{name: "browser dead", err: context.Canceled, want: false},
+		{name: "dry run", err: errDryRun, want: false},
+		{name: "after submit click", err: fmt.Errorf("clicking apply: %w", maybeSubmitted(timeout)), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: isRetryable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdLog "log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	FAILURES_FOLDER                = "failures"
+	FAILURE_INFO_FILE              = "failure.json"
+	FAILURE_TIME_FORMAT            = "20060102-150405.000"
+	DEFAULT_FAILURE_SNAPSHOT_LIMIT = 50
+	FAILURE_SNAPSHOT_TIMEOUT       = 15 * time.Second
+)
+
+// FailureInfo is the failure.json of a snapshot directory.
+type FailureInfo struct {
+	Time     time.Time `json:"time"`
+	Thread   int       `json:"thread"`
+	OrderURL string    `json:"order_url,omitempty"`
+	PageURL  string    `json:"page_url"`
+	Stage    Stage     `json:"stage,omitempty"`
+	Class    string    `json:"class"`
+	Selector string    `json:"selector,omitempty"`
+	Error    string    `json:"error"`
+	// Problems lists the parts of the snapshot that could not be saved.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// lastFailure is the directory of the newest snapshot, for the home screen.
+var lastFailure struct {
+	sync.Mutex
+	dir  string
+	info FailureInfo
+}
+
+func getFailuresDir() string {
+	return filepath.Join(getSysfilesDir(), FAILURES_FOLDER)
+}
+
+// wantsFailureSnapshot reports whether err is a failure worth a snapshot.
+// Normal per-order outcomes are not, a script failure is not about the
+// page, a stop is no failure, and a dead browser has nothing left to show.
+func wantsFailureSnapshot(err error) bool {
+	if err == nil || cfg.FailureSnapshotLimit <= 0 || errors.Is(err, errDryRun) {
+		return false
+	}
+	for _, class := range []error{ErrCapReached, ErrScriptSkip, ErrScriptFailed, ErrOrderGone, ErrStopped, ErrBrowserDead} {
+		if errors.Is(err, class) {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshotFailure saves a screenshot, the HTML, the URL, the selector
+// involved and the recent console messages of the page in ctx under
+// sysfiles/failures/<timestamp>-<order>. It runs even if ctx already timed
+// out, since timeouts are what it most often documents.
+func snapshotFailure(ctx context.Context, threadIndex int, orderUrl string, err error) {
+	if !wantsFailureSnapshot(err) {
+		return
+	}
+	now := time.Now()
+	info := FailureInfo{Time: now, Thread: threadIndex, OrderURL: orderUrl, Class: errorClass(err).Error(), Error: err.Error()}
+	var pe *PipelineError
+	if errors.As(err, &pe) {
+		info.Stage, info.Selector = pe.Stage, pe.Selector
+	}
+
+	name := "list"
+	if orderUrl != "" {
+		name = "order-" + orderID(orderUrl)
+	}
+	dir, err := makeFailureDir(fmt.Sprintf("%s-%s-t%d", now.Format(FAILURE_TIME_FORMAT), sanitizeFileName(name), threadIndex))
+	if err != nil {
+		debugLogger.Printf("Thread %d: Failure snapshot folder error: %v", threadIndex, err)
+		return
+	}
+
+	ctxSnap, cancel := context.WithTimeout(context.WithoutCancel(ctx), FAILURE_SNAPSHOT_TIMEOUT)
+	defer cancel()
+	page := pageFrom(ctx)
+	save := func(file string, data []byte) {
+		if err := os.WriteFile(filepath.Join(dir, file), data, 0644); err != nil {
+			info.Problems = append(info.Problems, fmt.Sprintf("%s: %v", file, err))
+		}
+	}
+
+	var doc struct {
+		URL  string `json:"url"`
+		HTML string `json:"html"`
+	}
+	if err := page.Evaluate(ctxSnap, `({url: location.href, html: document.documentElement.outerHTML})`, &doc); err != nil {
+		info.Problems = append(info.Problems, fmt.Sprintf("page.html: %v", err))
+	} else {
+		info.PageURL = doc.URL
+		save("page.html", []byte(doc.HTML))
+	}
+	if shot, err := page.Screenshot(ctxSnap); err != nil {
+		info.Problems = append(info.Problems, fmt.Sprintf("screenshot.png: %v", err))
+	} else {
+		save("screenshot.png", shot)
+	}
+	if console := consoleFrom(ctx); console != nil {
+		save("console.log", []byte(strings.Join(console.recent(), "\n")+"\n"))
+	}
+
+	data, _ := json.MarshalIndent(info, "", "  ")
+	save(FAILURE_INFO_FILE, data)
+
+	lastFailure.Lock()
+	lastFailure.dir, lastFailure.info = dir, info
+	lastFailure.Unlock()
+	stdLog.Printf("Thread %d: Failure snapshot saved to %s", threadIndex, dir)
+	debugLogger.Printf("Thread %d: Failure snapshot %s (%d problems)", threadIndex, dir, len(info.Problems))
+
+	pruneFailureSnapshots(cfg.FailureSnapshotLimit)
+	refreshFailureLink()
+}
+
+// makeFailureDir creates a new snapshot directory, numbering it if a
+// failure in the same millisecond already took the name.
+func makeFailureDir(name string) (string, error) {
+	if err := os.MkdirAll(getFailuresDir(), 0755); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(getFailuresDir(), name)
+	for n := 2; ; n++ {
+		err := os.Mkdir(dir, 0755)
+		if !os.IsExist(err) {
+			return dir, err
+		}
+		dir = filepath.Join(getFailuresDir(), fmt.Sprintf("%s-%d", name, n))
+	}
+}
+
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// failureSnapshotDirs returns the snapshot directories, oldest first. The
+// names start with the time, so they sort chronologically.
+func failureSnapshotDirs() []string {
+	entries, err := os.ReadDir(getFailuresDir())
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, filepath.Join(getFailuresDir(), e.Name()))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// pruneFailureSnapshots deletes all but the newest keep snapshots.
+func pruneFailureSnapshots(keep int) {
+	dirs := failureSnapshotDirs()
+	for len(dirs) > keep {
+		if err := os.RemoveAll(dirs[0]); err != nil {
+			debugLogger.Printf("Failure snapshot prune error: %v", err)
+		}
+		dirs = dirs[1:]
+	}
+}
+
+// latestFailure returns the newest snapshot, including those of earlier
+// runs.
+func latestFailure() (string, FailureInfo, bool) {
+	lastFailure.Lock()
+	dir, info := lastFailure.dir, lastFailure.info
+	lastFailure.Unlock()
+	if dir != "" {
+		return dir, info, true
+	}
+
+	dirs := failureSnapshotDirs()
+	if len(dirs) == 0 {
+		return "", FailureInfo{}, false
+	}
+	dir = dirs[len(dirs)-1]
+	if data, err := os.ReadFile(filepath.Join(dir, FAILURE_INFO_FILE)); err == nil {
+		json.Unmarshal(data, &info)
+	}
+	return dir, info, true
+}
+
+var (
+	failureLabel  *widget.Label
+	failureButton *widget.Button
+)
+
+func failureLinkText() string {
+	dir, info, ok := latestFailure()
+	if !ok {
+		return "No failure snapshots."
+	}
+	text := fmt.Sprintf("Latest failure: %s, %s", info.Time.Format("Jan 2 15:04:05"), info.Class)
+	if info.Stage != "" {
+		text += " in " + string(info.Stage) + " stage"
+	}
+	return text + " (" + filepath.Base(dir) + ")"
+}
+
+// refreshFailureLink updates the home screen. Safe to call from any
+// goroutine.
+func refreshFailureLink() {
+	if failureLabel == nil {
+		return
+	}
+	text := failureLinkText()
+	_, _, ok := latestFailure()
+	fyne.Do(func() {
+		failureLabel.SetText(text)
+		if ok {
+			failureButton.Enable()
+		} else {
+			failureButton.Disable()
+		}
+	})
+}
+
+// failureLinkContent is the home screen row that opens the newest snapshot
+// folder in the system file browser.
+func failureLinkContent() fyne.CanvasObject {
+	failureLabel = widget.NewLabel(failureLinkText())
+	failureButton = widget.NewButton("Open Latest Failure", func() {
+		dir, _, ok := latestFailure()
+		if !ok {
+			return
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			abs = dir
+		}
+		u := &url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+		if err := fyne.CurrentApp().OpenURL(u); err != nil {
+			stdLog.Printf("Error opening %s: %v", abs, err)
+		}
+	})
+	if _, _, ok := latestFailure(); !ok {
+		failureButton.Disable()
+	}
+	return container.NewHBox(failureButton, failureLabel)
+}
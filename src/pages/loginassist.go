@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	stdLog "log"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+)
+
+const (
+	LOGIN_FORM_TIMEOUT   = 20 * time.Second
+	LOGIN_SUBMIT_TIMEOUT = 30 * time.Second
+	// LOGIN_SETTLE is how long a page that is neither the login form nor
+	// the orders list may load before it counts as an interstitial.
+	LOGIN_SETTLE             = 10 * time.Second
+	LOGIN_ASSIST_TIMEOUT     = 10 * time.Minute
+	LOGIN_ASSIST_REFRESH     = 2 * time.Second
+	LOGIN_ASSIST_PAUSE_KEY   = "login-assist"
+	LOGIN_ASSIST_IMAGE_WIDTH = 900
+)
+
+var errLoginCancelled = errors.New("login cancelled by the user")
+
+// loginState is what the login flow needs to know about the current page.
+type loginState struct {
+	URL       string `json:"url"`
+	Orders    bool   `json:"orders"`
+	LoginForm bool   `json:"loginForm"`
+	CodeField string `json:"codeField"` // selector of a one-time code input
+	Captcha   bool   `json:"captcha"`
+	Error     string `json:"error"`
+}
+
+func probeLogin(ctx context.Context) (loginState, error) {
+	var state loginState
+	ctxProbe, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := chromedp.Run(ctxProbe, chromedp.Evaluate(`
+		(function(){
+			const visible = el => !!(el.offsetWidth || el.offsetHeight || el.getClientRects().length);
+			const code = Array.from(document.querySelectorAll("input")).find(el =>
+				visible(el) && el.type !== "hidden" && el.name !== "login" && el.name !== "password" &&
+				(el.autocomplete === "one-time-code" ||
+				 /(code|otp|token|2fa|verif)/i.test([el.name, el.id, el.placeholder].join(" "))));
+			let codeField = "";
+			if (code) {
+				codeField = code.id ? "#" + CSS.escape(code.id)
+					: code.name ? 'input[name="' + code.name + '"]'
+					: 'input[autocomplete="one-time-code"]';
+			}
+			const error = document.querySelector(".errorlist, .error, .alert-danger, .form-error");
+			return {
+				url: location.href,
+				orders: !!document.querySelector("#available_orders_list_container"),
+				loginForm: !!document.querySelector('input[name="password"]'),
+				codeField: codeField,
+				captcha: !!document.querySelector('iframe[src*="captcha"], .g-recaptcha, .h-captcha, #challenge-form'),
+				error: error && visible(error) ? error.innerText.trim().slice(0, 200) : ""
+			};
+		})()
+	`, &state))
+	return state, err
+}
+
+// awaitLoginResult watches the page after the login form was submitted.
+// A code field, a captcha or any other page that is not the orders list
+// is handed to the user.
+func awaitLoginResult(ctx context.Context, threadIndex int) error {
+	start := time.Now()
+	for {
+		state, err := probeLogin(ctx)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return err
+			}
+		case state.Orders:
+			return nil
+		case state.CodeField != "":
+			return assistLogin(ctx, threadIndex, "The site asks for a verification code.", state.CodeField, loginDone)
+		case state.Captcha:
+			return assistLogin(ctx, threadIndex, "The site shows a captcha.", "", loginDone)
+		case state.LoginForm && state.Error != "":
+			return fmt.Errorf("error during login: %s", state.Error)
+		case !state.LoginForm && time.Since(start) > LOGIN_SETTLE:
+			return assistLogin(ctx, threadIndex, fmt.Sprintf("The site shows an unexpected page (%s).", state.URL), "", loginDone)
+		}
+		if time.Since(start) > LOGIN_SUBMIT_TIMEOUT {
+			return fmt.Errorf("error during login: no orders page after %v", LOGIN_SUBMIT_TIMEOUT)
+		}
+		beat(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func loginDone(s loginState) bool {
+	return s.Orders
+}
+
+func loginFormShown(s loginState) bool {
+	return s.Orders || s.LoginForm
+}
+
+// loginAssistant is one request for the user to finish a login step.
+type loginAssistant struct {
+	ctx          context.Context
+	threadIndex  int
+	codeSelector string
+
+	mu       sync.Mutex
+	viewport fyne.Size // CSS pixels of the last screenshot
+	shotSize fyne.Size // image pixels of the last screenshot
+
+	cancelled chan struct{}
+	cancel    sync.Once
+	window    fyne.Window
+}
+
+// assistLogin pauses the bot and shows the worker's page in a window where
+// the user can enter a code, click and type until done reports the step
+// finished.
+func assistLogin(ctx context.Context, threadIndex int, reason, codeSelector string, done func(loginState) bool) error {
+	if mainWindow == nil {
+		return fmt.Errorf("login needs manual verification: %s", reason)
+	}
+	stdLog.Printf("Thread %d: Login needs your input: %s", threadIndex, reason)
+	debugLogger.Printf("Thread %d: Login assist started: %s", threadIndex, reason)
+	pauseBot(LOGIN_ASSIST_PAUSE_KEY, "login needs your input")
+	defer resumeBot(LOGIN_ASSIST_PAUSE_KEY)
+	notify(EventLoginAssist, "Login Needs Your Input", reason+" Finish the step in the Login Verification window.")
+
+	// The user needs to see the page as it is, images included.
+	if b := blockerFrom(ctx); b != nil && cfg.RequestBlocking.active() {
+		if err := b.apply(ctx, RequestBlocking{}); err != nil {
+			debugLogger.Printf("Thread %d: Could not lift request blocking: %v", threadIndex, err)
+		}
+		defer b.apply(ctx, cfg.RequestBlocking)
+	}
+
+	a := &loginAssistant{ctx: ctx, threadIndex: threadIndex, codeSelector: codeSelector, cancelled: make(chan struct{})}
+	fyne.Do(func() { a.show(reason) })
+	defer fyne.Do(func() {
+		if a.window != nil {
+			a.window.SetOnClosed(nil)
+			a.window.Close()
+		}
+	})
+
+	deadline := time.Now().Add(LOGIN_ASSIST_TIMEOUT)
+	for {
+		beat(ctx)
+		select {
+		case <-a.cancelled:
+			return errLoginCancelled
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+		if state, err := probeLogin(ctx); err == nil && done(state) {
+			stdLog.Printf("Thread %d: Login step finished.", threadIndex)
+			debugLogger.Printf("Thread %d: Login assist finished at %s.", threadIndex, state.URL)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("login step not finished within %v", LOGIN_ASSIST_TIMEOUT)
+		}
+	}
+}
+
+// show opens the Login Verification window. It runs on the GUI thread.
+func (a *loginAssistant) show(reason string) {
+	w := fyne.CurrentApp().NewWindow(fmt.Sprintf("Login Verification - Thread %d", a.threadIndex))
+	a.window = w
+
+	shot := canvas.NewImageFromImage(nil)
+	shot.FillMode = canvas.ImageFillContain
+	shot.SetMinSize(fyne.NewSize(LOGIN_ASSIST_IMAGE_WIDTH, LOGIN_ASSIST_IMAGE_WIDTH*2/3))
+	status := widget.NewLabel("")
+	view := newTappableImage(shot, func(pos fyne.Position, size fyne.Size) {
+		x, y, ok := a.toPage(pos, size)
+		if !ok {
+			return
+		}
+		a.run(status, "Clicked.", chromedp.MouseClickXY(x, y))
+	})
+
+	refresh := func() {
+		go func() {
+			img, err := a.screenshot()
+			fyne.Do(func() {
+				if err != nil {
+					status.SetText(fmt.Sprintf("Screenshot failed: %v", err))
+					return
+				}
+				shot.Image = img
+				shot.Refresh()
+			})
+		}()
+	}
+
+	textEntry := widget.NewEntry()
+	var actions fyne.CanvasObject
+	if a.codeSelector != "" {
+		textEntry.SetPlaceHolder("Verification code")
+		actions = container.NewBorder(nil, nil, widget.NewLabel("Code:"), widget.NewButton("Submit Code", func() {
+			a.run(status, "Code submitted.",
+				chromedp.Clear(a.codeSelector, chromedp.ByQuery),
+				chromedp.SendKeys(a.codeSelector, textEntry.Text+kb.Enter, chromedp.ByQuery))
+			textEntry.SetText("")
+		}), textEntry)
+	} else {
+		textEntry.SetPlaceHolder("Text to type into the focused field")
+		actions = container.NewBorder(nil, nil, widget.NewLabel("Type:"), container.NewHBox(
+			widget.NewButton("Type", func() {
+				a.run(status, "Typed.", chromedp.KeyEvent(textEntry.Text))
+				textEntry.SetText("")
+			}),
+			widget.NewButton("Press Enter", func() {
+				a.run(status, "Pressed Enter.", chromedp.KeyEvent(kb.Enter))
+			}),
+		), textEntry)
+	}
+
+	w.SetContent(container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel(reason+" Click on the page below to interact with it. The bot continues by itself once the step is done."),
+			actions,
+		),
+		container.NewHBox(widget.NewButton("Refresh", refresh), widget.NewButton("Cancel Login", a.stop), status),
+		nil, nil,
+		view,
+	))
+	w.SetOnClosed(a.stop)
+	w.Show()
+
+	refresh()
+	go func() {
+		ticker := time.NewTicker(LOGIN_ASSIST_REFRESH)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.cancelled:
+				return
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
+
+func (a *loginAssistant) stop() {
+	a.cancel.Do(func() { close(a.cancelled) })
+}
+
+// run performs actions on the worker's page off the GUI thread.
+func (a *loginAssistant) run(status *widget.Label, done string, actions ...chromedp.Action) {
+	go func() {
+		ctx, cancel := context.WithTimeout(a.ctx, 10*time.Second)
+		defer cancel()
+		err := chromedp.Run(ctx, actions...)
+		fyne.Do(func() {
+			if err != nil {
+				status.SetText(fmt.Sprintf("Failed: %v", err))
+				return
+			}
+			status.SetText(done)
+		})
+	}()
+}
+
+// screenshot captures the viewport, which is what clicks are mapped to.
+func (a *loginAssistant) screenshot() (image.Image, error) {
+	ctx, cancel := context.WithTimeout(a.ctx, 10*time.Second)
+	defer cancel()
+	var buf []byte
+	var viewport struct {
+		Width  float32 `json:"w"`
+		Height float32 `json:"h"`
+	}
+	err := chromedp.Run(ctx,
+		chromedp.CaptureScreenshot(&buf),
+		chromedp.Evaluate(`({w: window.innerWidth, h: window.innerHeight})`, &viewport),
+	)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding screenshot: %w", err)
+	}
+	a.mu.Lock()
+	a.viewport = fyne.NewSize(viewport.Width, viewport.Height)
+	a.shotSize = fyne.NewSize(float32(img.Bounds().Dx()), float32(img.Bounds().Dy()))
+	a.mu.Unlock()
+	return img, nil
+}
+
+// toPage maps a tap on the screenshot, drawn to fit size, to page
+// coordinates.
+func (a *loginAssistant) toPage(pos fyne.Position, size fyne.Size) (float64, float64, bool) {
+	a.mu.Lock()
+	viewport, shot := a.viewport, a.shotSize
+	a.mu.Unlock()
+	if shot.Width == 0 || shot.Height == 0 || viewport.Width == 0 {
+		return 0, 0, false
+	}
+	scale := size.Width / shot.Width
+	if s := size.Height / shot.Height; s < scale {
+		scale = s
+	}
+	offsetX := (size.Width - shot.Width*scale) / 2
+	offsetY := (size.Height - shot.Height*scale) / 2
+	px := (pos.X - offsetX) / scale
+	py := (pos.Y - offsetY) / scale
+	if px < 0 || py < 0 || px > shot.Width || py > shot.Height {
+		return 0, 0, false
+	}
+	return float64(px * viewport.Width / shot.Width), float64(py * viewport.Height / shot.Height), true
+}
+
+// tappableImage is an image that reports where it was tapped.
+type tappableImage struct {
+	widget.BaseWidget
+	image *canvas.Image
+	onTap func(pos fyne.Position, size fyne.Size)
+}
+
+func newTappableImage(img *canvas.Image, onTap func(pos fyne.Position, size fyne.Size)) *tappableImage {
+	t := &tappableImage{image: img, onTap: onTap}
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+func (t *tappableImage) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(t.image)
+}
+
+func (t *tappableImage) Tapped(ev *fyne.PointEvent) {
+	t.onTap(ev.Position, t.Size())
+}
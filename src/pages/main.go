@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"math"
 	"math/rand" // Imported to resolve undefined: rand
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -21,7 +24,6 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 
-	chromelog "github.com/chromedp/cdproto/log" // Aliased to prevent naming conflict
 	"github.com/chromedp/chromedp"
 	stdLog "log"
 )
@@ -32,7 +34,6 @@ const (
 	CONFIG_DIR_NAME         = ".bidding-bot"
 	CONFIG_FILE_NAME        = "config.json"
 	SYSFILES_FOLDER         = "sysfiles"
-	CHROME_USER_DATA_DIR    = "chrome_user_data"
 	DOWNLOADS_FOLDER        = "downloads"
 	USERFILES_FOLDER        = "userfiles"
 	LOG_FILE_NAME           = "bot_debug.log"
@@ -40,15 +41,28 @@ const (
 	DEFAULT_THREAD_COUNT    = 3
 	DEFAULT_MIN_DEADLINE_HS = 0
 	DEFAULT_MAX_DEADLINE_HS = 2880
+	ORDERS_PAGE_URL         = "https://essayshark.com/writer/orders/"
+	LOGIN_PAGE_URL          = "https://essayshark.com/log-in.html"
+
+	// ORDER_COUNTDOWN_HEADROOM is the time an order must have left after
+	// its countdown to place the bid.
+	ORDER_COUNTDOWN_HEADROOM = 5 * time.Second
 )
 
+var pageCountPattern = regexp.MustCompile(`(?i)\b(\d+)\s*pages?\b`)
+
 var (
-	stopFlag          int32
-	orderToThreadMap  = make(map[string]int)
-	orderLock         sync.Mutex
-	executorWG        sync.WaitGroup
+	stopFlag            int32
+	orderToThreadMap    = make(map[string]int)
+	orderLock           sync.Mutex
+	executorWG          sync.WaitGroup
 	mainCtx, mainCancel = context.WithCancel(context.Background())
 
+	// botCtx lives for one run of the bot: startBot creates it and stopBot
+	// cancels it, closing the run's browsers and background tasks.
+	botCtx    context.Context
+	botCancel context.CancelFunc = func() {}
+
 	cfg = &Config{}
 
 	userEmail    string
@@ -66,6 +80,60 @@ type Config struct {
 	MinDeadlineHours   int    `json:"min_deadline_hours"`
 	MaxDeadlineHours   int    `json:"max_deadline_hours"`
 	ThreadCount        int    `json:"thread_count"`
+
+	// UnparsedDeadlinePolicy is DEADLINE_POLICY_SKIP (the default) or
+	// DEADLINE_POLICY_ACCEPT.
+	UnparsedDeadlinePolicy string `json:"unparsed_deadline_policy"`
+
+	RetryPolicies      map[string]RetryPolicy `json:"retry_policies,omitempty"`
+	BreakerThreshold   int                    `json:"breaker_threshold"`
+	BreakerCooldownSec int                    `json:"breaker_cooldown_sec"`
+
+	ScanIntervalMs        int `json:"scan_interval_ms"`
+	MinScanIntervalMs     int `json:"min_scan_interval_ms"`
+	MaxScanIntervalMs     int `json:"max_scan_interval_ms"`
+	MaxPageLoadsPerMinute int `json:"max_page_loads_per_minute"`
+
+	Schedule WorkSchedule `json:"schedule"`
+
+	MaxBidsPerHour    int   `json:"max_bids_per_hour"`
+	MaxBidsPerDay     int   `json:"max_bids_per_day"`
+	MaxBidValuePerDay Money `json:"max_bid_value_per_day"`
+	MaxActiveOrders   int   `json:"max_active_orders"`
+
+	DryRun bool `json:"dry_run"`
+
+	// BidMarkupPercent is added to the minimum bid, e.g. 5 bids 5% over it.
+	BidMarkupPercent float64 `json:"bid_markup_percent"`
+
+	CaptureEnabled bool   `json:"capture_enabled"`
+	CaptureDir     string `json:"capture_dir,omitempty"`
+
+	// FailureSnapshotLimit is how many failure snapshots are kept; 0 turns
+	// them off.
+	FailureSnapshotLimit int `json:"failure_snapshot_limit"`
+
+	// BrowserMode is BROWSER_MODE_PER_THREAD (a Chrome process per worker),
+	// BROWSER_MODE_SHARED (one Chrome process, workers lease its tabs) or
+	// BROWSER_MODE_REMOTE (like shared, in the Chrome at RemoteDebuggingURL).
+	BrowserMode string `json:"browser_mode"`
+	// RemoteDebuggingURL is a DevTools websocket URL or host:port.
+	RemoteDebuggingURL string `json:"remote_debugging_url,omitempty"`
+	// TabPoolSize is the number of tabs in shared mode; 0 means one per
+	// worker.
+	TabPoolSize int `json:"tab_pool_size"`
+
+	// ChromePath overrides Chrome discovery; see findChromeExecutable.
+	ChromePath         string `json:"chrome_path,omitempty"`
+	ChromeHeadless     bool   `json:"chrome_headless"`
+	ChromeWindowWidth  int    `json:"chrome_window_width"`
+	ChromeWindowHeight int    `json:"chrome_window_height"`
+
+	RequestBlocking RequestBlocking `json:"request_blocking"`
+
+	Notifications NotificationConfig `json:"notifications"`
+	Webhooks      []WebhookTarget    `json:"webhooks"`
+	Script        ScriptConfig       `json:"script"`
 }
 
 func init() {
@@ -87,29 +155,46 @@ func main() {
 	ensureFolders()
 	loadConfig()
 
-	// Initialize Chromedp with existing Chrome
-	// Attempt to find Chrome executable path based on OS
-	chromePath, err := findChromeExecutable()
+	replayDir := flag.String("replay", "", "replay a capture session directory offline and exit")
+	replayUpdate := flag.Bool("replay-update", false, "with -replay, save the decisions as the session's expected results")
+	reportPath := flag.String("report", "", "write a bidding report to this .csv, .json or .html file and exit")
+	marketReportPath := flag.String("market-report", "", "write a market activity report to this .csv or .json file and exit")
+	reportDays := flag.Int("report-days", DEFAULT_REPORT_DAYS, "with -report or -market-report, how many days to cover")
+	flag.Parse()
+	if *reportPath != "" {
+		os.Exit(runReportCommand(*reportPath, *reportDays, loadBidReportView))
+	}
+	if *marketReportPath != "" {
+		os.Exit(runReportCommand(*marketReportPath, *reportDays, loadMarketReportView))
+	}
+
+	var err error
+	chrome, err = findChromeExecutable()
 	if err != nil {
 		stdLog.Fatalf("Failed to find Chrome executable: %v", err)
 	}
+	if chrome.Version, err = chromeVersion(chrome.Path); err != nil {
+		debugLogger.Printf("Chrome version unknown: %v", err)
+	}
+	stdLog.Printf("Using %s.", chrome)
+	debugLogger.Printf("Chrome: %s", chrome)
+	chromePath := chrome.Path
+
+	if *replayDir != "" {
+		os.Exit(runReplay(*replayDir, *replayUpdate, chromePath))
+	}
 
 	// Create Chromedp allocator with anti-detection measures
-	allocCtx, cancel := chromedp.NewExecAllocator(mainCtx, append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.ExecPath(chromePath),
-		chromedp.Flag("start-maximized", true),
-		chromedp.Flag("disable-blink-features", "AutomationControlled"),
-		chromedp.Flag("disable-infobars", true),
-		chromedp.Flag("excludeSwitches", "enable-automation"),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-gpu", true),
-	)...)
+	allocCtx, cancel := chromedp.NewExecAllocator(mainCtx, chromeOptions(chromePath)...)
 	defer cancel()
+	defer mainCancel()
+
+	webhooks.Start()
 
 	a := app.New()
 	w := a.NewWindow("Bidding Bot (Go Version)")
 	w.Resize(fyne.NewSize(400, 500))
+	mainWindow = w
 
 	// HOME UI
 	emailEntry := widget.NewEntry()
@@ -118,11 +203,15 @@ func main() {
 	passwordEntry.SetPlaceHolder("Password")
 
 	startStopButton := widget.NewButton("Start", nil)
+	statusLabel = widget.NewLabel(statusText())
+	statusLabel.Wrapping = fyne.TextWrapWord
 
 	homeContent := container.NewVBox(
 		widget.NewLabel("Email:"), emailEntry,
 		widget.NewLabel("Password:"), passwordEntry,
 		startStopButton,
+		statusLabel,
+		failureLinkContent(),
 	)
 
 	// SETTINGS UI
@@ -141,33 +230,162 @@ func main() {
 	discardEditingCheck := widget.NewCheck("Discard Editing", func(v bool) {})
 	discardEditingCheck.SetChecked(cfg.DiscardEditing)
 
+	dryRunCheck := widget.NewCheck("Dry Run (never bid or send messages)", func(v bool) {})
+	dryRunCheck.SetChecked(cfg.DryRun)
+	captureCheck := widget.NewCheck("Capture Pages for Replay", func(v bool) {})
+	captureCheck.SetChecked(cfg.CaptureEnabled)
+
+	browserModeSelect := widget.NewSelect([]string{BROWSER_MODE_PER_THREAD, BROWSER_MODE_SHARED, BROWSER_MODE_REMOTE}, nil)
+	browserModeSelect.SetSelected(browserMode())
+
+	remoteURLEntry := widget.NewEntry()
+	remoteURLEntry.SetPlaceHolder("127.0.0.1:9222 or ws://.../devtools/browser/...")
+	remoteURLEntry.SetText(cfg.RemoteDebuggingURL)
+
+	tabPoolEntry := widget.NewEntry()
+	tabPoolEntry.SetText(strconv.Itoa(cfg.TabPoolSize))
+
+	chromePathEntry := widget.NewEntry()
+	chromePathEntry.SetPlaceHolder("Found automatically")
+	chromePathEntry.SetText(cfg.ChromePath)
+	chromeLabel := widget.NewLabel("Using " + chrome.String())
+	chromeLabel.Wrapping = fyne.TextWrapWord
+
+	headlessCheck := widget.NewCheck("Hide Chrome Windows (headless, applies on next start)", func(v bool) {})
+	headlessCheck.SetChecked(cfg.ChromeHeadless)
+
+	windowWidth, windowHeight := chromeWindowSize()
+	windowWidthEntry := widget.NewEntry()
+	windowWidthEntry.SetText(strconv.Itoa(windowWidth))
+	windowHeightEntry := widget.NewEntry()
+	windowHeightEntry.SetText(strconv.Itoa(windowHeight))
+
+	failureLimitEntry := widget.NewEntry()
+	failureLimitEntry.SetText(strconv.Itoa(cfg.FailureSnapshotLimit))
+
 	minDeadlineEntry := widget.NewEntry()
 	minDeadlineEntry.SetText(strconv.Itoa(cfg.MinDeadlineHours))
 
 	maxDeadlineEntry := widget.NewEntry()
 	maxDeadlineEntry.SetText(strconv.Itoa(cfg.MaxDeadlineHours))
 
+	deadlinePolicySelect := widget.NewSelect([]string{DEADLINE_POLICY_SKIP, DEADLINE_POLICY_ACCEPT}, nil)
+	if cfg.UnparsedDeadlinePolicy == DEADLINE_POLICY_ACCEPT {
+		deadlinePolicySelect.SetSelected(DEADLINE_POLICY_ACCEPT)
+	} else {
+		deadlinePolicySelect.SetSelected(DEADLINE_POLICY_SKIP)
+	}
+
+	breakerThresholdEntry := widget.NewEntry()
+	breakerThresholdEntry.SetText(strconv.Itoa(breakerThreshold()))
+
+	breakerCooldownEntry := widget.NewEntry()
+	breakerCooldownEntry.SetText(strconv.Itoa(int(breakerCooldown() / time.Second)))
+
+	scanIntervalEntry := widget.NewEntry()
+	scanIntervalEntry.SetText(strconv.Itoa(int(baseScanInterval() / time.Millisecond)))
+
+	pageBudgetEntry := widget.NewEntry()
+	pageBudgetEntry.SetText(strconv.Itoa(maxPageLoadsPerMinute()))
+
+	scheduleCheck := widget.NewCheck("Working Hours Schedule", func(v bool) {})
+	scheduleCheck.SetChecked(cfg.Schedule.Enabled)
+
+	timezoneEntry := widget.NewEntry()
+	timezoneEntry.SetPlaceHolder("Local time (e.g. Europe/Kyiv)")
+	timezoneEntry.SetText(cfg.Schedule.Timezone)
+
+	scheduleArea := widget.NewMultiLineEntry()
+	scheduleArea.SetPlaceHolder("monday: 09:00-13:00, 14:00-18:00\n2026-12-25: closed")
+	scheduleArea.SetText(formatScheduleText(cfg.Schedule))
+
+	bidsPerHourEntry := widget.NewEntry()
+	bidsPerHourEntry.SetText(strconv.Itoa(cfg.MaxBidsPerHour))
+
+	bidsPerDayEntry := widget.NewEntry()
+	bidsPerDayEntry.SetText(strconv.Itoa(cfg.MaxBidsPerDay))
+
+	bidValuePerDayEntry := widget.NewEntry()
+	bidValuePerDayEntry.SetText(cfg.MaxBidValuePerDay.Decimal())
+
+	bidMarkupEntry := widget.NewEntry()
+	bidMarkupEntry.SetText(strconv.FormatFloat(cfg.BidMarkupPercent, 'f', -1, 64))
+
+	activeOrdersEntry := widget.NewEntry()
+	activeOrdersEntry.SetText(strconv.Itoa(cfg.MaxActiveOrders))
+
 	saveSettingsButton := widget.NewButton("Save Settings", func() {
 		cfg.MessageEnabled = messageCheck.Checked
 		cfg.MessageText = messageArea.Text
 		cfg.DiscardAssignments = discardAssignmentsCheck.Checked
 		cfg.DiscardEditing = discardEditingCheck.Checked
+		cfg.DryRun = dryRunCheck.Checked
+		cfg.CaptureEnabled = captureCheck.Checked
 		minDH, err1 := strconv.Atoi(minDeadlineEntry.Text)
 		maxDH, err2 := strconv.Atoi(maxDeadlineEntry.Text)
 		tc, err3 := strconv.Atoi(threadEntry.Text)
-
-		if err1 != nil || err2 != nil || err3 != nil {
+		bt, err4 := strconv.Atoi(breakerThresholdEntry.Text)
+		bc, err5 := strconv.Atoi(breakerCooldownEntry.Text)
+		si, err6 := strconv.Atoi(scanIntervalEntry.Text)
+		pb, err7 := strconv.Atoi(pageBudgetEntry.Text)
+		bph, err8 := strconv.Atoi(bidsPerHourEntry.Text)
+		bpd, err9 := strconv.Atoi(bidsPerDayEntry.Text)
+		bvd, err10 := parseMoneyAmount(strings.TrimPrefix(strings.TrimSpace(bidValuePerDayEntry.Text), "$"), RoundDown)
+		mao, err11 := strconv.Atoi(activeOrdersEntry.Text)
+		bmp, err12 := strconv.ParseFloat(bidMarkupEntry.Text, 64)
+		fsl, err13 := strconv.Atoi(failureLimitEntry.Text)
+		tps, err14 := strconv.Atoi(tabPoolEntry.Text)
+		cww, err15 := strconv.Atoi(windowWidthEntry.Text)
+		cwh, err16 := strconv.Atoi(windowHeightEntry.Text)
+
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil || err5 != nil || err6 != nil || err7 != nil ||
+			err8 != nil || err9 != nil || err10 != nil || err11 != nil || err12 != nil || err13 != nil || err14 != nil ||
+			err15 != nil || err16 != nil || bmp < 0 || fsl < 0 || tps < 0 {
 			dialog.ShowError(fmt.Errorf("invalid numeric input in settings"), w)
 			return
 		}
 
+		schedule, err := parseScheduleText(scheduleArea.Text)
+		if err == nil {
+			schedule.Enabled = scheduleCheck.Checked
+			schedule.Timezone = strings.TrimSpace(timezoneEntry.Text)
+			err = schedule.Validate()
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid working hours schedule: %w", err), w)
+			return
+		}
+		cfg.Schedule = schedule
+
 		cfg.MinDeadlineHours = minDH
 		cfg.MaxDeadlineHours = maxDH
+		cfg.UnparsedDeadlinePolicy = deadlinePolicySelect.Selected
 		if tc <= 0 {
 			tc = DEFAULT_THREAD_COUNT
 		}
 		cfg.ThreadCount = tc
+		cfg.BreakerThreshold = bt
+		cfg.BreakerCooldownSec = bc
+		cfg.ScanIntervalMs = si
+		cfg.MaxPageLoadsPerMinute = pb
+		cfg.MaxBidsPerHour = bph
+		cfg.MaxBidsPerDay = bpd
+		cfg.MaxBidValuePerDay = bvd
+		cfg.BidMarkupPercent = bmp
+		cfg.MaxActiveOrders = mao
+		cfg.FailureSnapshotLimit = fsl
+		cfg.BrowserMode = browserModeSelect.Selected
+		cfg.TabPoolSize = tps
+		cfg.RemoteDebuggingURL = strings.TrimSpace(remoteURLEntry.Text)
+		cfg.ChromePath = strings.TrimSpace(chromePathEntry.Text)
+		cfg.ChromeHeadless = headlessCheck.Checked
+		cfg.ChromeWindowWidth = cww
+		cfg.ChromeWindowHeight = cwh
 		saveConfig()
+		if atomic.LoadInt32(&botRunning) != 0 {
+			applySchedule(time.Now())
+			updateBidCapStatus()
+		}
 		dialog.ShowInformation("Settings Saved", "Your settings have been saved.", w)
 	})
 
@@ -175,10 +393,34 @@ func main() {
 		messageCheck,
 		messageArea,
 		widget.NewLabel("Threads:"), threadEntry,
+		widget.NewLabel("Browser Mode (applies on next start):"), browserModeSelect,
+		widget.NewLabel("Remote Chrome (for remote mode, started with --remote-debugging-port):"), remoteURLEntry,
+		widget.NewLabel("Shared Browser Tabs (0 = one per thread):"), tabPoolEntry,
+		widget.NewLabel("Chrome Path (applies after restarting the app):"), chromePathEntry,
+		chromeLabel,
+		headlessCheck,
+		widget.NewLabel("Chrome Window Size (width x height):"),
+		container.NewGridWithColumns(2, windowWidthEntry, windowHeightEntry),
 		discardAssignmentsCheck,
 		discardEditingCheck,
+		dryRunCheck,
+		captureCheck,
+		widget.NewLabel("Failure Snapshots Kept (0 = off):"), failureLimitEntry,
 		widget.NewLabel("Minimum Deadline (hours):"), minDeadlineEntry,
 		widget.NewLabel("Maximum Deadline (hours):"), maxDeadlineEntry,
+		widget.NewLabel("Orders With Unreadable Deadlines:"), deadlinePolicySelect,
+		widget.NewLabel("Circuit Breaker Threshold (failures):"), breakerThresholdEntry,
+		widget.NewLabel("Circuit Breaker Cooldown (seconds):"), breakerCooldownEntry,
+		widget.NewLabel("Scan Interval (ms):"), scanIntervalEntry,
+		widget.NewLabel("Max Page Loads per Minute:"), pageBudgetEntry,
+		scheduleCheck,
+		widget.NewLabel("Schedule Timezone:"), timezoneEntry,
+		widget.NewLabel("Working Hours (one day or date per line):"), scheduleArea,
+		widget.NewLabel("Max Bids per Hour (0 = no limit):"), bidsPerHourEntry,
+		widget.NewLabel("Max Bids per Day (0 = no limit):"), bidsPerDayEntry,
+		widget.NewLabel("Max Bid Value per Day ($, 0 = no limit):"), bidValuePerDayEntry,
+		widget.NewLabel("Bid Markup over Minimum (%):"), bidMarkupEntry,
+		widget.NewLabel("Max Active Orders (0 = no limit):"), activeOrdersEntry,
 		saveSettingsButton,
 	)
 
@@ -193,8 +435,41 @@ func main() {
 		currentContent.Objects = []fyne.CanvasObject{settingsContent}
 		currentContent.Refresh()
 	})
+	notificationsContent := notificationSettingsContent(w)
+	notificationsItem := fyne.NewMenuItem("Notifications", func() {
+		currentContent.Objects = []fyne.CanvasObject{notificationsContent}
+		currentContent.Refresh()
+	})
+	webhooksContent := webhookSettingsContent(w)
+	webhooksItem := fyne.NewMenuItem("Webhooks", func() {
+		currentContent.Objects = []fyne.CanvasObject{webhooksContent}
+		currentContent.Refresh()
+	})
+	// Built on each visit so the figures are current.
+	analyticsItem := fyne.NewMenuItem("Analytics", func() {
+		currentContent.Objects = []fyne.CanvasObject{analyticsContent(w)}
+		currentContent.Refresh()
+	})
+	scriptContent := scriptSettingsContent(w)
+	scriptItem := fyne.NewMenuItem("Script", func() {
+		currentContent.Objects = []fyne.CanvasObject{scriptContent}
+		currentContent.Refresh()
+	})
+	marketItem := fyne.NewMenuItem("Market", func() {
+		currentContent.Objects = []fyne.CanvasObject{marketContent(w)}
+		currentContent.Refresh()
+	})
+	workersItem := fyne.NewMenuItem("Workers", func() {
+		currentContent.Objects = []fyne.CanvasObject{workersContent()}
+		currentContent.Refresh()
+	})
+	pageLoadsContent := blockingContent(w)
+	pageLoadsItem := fyne.NewMenuItem("Page Loads", func() {
+		currentContent.Objects = []fyne.CanvasObject{pageLoadsContent}
+		currentContent.Refresh()
+	})
 	menu := fyne.NewMainMenu(
-		fyne.NewMenu("Menu", homeItem, settingsItem),
+		fyne.NewMenu("Menu", homeItem, settingsItem, notificationsItem, webhooksItem, scriptItem, analyticsItem, marketItem, workersItem, pageLoadsItem),
 	)
 	w.SetMainMenu(menu)
 
@@ -229,12 +504,41 @@ func startBot(allocCtx context.Context, win fyne.Window, chromePath string) {
 	stdLog.Println("Starting the bidding bot...")
 	debugLogger.Println("Bot start initiated.")
 
-	executorWG = sync.WaitGroup{}
-	for i := 0; i < cfg.ThreadCount; i++ {
-		executorWG.Add(1)
-		go runWorker(i, allocCtx)
+	breaker.Reset()
+	scheduler.Reset()
+	bidLedger.Load()
+	outcomes.Load()
+	pruneMarketData(time.Now())
+	if cfg.DryRun {
+		atomic.StoreInt32(&dryRunActive, 1)
+		stdLog.Println("Dry run: orders are evaluated but nothing is submitted.")
+	} else {
+		atomic.StoreInt32(&dryRunActive, 0)
+	}
+	if cfg.CaptureEnabled {
+		if err := recorder.Start(); err != nil {
+			stdLog.Printf("Page capture disabled: %v", err)
+			debugLogger.Printf("Recorder start error: %v", err)
+		}
+	}
+	atomic.StoreInt32(&botRunning, 1)
+	updateBidCapStatus()
+	refreshStatus()
+	runCtx := beginRun(allocCtx)
+
+	session.Load(userEmail, userPassword)
+	switch browserMode() {
+	case BROWSER_MODE_SHARED:
+		sharedPool = newTabPool(runCtx, &sharedChrome{opts: chromeOptions(chromePath)}, tabPoolSize())
+		stdLog.Printf("Shared browser mode: %d workers share %d tabs.", cfg.ThreadCount, tabPoolSize())
+	case BROWSER_MODE_REMOTE:
+		sharedPool = newTabPool(runCtx, remoteBrowser, tabPoolSize())
+		stdLog.Printf("Remote browser mode: %d workers share %d tabs of the Chrome at %s.", cfg.ThreadCount, tabPoolSize(), cfg.RemoteDebuggingURL)
 	}
 
+	executorWG = sync.WaitGroup{}
+	supervisor.Start(runCtx, cfg.ThreadCount, chromePath)
+
 	dialog.ShowInformation("Bot Started", "The bidding bot has started working.", win)
 }
 
@@ -245,1261 +549,197 @@ func stopBot() {
 
 	// Wait for all workers to finish
 	executorWG.Wait()
-
-	// Cancel the main context to close Chrome instances
-	mainCancel()
-	stdLog.Println("Bidding bot stopped.")
-	debugLogger.Println("Main context canceled, Chrome instances should close.")
-}
-
-func runWorker(threadIndex int, allocCtx context.Context) {
-	defer executorWG.Done()
-	debugLogger.Printf("Worker %d started.", threadIndex)
-
-	userDataDir := filepath.Join(getSysfilesDir(), CHROME_USER_DATA_DIR, strconv.Itoa(threadIndex))
-	os.MkdirAll(userDataDir, 0755)
-
-	userAgent := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/117.0.0.0 Safari/537.36"
-
-	// Create a new context with the user data directory
-	taskCtx, cancel := chromedp.NewContext(allocCtx,
-		chromedp.WithLogf(debugLogger.Printf),
-	)
-	defer cancel()
-
-	// Configure Chrome options for anti-detection
-	opts := []chromedp.RunBrowserOption{
-		chromedp.WindowSize(CHROME_WINDOW_WIDTH, CHROME_WINDOW_HEIGHT),
-		chromedp.UserAgent(userAgent),
-		chromedp.NoDefaultBrowserCheck,
-		chromedp.Flag("disable-blink-features", "AutomationControlled"),
-		chromedp.Flag("disable-infobars", true),
-		chromedp.Flag("excludeSwitches", "enable-automation"),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-gpu", true),
-	}
-
-	// Apply options
-	err := chromedp.Run(taskCtx, opts...)
-	if err != nil {
-		stdLog.Printf("Worker %d: Failed to run Chromedp with options: %v", threadIndex, err)
-		debugLogger.Printf("Worker %d: Chromedp run error: %v", threadIndex, err)
-		return
-	}
-
-	// Enable verbose logging for chromedp
-	chromedp.ListenTarget(taskCtx, func(ev interface{}) {
-		switch ev := ev.(type) {
-		case *chromelog.EventEntryAdded:
-			debugLogger.Printf("Chromedp Log: %s", ev.Entry.Text)
-		}
-	})
-
-	// Check if session is valid
-	sessionValid := checkSession(taskCtx)
-	if sessionValid {
-		stdLog.Printf("Thread %d: Existing session found, no login required.", threadIndex)
-		debugLogger.Printf("Thread %d: Existing session confirmed.", threadIndex)
-	} else {
-		stdLog.Printf("Thread %d: No valid session found, attempting to log in.", threadIndex)
-		debugLogger.Printf("Thread %d: Session invalid, performing login.", threadIndex)
-		if err := performLogin(taskCtx, userEmail, userPassword); err != nil {
-			stdLog.Printf("Thread %d: Failed to login: %v", threadIndex, err)
-			debugLogger.Printf("Thread %d: Login error: %v", threadIndex, err)
-			return
-		}
-		stdLog.Printf("Thread %d: Logged in successfully.", threadIndex)
-		debugLogger.Printf("Thread %d: Login successful.", threadIndex)
-	}
-
-	// Initial delay after login/session check (3-7 seconds)
-	initialWait := time.Duration(rand.Intn(4000)+3000) * time.Millisecond
-	stdLog.Printf("Thread %d: Initial wait for %v before starting to bid.", threadIndex, initialWait)
-	debugLogger.Printf("Thread %d: Sleeping for %v before bidding loop.", threadIndex, initialWait)
-	time.Sleep(initialWait)
-
-	// Main bidding loop
-	for atomic.LoadInt32(&stopFlag) == 0 {
-		processed, err := findAndHandleSingleOrder(taskCtx, threadIndex)
-		if atomic.LoadInt32(&stopFlag) != 0 {
-			break
-		}
-		if err != nil {
-			if isContextError(err) {
-				stdLog.Printf("Thread %d: No orders processed or context error, continuing.", threadIndex)
-				debugLogger.Printf("Thread %d: Context error: %v", threadIndex, err)
-				continue
-			} else {
-				stdLog.Printf("Thread %d: Error processing order: %v", threadIndex, err)
-				debugLogger.Printf("Thread %d: Unexpected error: %v", threadIndex, err)
-				continue
-			}
-		}
-
-		if !processed {
-			stdLog.Printf("Thread %d: No orders processed, continuing.", threadIndex)
-			debugLogger.Printf("Thread %d: No orders found during this iteration.", threadIndex)
-			continue
-		}
-
-		// No sleep to ensure immediate responsiveness
-	}
-	debugLogger.Printf("Worker %d exiting loop.", threadIndex)
-}
-
-func checkSession(ctx context.Context) bool {
-	ctxCheck, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	err := chromedp.Run(ctxCheck,
-		chromedp.Navigate("https://essayshark.com/writer/orders/"),
-		chromedp.WaitVisible(`#available_orders_list_container`, chromedp.ByID),
-	)
-	if err != nil {
-		debugLogger.Printf("Session check failed: %v", err)
-		return false
-	}
-	return true
-}
-
-func performLogin(ctx context.Context, email, password string) error {
-	ctxLogin, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	err := chromedp.Run(ctxLogin,
-		chromedp.Navigate("https://essayshark.com/log-in.html"),
-		chromedp.WaitVisible(`input[name="login"]`, chromedp.ByQuery),
-		chromedp.WaitVisible(`input[name="password"]`, chromedp.ByQuery),
-		chromedp.Clear(`input[name="login"]`, chromedp.ByQuery),
-		chromedp.SendKeys(`input[name="login"]`, email),
-		chromedp.Clear(`input[name="password"]`, chromedp.ByQuery),
-		chromedp.SendKeys(`input[name="password"]`, password),
-		chromedp.Click(`button.bb-button[type="submit"]`, chromedp.NodeVisible),
-		chromedp.WaitVisible(`#available_orders_list_container`, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error during login: %w", err)
-	}
-
-	return nil
-}
-
-func findAndHandleSingleOrder(ctx context.Context, threadIndex int) (bool, error) {
-	ctxOrders, cancel := context.WithTimeout(ctx, 20*time.Second)
-	defer cancel()
-
-	err := chromedp.Run(ctxOrders,
-		chromedp.Navigate("https://essayshark.com/writer/orders/"),
-		chromedp.WaitVisible(`tr.order_container`, chromedp.ByQuery),
-	)
-	if err != nil {
-		debugLogger.Printf("Thread %d: Error navigating to orders page: %v", threadIndex, err)
-		return false, err
-	}
-
-	var result struct {
-		Links     []string `json:"links"`
-		Services  []string `json:"services"`
-		Deadlines []string `json:"deadlines"`
-	}
-
-	err = chromedp.Run(ctxOrders,
-		chromedp.Evaluate(`
-			(function(){
-				let rows = document.querySelectorAll("tr.order_container");
-				let data = {links: [], services: [], deadlines: []};
-				for (let row of rows) {
-					let topicLink = row.querySelector("td.topictitle a");
-					let serviceEl = row.querySelector("div.service_type");
-					let deadlineEl = row.querySelector("td.td_deadline span.d-deadline + span.d-left");
-					data.links.push(topicLink ? topicLink.href : "");
-					data.services.push(serviceEl ? serviceEl.textContent.trim() : "");
-					data.deadlines.push(deadlineEl ? deadlineEl.textContent.trim() : "");
-				}
-				return data;
-			})()
-		`, &result),
-	)
-	if err != nil {
-		debugLogger.Printf("Thread %d: Error evaluating orders: %v", threadIndex, err)
-		return false, err
-	}
-
-	orderLinks := result.Links
-	serviceTypes := result.Services
-	deadlineTexts := result.Deadlines
-
-	for i, orderUrl := range orderLinks {
-		if orderUrl == "" {
-			continue
-		}
-		if atomic.LoadInt32(&stopFlag) != 0 {
-			return false, nil
-		}
-
-		orderLock.Lock()
-		_, alreadyProcessing := orderToThreadMap[orderUrl]
-		if alreadyProcessing {
-			orderLock.Unlock()
-			continue
-		}
-		orderToThreadMap[orderUrl] = threadIndex
-		orderLock.Unlock()
-
-		if shouldDiscardServiceType(serviceTypes[i]) {
-			discardOrder(orderUrl)
-			orderLock.Lock()
-			delete(orderToThreadMap, orderUrl)
-			orderLock.Unlock()
-			continue
-		}
-
-		dh := convertDeadlineToHours(deadlineTexts[i])
-		if dh != -1 && (dh < cfg.MinDeadlineHours || dh > cfg.MaxDeadlineHours) {
-			stdLog.Printf("Thread %d: Order %s deadline (%dh) out of range.", threadIndex, orderUrl, dh)
-			discardOrder(orderUrl)
-			orderLock.Lock()
-			delete(orderToThreadMap, orderUrl)
-			orderLock.Unlock()
-			continue
-		}
-
-		// Open order details
-		ctxOrderDetail, cancelOrderDetail := context.WithTimeout(ctx, 20*time.Second)
-		defer cancelOrderDetail()
-
-		err = chromedp.Run(ctxOrderDetail,
-			chromedp.Navigate(orderUrl),
-			chromedp.WaitReady(`body`, chromedp.ByQuery),
-		)
-		if err != nil {
-			stdLog.Printf("Thread %d: Failed to open order %s: %v", threadIndex, orderUrl, err)
-			orderLock.Lock()
-			delete(orderToThreadMap, orderUrl)
-			orderLock.Unlock()
-			continue
-		}
-
-		// Handle the order (place bid or apply)
-		err = handleOrder(ctxOrderDetail, orderUrl, threadIndex)
-		if err != nil {
-			stdLog.Printf("Thread %d: Error handling order %s: %v", threadIndex, orderUrl, err)
-			orderLock.Lock()
-			delete(orderToThreadMap, orderUrl)
-			orderLock.Unlock()
-			continue
-		}
-
-		// Remove from processing map
-		orderLock.Lock()
-		delete(orderToThreadMap, orderUrl)
-		orderLock.Unlock()
-
-		return true, nil // Processed an order
-	}
-
-	return false, nil // No orders processed
-}
-
-func handleOrder(ctx context.Context, orderUrl string, threadIndex int) error {
-	isFixed, err := isFixedPriceOrder(ctx)
-	if err != nil {
-		return fmt.Errorf("error checking if order is fixed-price: %w", err)
-	}
-
-	if hasCountdown, seconds := checkCountdown(ctx); hasCountdown {
-		stdLog.Printf("Thread %d: Order %s has countdown: %d seconds. Waiting...", threadIndex, orderUrl, seconds)
-		debugLogger.Printf("Thread %d: Waiting for %d seconds due to countdown.", threadIndex, seconds)
-		time.Sleep(time.Duration(seconds) * time.Second)
-	}
-
-	if hasAttachments(ctx) {
-		err := downloadFileIfAvailable(ctx)
-		if err != nil {
-			stdLog.Printf("Thread %d: Error downloading attachments for order %s: %v", threadIndex, orderUrl, err)
-			debugLogger.Printf("Thread %d: Attachment download error: %v", threadIndex, err)
-		}
-	}
-
-	if isFixed {
-		stdLog.Printf("Thread %d: Order %s is fixed-price. Applying directly.", threadIndex, orderUrl)
-		debugLogger.Printf("Thread %d: Applying for fixed-price order.", threadIndex)
-		err = applyForOrder(ctx)
-		if err != nil {
-			return fmt.Errorf("error applying for fixed-price order: %w", err)
-		}
-	} else {
-		stdLog.Printf("Thread %d: Order %s is not fixed-price. Placing bid.", threadIndex, orderUrl)
-		debugLogger.Printf("Thread %d: Placing bid on order.", threadIndex)
-		err = placeBid(ctx, threadIndex)
-		if err != nil {
-			return fmt.Errorf("error placing bid: %w", err)
-		}
-	}
-
-	if cfg.MessageEnabled {
-		err = sendMessageToClient(ctx, cfg.MessageText)
-		if err != nil {
-			stdLog.Printf("Thread %d: Error sending message for order %s: %v", threadIndex, orderUrl, err)
-			debugLogger.Printf("Thread %d: Message sending error: %v", threadIndex, err)
-		}
-	}
-
-	// Navigate back to orders page without delay
-	ctxBack, cancelBack := context.WithTimeout(ctx, 10*time.Second)
-	defer cancelBack()
-	err = chromedp.Run(ctxBack, chromedp.Navigate("https://essayshark.com/writer/orders/"))
-	if err != nil {
-		stdLog.Printf("Thread %d: Error navigating back to orders page: %v", threadIndex, err)
-		debugLogger.Printf("Thread %d: Navigation back error: %v", threadIndex, err)
-	}
-
-	return nil
-}
-
-func isFixedPriceOrder(ctx context.Context) (bool, error) {
-	var bodyText string
-	ctxCheck, cancelCheck := context.WithTimeout(ctx, 10*time.Second)
-	defer cancelCheck()
-
-	err := chromedp.Run(ctxCheck, chromedp.Text("body", &bodyText))
-	if err != nil {
-		return false, fmt.Errorf("error retrieving page body: %w", err)
-	}
-
-	if strings.Contains(strings.ToLower(bodyText), "this field is disabled for fixed-price orders") {
-		return true, nil
-	}
-	return false, nil
-}
-
-func checkCountdown(ctx context.Context) (bool, int) {
-	var countdownText string
-	ctxCount, cancelCount := context.WithTimeout(ctx, 5*time.Second)
-	defer cancelCount()
-
-	err := chromedp.Run(ctxCount,
-		chromedp.Text(`#id_read_timeout_sec`, &countdownText, chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil || countdownText == "" {
-		return false, 0
-	}
-	var sec int
-	fmt.Sscanf(countdownText, "%d", &sec)
-	return true, sec
-}
-
-func hasAttachments(ctx context.Context) bool {
-	var bodyText string
-	ctxAttach, cancelAttach := context.WithTimeout(ctx, 5*time.Second)
-	defer cancelAttach()
-
-	err := chromedp.Run(ctxAttach, chromedp.Text("body", &bodyText))
-	if err != nil {
-		debugLogger.Printf("Error checking attachments: %v", err)
-		return false
-	}
-	return strings.Contains(strings.ToLower(bodyText), "uploaded additional materials:")
-}
-
-func downloadFileIfAvailable(ctx context.Context) error {
-	// Implement actual download logic if needed
-	// For now, just log the action
-	stdLog.Println("Simulated file download to downloads directory.")
-	debugLogger.Println("Simulated file download action.")
-	return nil
-}
-
-func applyForOrder(ctx context.Context) error {
-	ctxApply, cancelApply := context.WithTimeout(ctx, 5*time.Second)
-	defer cancelApply()
-
-	err := chromedp.Run(ctxApply,
-		chromedp.Click("#apply_order", chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error clicking apply button: %w", err)
-	}
-	return nil
-}
-
-func placeBid(ctx context.Context, threadIndex int) error {
-	ctxBid, cancelBid := context.WithTimeout(ctx, 10*time.Second)
-	defer cancelBid()
-
-	err := chromedp.Run(ctxBid,
-		chromedp.SetValue("#id_bid4", "-1.00", chromedp.ByID),
-		chromedp.Click("#apply_order", chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error setting bid value or clicking apply: %w", err)
-	}
-
-	var errText string
-	err = chromedp.Run(ctxBid,
-		chromedp.Text("#id_bid4-error", &errText, chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error retrieving bid error message: %w", err)
-	}
-
-	minBid := extractMinimumBid(errText)
-	if minBid <= 0 {
-		stdLog.Printf("Thread %d: Invalid minimum bid extracted, skipping.", threadIndex)
-		debugLogger.Printf("Thread %d: Extracted minimum bid is invalid: %f", threadIndex, minBid)
-		return nil
-	}
-
-	err = chromedp.Run(ctxBid,
-		chromedp.SetValue("#id_bid4", fmt.Sprintf("%.2f", minBid), chromedp.ByID),
-		chromedp.Click("#apply_order", chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error setting minimum bid or clicking apply: %w", err)
-	}
-
-	return nil
-}
-
-func extractMinimumBid(errorMessage string) float64 {
-	var amount float64
-	fmt.Sscanf(errorMessage, "Minimum bid is $%f", &amount)
-	return amount
-}
-
-func sendMessageToClient(ctx context.Context, msg string) error {
-	ctxMsg, cancelMsg := context.WithTimeout(ctx, 5*time.Second)
-	defer cancelMsg()
-
-	err := chromedp.Run(ctxMsg,
-		chromedp.SetValue("#id_body", msg, chromedp.ByID),
-		chromedp.Click("#id_send_message", chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error sending message: %w", err)
-	}
-
-	return nil
-}
-
-func discardOrder(orderUrl string) {
-	stdLog.Printf("Discarding order %s.", orderUrl)
-	debugLogger.Printf("Order %s discarded based on service type.", orderUrl)
-}
-
-func shouldDiscardServiceType(serviceType string) bool {
-	serviceTypeLower := strings.ToLower(serviceType)
-	if cfg.DiscardAssignments && serviceTypeLower == "writing help or assignments" {
-		return true
-	}
-	if cfg.DiscardEditing && serviceTypeLower == "editing" {
-		return true
-	}
-	return false
-}
-
-func convertDeadlineToHours(deadlineText string) int {
-	if deadlineText == "" {
-		return -1
-	}
-	var days, hours int
-	_, err := fmt.Sscanf(deadlineText, "%dd %dh", &days, &hours)
-	if err != nil {
-		return -1
-	}
-	return days*24 + hours
-}
-
-func loadConfig() {
-	configPath := getConfigPath()
-	data, err := ioutil.ReadFile(configPath)
-	if err != nil {
-		stdLog.Println("Config file not found. Using default settings.")
-		debugLogger.Println("Config load error:", err)
-		setDefaultConfig()
-		return
-	}
-	err = json.Unmarshal(data, cfg)
-	if err != nil {
-		stdLog.Println("Error parsing config file. Using default settings.")
-		debugLogger.Println("Config parse error:", err)
-		setDefaultConfig()
-	}
-}
-
-func setDefaultConfig() {
-	cfg.MessageEnabled = false
-	cfg.MessageText = ""
-	cfg.DiscardAssignments = false
-	cfg.DiscardEditing = false
-	cfg.MinDeadlineHours = DEFAULT_MIN_DEADLINE_HS
-	cfg.MaxDeadlineHours = DEFAULT_MAX_DEADLINE_HS
-	cfg.ThreadCount = DEFAULT_THREAD_COUNT
-}
-
-func saveConfig() {
-	configDir := getConfigDir()
-	os.MkdirAll(configDir, 0755)
-	configPath := getConfigPath()
-	data, err := json.MarshalIndent(cfg, "", "  ")
-	if err != nil {
-		stdLog.Printf("Error marshaling config: %v", err)
-		debugLogger.Printf("Config marshal error: %v", err)
-		return
-	}
-	err = ioutil.WriteFile(configPath, data, 0644)
-	if err != nil {
-		stdLog.Printf("Error writing config file: %v", err)
-		debugLogger.Printf("Config write error: %v", err)
-	}
-}
-
-func getConfigDir() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		home = "."
-	}
-	return filepath.Join(home, CONFIG_DIR_NAME)
-}
-
-func getConfigPath() string {
-	return filepath.Join(getConfigDir(), CONFIG_FILE_NAME)
-}
-
-func getSysfilesDir() string {
-	cwd, err := os.Getwd()
-	if err != nil {
-		cwd = "."
-	}
-	return filepath.Join(cwd, SYSFILES_FOLDER)
-}
-
-func findChromeExecutable() (string, error) {
-	// Attempt to find Chrome executable based on OS
-	if isWindows() {
-		paths := []string{
-			`C:\Program Files\Google\Chrome\Application\chrome.exe`,
-			`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
-		}
-		for _, p := range paths {
-			if fileExists(p) {
-				return p, nil
-			}
-		}
-	} else if isMac() {
-		p := `/Applications/Google Chrome.app/Contents/MacOS/Google Chrome`
-		if fileExists(p) {
-			return p, nil
-		}
-	} else { // Assume Linux
-		paths := []string{
-			`/usr/bin/google-chrome`,
-			`/usr/bin/chromium-browser`,
-			`/usr/bin/chrome`,
-		}
-		for _, p := range paths {
-			if fileExists(p) {
-				return p, nil
-			}
-		}
-	}
-	return "", fmt.Errorf("Chrome executable not found")
-}
-
-func isWindows() bool {
-	return strings.Contains(strings.ToLower(runtime.GOOS), "windows")
-}
-
-func isMac() bool {
-	return strings.Contains(strings.ToLower(runtime.GOOS), "darwin")
-}
-
-func fileExists(path string) bool {
-	info, err := os.Stat(path)
-	if os.IsNotExist(err) {
-		return false
-	}
-	return !info.IsDir()
-}
-
-func runWorker(threadIndex int, allocCtx context.Context) {
-	defer executorWG.Done()
-	debugLogger.Printf("Worker %d started.", threadIndex)
-
-	userDataDir := filepath.Join(getSysfilesDir(), CHROME_USER_DATA_DIR, strconv.Itoa(threadIndex))
-	os.MkdirAll(userDataDir, 0755)
-
-	userAgent := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/117.0.0.0 Safari/537.36"
-
-	// Create a new context with the user data directory
-	taskCtx, taskCancel := chromedp.NewContext(allocCtx,
-		chromedp.WithLogf(debugLogger.Printf),
-	)
-	defer taskCancel()
-
-	// Configure Chrome options for anti-detection
-	opts := []chromedp.RunBrowserOption{
-		chromedp.WindowSize(CHROME_WINDOW_WIDTH, CHROME_WINDOW_HEIGHT),
-		chromedp.UserAgent(userAgent),
-		chromedp.NoDefaultBrowserCheck,
-		chromedp.Flag("disable-blink-features", "AutomationControlled"),
-		chromedp.Flag("disable-infobars", true),
-		chromedp.Flag("excludeSwitches", "enable-automation"),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.UserDataDir(userDataDir), // Persist session
-	}
-
-	// Apply options
-	err := chromedp.Run(taskCtx, opts...)
-	if err != nil {
-		stdLog.Printf("Worker %d: Failed to run Chromedp with options: %v", threadIndex, err)
-		debugLogger.Printf("Worker %d: Chromedp run error: %v", threadIndex, err)
-		return
-	}
-
-	// Enable verbose logging for chromedp
-	chromedp.ListenTarget(taskCtx, func(ev interface{}) {
-		switch ev := ev.(type) {
-		case *chromelog.EventEntryAdded:
-			debugLogger.Printf("Chromedp Log: %s", ev.Entry.Text)
-		}
-	})
-
-	// Check if session is valid
-	sessionValid := checkSession(taskCtx)
-	if sessionValid {
-		stdLog.Printf("Thread %d: Existing session found, no login required.", threadIndex)
-		debugLogger.Printf("Thread %d: Existing session confirmed.", threadIndex)
-	} else {
-		stdLog.Printf("Thread %d: No valid session found, attempting to log in.", threadIndex)
-		debugLogger.Printf("Thread %d: Session invalid, performing login.", threadIndex)
-		if err := performLogin(taskCtx, userEmail, userPassword); err != nil {
-			stdLog.Printf("Thread %d: Failed to login: %v", threadIndex, err)
-			debugLogger.Printf("Thread %d: Login error: %v", threadIndex, err)
-			return
-		}
-		stdLog.Printf("Thread %d: Logged in successfully.", threadIndex)
-		debugLogger.Printf("Thread %d: Login successful.", threadIndex)
-	}
-
-	// Initial delay after login/session check (3-7 seconds)
-	initialWait := time.Duration(rand.Intn(4000)+3000) * time.Millisecond
-	stdLog.Printf("Thread %d: Initial wait for %v before starting to bid.", threadIndex, initialWait)
-	debugLogger.Printf("Thread %d: Sleeping for %v before bidding loop.", threadIndex, initialWait)
-	time.Sleep(initialWait)
-
-	// Main bidding loop
-	for atomic.LoadInt32(&stopFlag) == 0 {
-		processed, err := findAndHandleSingleOrder(taskCtx, threadIndex)
-		if atomic.LoadInt32(&stopFlag) != 0 {
-			break
-		}
-		if err != nil {
-			if isContextError(err) {
-				stdLog.Printf("Thread %d: No orders processed or context error, continuing.", threadIndex)
-				debugLogger.Printf("Thread %d: Context error: %v", threadIndex, err)
-				continue
-			} else {
-				stdLog.Printf("Thread %d: Error processing order: %v", threadIndex, err)
-				debugLogger.Printf("Thread %d: Unexpected error: %v", threadIndex, err)
-				continue
-			}
-		}
-
-		if !processed {
-			stdLog.Printf("Thread %d: No orders processed, continuing.", threadIndex)
-			debugLogger.Printf("Thread %d: No orders found during this iteration.", threadIndex)
-			continue
-		}
-
-		// No sleep to ensure immediate responsiveness
-	}
-	debugLogger.Printf("Worker %d exiting loop.", threadIndex)
-}
-
-func checkSession(ctx context.Context) bool {
-	ctxCheck, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	err := chromedp.Run(ctxCheck,
-		chromedp.Navigate("https://essayshark.com/writer/orders/"),
-		chromedp.WaitVisible(`#available_orders_list_container`, chromedp.ByID),
-	)
-	if err != nil {
-		debugLogger.Printf("Session check failed: %v", err)
-		return false
-	}
-	return true
-}
-
-func performLogin(ctx context.Context, email, password string) error {
-	ctxLogin, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	err := chromedp.Run(ctxLogin,
-		chromedp.Navigate("https://essayshark.com/log-in.html"),
-		chromedp.WaitVisible(`input[name="login"]`, chromedp.ByQuery),
-		chromedp.WaitVisible(`input[name="password"]`, chromedp.ByQuery),
-		chromedp.Clear(`input[name="login"]`, chromedp.ByQuery),
-		chromedp.SendKeys(`input[name="login"]`, email),
-		chromedp.Clear(`input[name="password"]`, chromedp.ByQuery),
-		chromedp.SendKeys(`input[name="password"]`, password),
-		chromedp.Click(`button.bb-button[type="submit"]`, chromedp.NodeVisible),
-		chromedp.WaitVisible(`#available_orders_list_container`, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error during login: %w", err)
-	}
-
-	return nil
-}
-
-func findAndHandleSingleOrder(ctx context.Context, threadIndex int) (bool, error) {
-	ctxOrders, cancel := context.WithTimeout(ctx, 20*time.Second)
-	defer cancel()
-
-	err := chromedp.Run(ctxOrders,
-		chromedp.Navigate("https://essayshark.com/writer/orders/"),
-		chromedp.WaitVisible(`tr.order_container`, chromedp.ByQuery),
-	)
-	if err != nil {
-		debugLogger.Printf("Thread %d: Error navigating to orders page: %v", threadIndex, err)
-		return false, err
-	}
-
-	var result struct {
-		Links     []string `json:"links"`
-		Services  []string `json:"services"`
-		Deadlines []string `json:"deadlines"`
-	}
-
-	err = chromedp.Run(ctxOrders,
-		chromedp.Evaluate(`
-			(function(){
-				let rows = document.querySelectorAll("tr.order_container");
-				let data = {links: [], services: [], deadlines: []};
-				for (let row of rows) {
-					let topicLink = row.querySelector("td.topictitle a");
-					let serviceEl = row.querySelector("div.service_type");
-					let deadlineEl = row.querySelector("td.td_deadline span.d-deadline + span.d-left");
-					data.links.push(topicLink ? topicLink.href : "");
-					data.services.push(serviceEl ? serviceEl.textContent.trim() : "");
-					data.deadlines.push(deadlineEl ? deadlineEl.textContent.trim() : "");
-				}
-				return data;
-			})()
-		`, &result),
-	)
-	if err != nil {
-		debugLogger.Printf("Thread %d: Error evaluating orders: %v", threadIndex, err)
-		return false, err
-	}
-
-	orderLinks := result.Links
-	serviceTypes := result.Services
-	deadlineTexts := result.Deadlines
-
-	for i, orderUrl := range orderLinks {
-		if orderUrl == "" {
-			continue
-		}
-		if atomic.LoadInt32(&stopFlag) != 0 {
-			return false, nil
-		}
-
-		orderLock.Lock()
-		_, alreadyProcessing := orderToThreadMap[orderUrl]
-		if alreadyProcessing {
-			orderLock.Unlock()
-			continue
-		}
-		orderToThreadMap[orderUrl] = threadIndex
-		orderLock.Unlock()
-
-		if shouldDiscardServiceType(serviceTypes[i]) {
-			discardOrder(orderUrl)
-			orderLock.Lock()
-			delete(orderToThreadMap, orderUrl)
-			orderLock.Unlock()
-			continue
-		}
-
-		dh := convertDeadlineToHours(deadlineTexts[i])
-		if dh != -1 && (dh < cfg.MinDeadlineHours || dh > cfg.MaxDeadlineHours) {
-			stdLog.Printf("Thread %d: Order %s deadline (%dh) out of range.", threadIndex, orderUrl, dh)
-			discardOrder(orderUrl)
-			orderLock.Lock()
-			delete(orderToThreadMap, orderUrl)
-			orderLock.Unlock()
-			continue
-		}
-
-		// Open order details
-		ctxOrderDetail, cancelOrderDetail := context.WithTimeout(ctx, 20*time.Second)
-		defer cancelOrderDetail()
-
-		err = chromedp.Run(ctxOrderDetail,
-			chromedp.Navigate(orderUrl),
-			chromedp.WaitReady(`body`, chromedp.ByQuery),
-		)
-		if err != nil {
-			stdLog.Printf("Thread %d: Failed to open order %s: %v", threadIndex, orderUrl, err)
-			orderLock.Lock()
-			delete(orderToThreadMap, orderUrl)
-			orderLock.Unlock()
-			continue
-		}
-
-		// Handle the order (place bid or apply)
-		err = handleOrder(ctxOrderDetail, orderUrl, threadIndex)
-		if err != nil {
-			stdLog.Printf("Thread %d: Error handling order %s: %v", threadIndex, orderUrl, err)
-			orderLock.Lock()
-			delete(orderToThreadMap, orderUrl)
-			orderLock.Unlock()
-			continue
-		}
-
-		// Remove from processing map
-		orderLock.Lock()
-		delete(orderToThreadMap, orderUrl)
-		orderLock.Unlock()
-
-		return true, nil // Processed an order
-	}
-
-	return false, nil // No orders processed
-}
-
-func handleOrder(ctx context.Context, orderUrl string, threadIndex int) error {
-	isFixed, err := isFixedPriceOrder(ctx)
-	if err != nil {
-		return fmt.Errorf("error checking if order is fixed-price: %w", err)
-	}
-
-	if hasCountdown, seconds := checkCountdown(ctx); hasCountdown {
-		stdLog.Printf("Thread %d: Order %s has countdown: %d seconds. Waiting...", threadIndex, orderUrl, seconds)
-		debugLogger.Printf("Thread %d: Waiting for %d seconds due to countdown.", threadIndex, seconds)
-		time.Sleep(time.Duration(seconds) * time.Second)
-	}
-
-	if hasAttachments(ctx) {
-		err := downloadFileIfAvailable(ctx)
-		if err != nil {
-			stdLog.Printf("Thread %d: Error downloading attachments for order %s: %v", threadIndex, orderUrl, err)
-			debugLogger.Printf("Thread %d: Attachment download error: %v", threadIndex, err)
-		}
-	}
-
-	if isFixed {
-		stdLog.Printf("Thread %d: Order %s is fixed-price. Applying directly.", threadIndex, orderUrl)
-		debugLogger.Printf("Thread %d: Applying for fixed-price order.", threadIndex)
-		err = applyForOrder(ctx)
-		if err != nil {
-			return fmt.Errorf("error applying for fixed-price order: %w", err)
-		}
-	} else {
-		stdLog.Printf("Thread %d: Order %s is not fixed-price. Placing bid.", threadIndex, orderUrl)
-		debugLogger.Printf("Thread %d: Placing bid on order.", threadIndex)
-		err = placeBid(ctx, threadIndex)
-		if err != nil {
-			return fmt.Errorf("error placing bid: %w", err)
-		}
-	}
-
-	if cfg.MessageEnabled {
-		err = sendMessageToClient(ctx, cfg.MessageText)
-		if err != nil {
-			stdLog.Printf("Thread %d: Error sending message for order %s: %v", threadIndex, orderUrl, err)
-			debugLogger.Printf("Thread %d: Message sending error: %v", threadIndex, err)
-		}
-	}
-
-	// Navigate back to orders page without delay
-	ctxBack, cancelBack := context.WithTimeout(ctx, 10*time.Second)
-	defer cancelBack()
-	err = chromedp.Run(ctxBack, chromedp.Navigate("https://essayshark.com/writer/orders/"))
-	if err != nil {
-		stdLog.Printf("Thread %d: Error navigating back to orders page: %v", threadIndex, err)
-		debugLogger.Printf("Thread %d: Navigation back error: %v", threadIndex, err)
-	}
-
-	return nil
-}
-
-func isFixedPriceOrder(ctx context.Context) (bool, error) {
-	var bodyText string
-	ctxCheck, cancelCheck := context.WithTimeout(ctx, 10*time.Second)
-	defer cancelCheck()
-
-	err := chromedp.Run(ctxCheck, chromedp.Text("body", &bodyText))
-	if err != nil {
-		return false, fmt.Errorf("error retrieving page body: %w", err)
-	}
-
-	if strings.Contains(strings.ToLower(bodyText), "this field is disabled for fixed-price orders") {
-		return true, nil
-	}
-	return false, nil
-}
-
-func checkCountdown(ctx context.Context) (bool, int) {
-	var countdownText string
-	ctxCount, cancelCount := context.WithTimeout(ctx, 5*time.Second)
-	defer cancelCount()
-
-	err := chromedp.Run(ctxCount,
-		chromedp.Text(`#id_read_timeout_sec`, &countdownText, chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil || countdownText == "" {
-		return false, 0
-	}
-	var sec int
-	fmt.Sscanf(countdownText, "%d", &sec)
-	return true, sec
-}
-
-func hasAttachments(ctx context.Context) bool {
-	var bodyText string
-	ctxAttach, cancelAttach := context.WithTimeout(ctx, 5*time.Second)
-	defer cancelAttach()
-
-	err := chromedp.Run(ctxAttach, chromedp.Text("body", &bodyText))
-	if err != nil {
-		debugLogger.Printf("Error checking attachments: %v", err)
-		return false
-	}
-	return strings.Contains(strings.ToLower(bodyText), "uploaded additional materials:")
-}
-
-func downloadFileIfAvailable(ctx context.Context) error {
-	// Implement actual download logic if needed
-	// For now, just log the action
-	stdLog.Println("Simulated file download to downloads directory.")
-	debugLogger.Println("Simulated file download action.")
-	return nil
-}
-
-func applyForOrder(ctx context.Context) error {
-	ctxApply, cancelApply := context.WithTimeout(ctx, 5*time.Second)
-	defer cancelApply()
-
-	err := chromedp.Run(ctxApply,
-		chromedp.Click("#apply_order", chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error clicking apply button: %w", err)
-	}
-	return nil
-}
-
-func placeBid(ctx context.Context, threadIndex int) error {
-	ctxBid, cancelBid := context.WithTimeout(ctx, 10*time.Second)
-	defer cancelBid()
-
-	err := chromedp.Run(ctxBid,
-		chromedp.SetValue("#id_bid4", "-1.00", chromedp.ByID),
-		chromedp.Click("#apply_order", chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error setting bid value or clicking apply: %w", err)
-	}
-
-	var errText string
-	err = chromedp.Run(ctxBid,
-		chromedp.Text("#id_bid4-error", &errText, chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error retrieving bid error message: %w", err)
-	}
-
-	minBid := extractMinimumBid(errText)
-	if minBid <= 0 {
-		stdLog.Printf("Thread %d: Invalid minimum bid extracted, skipping.", threadIndex)
-		debugLogger.Printf("Thread %d: Extracted minimum bid is invalid: %f", threadIndex, minBid)
-		return nil
-	}
-
-	err = chromedp.Run(ctxBid,
-		chromedp.SetValue("#id_bid4", fmt.Sprintf("%.2f", minBid), chromedp.ByID),
-		chromedp.Click("#apply_order", chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error setting minimum bid or clicking apply: %w", err)
-	}
-
-	return nil
-}
-
-func extractMinimumBid(errorMessage string) float64 {
-	var amount float64
-	fmt.Sscanf(errorMessage, "Minimum bid is $%f", &amount)
-	return amount
-}
-
-func sendMessageToClient(ctx context.Context, msg string) error {
-	ctxMsg, cancelMsg := context.WithTimeout(ctx, 5*time.Second)
-	defer cancelMsg()
-
-	err := chromedp.Run(ctxMsg,
-		chromedp.SetValue("#id_body", msg, chromedp.ByID),
-		chromedp.Click("#id_send_message", chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error sending message: %w", err)
-	}
-
-	return nil
-}
-
-func discardOrder(orderUrl string) {
-	stdLog.Printf("Discarding order %s.", orderUrl)
-	debugLogger.Printf("Order %s discarded based on service type.", orderUrl)
-}
-
-func shouldDiscardServiceType(serviceType string) bool {
-	serviceTypeLower := strings.ToLower(serviceType)
-	if cfg.DiscardAssignments && serviceTypeLower == "writing help or assignments" {
-		return true
-	}
-	if cfg.DiscardEditing && serviceTypeLower == "editing" {
-		return true
-	}
-	return false
-}
-
-func convertDeadlineToHours(deadlineText string) int {
-	if deadlineText == "" {
-		return -1
-	}
-	var days, hours int
-	_, err := fmt.Sscanf(deadlineText, "%dd %dh", &days, &hours)
-	if err != nil {
-		return -1
-	}
-	return days*24 + hours
-}
-
-func loadConfig() {
-	configPath := getConfigPath()
-	data, err := ioutil.ReadFile(configPath)
-	if err != nil {
-		stdLog.Println("Config file not found. Using default settings.")
-		debugLogger.Println("Config load error:", err)
-		setDefaultConfig()
-		return
-	}
-	err = json.Unmarshal(data, cfg)
-	if err != nil {
-		stdLog.Println("Error parsing config file. Using default settings.")
-		debugLogger.Println("Config parse error:", err)
-		setDefaultConfig()
-	}
-}
-
-func setDefaultConfig() {
-	cfg.MessageEnabled = false
-	cfg.MessageText = ""
-	cfg.DiscardAssignments = false
-	cfg.DiscardEditing = false
-	cfg.MinDeadlineHours = DEFAULT_MIN_DEADLINE_HS
-	cfg.MaxDeadlineHours = DEFAULT_MAX_DEADLINE_HS
-	cfg.ThreadCount = DEFAULT_THREAD_COUNT
-}
-
-func saveConfig() {
-	configDir := getConfigDir()
-	os.MkdirAll(configDir, 0755)
-	configPath := getConfigPath()
-	data, err := json.MarshalIndent(cfg, "", "  ")
-	if err != nil {
-		stdLog.Printf("Error marshaling config: %v", err)
-		debugLogger.Printf("Config marshal error: %v", err)
-		return
-	}
-	err = ioutil.WriteFile(configPath, data, 0644)
-	if err != nil {
-		stdLog.Printf("Error writing config file: %v", err)
-		debugLogger.Printf("Config write error: %v", err)
-	}
-}
-
-func getConfigDir() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		home = "."
-	}
-	return filepath.Join(home, CONFIG_DIR_NAME)
-}
-
-func getConfigPath() string {
-	return filepath.Join(getConfigDir(), CONFIG_FILE_NAME)
-}
-
-func getSysfilesDir() string {
-	cwd, err := os.Getwd()
-	if err != nil {
-		cwd = "."
-	}
-	return filepath.Join(cwd, SYSFILES_FOLDER)
-}
-
-func findChromeExecutable() (string, error) {
-	// Attempt to find Chrome executable based on OS
-	if isWindows() {
-		paths := []string{
-			`C:\Program Files\Google\Chrome\Application\chrome.exe`,
-			`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
-		}
-		for _, p := range paths {
-			if fileExists(p) {
-				return p, nil
-			}
-		}
-	} else if isMac() {
-		p := `/Applications/Google Chrome.app/Contents/MacOS/Google Chrome`
-		if fileExists(p) {
-			return p, nil
-		}
-	} else { // Assume Linux
-		paths := []string{
-			`/usr/bin/google-chrome`,
-			`/usr/bin/chromium-browser`,
-			`/usr/bin/chrome`,
-		}
-		for _, p := range paths {
-			if fileExists(p) {
-				return p, nil
-			}
-		}
-	}
-	return "", fmt.Errorf("Chrome executable not found")
-}
-
-func isWindows() bool {
-	return strings.Contains(strings.ToLower(os.Getenv("OS")), "windows")
-}
-
-func isMac() bool {
-	return strings.Contains(strings.ToLower(os.Getenv("OSTYPE")), "darwin")
+	if sharedPool != nil {
+		sharedPool.Close()
+		sharedPool = nil
+	}
+	atomic.StoreInt32(&botRunning, 0)
+	breaker.Reset()
+	recorder.Stop()
+	scheduleStatus.Store("")
+	clearPauses()
+
+	// Cancel the run's context to close Chrome instances
+	botCancel()
+	stdLog.Println("Bidding bot stopped.")
+	debugLogger.Println("Run context canceled, Chrome instances should close.")
 }
 
-func fileExists(path string) bool {
-	info, err := os.Stat(path)
-	if os.IsNotExist(err) {
-		return false
-	}
-	return !info.IsDir()
+// beginRun starts a run of the bot under parent, with the background tasks
+// that last as long as the run, and returns the run's context.
+func beginRun(parent context.Context) context.Context {
+	botCtx, botCancel = context.WithCancel(parent)
+	go runScheduleWatcher(botCtx)
+	return botCtx
 }
 
-func runWorker(threadIndex int, allocCtx context.Context) {
-	defer executorWG.Done()
+// runWorker logs in and bids until the bot is stopped or ctx ends. It
+// returns why it stopped early; the supervisor restarts it.
+func runWorker(ctx context.Context, threadIndex int, chromePath string) error {
 	debugLogger.Printf("Worker %d started.", threadIndex)
 
-	userDataDir := filepath.Join(getSysfilesDir(), CHROME_USER_DATA_DIR, strconv.Itoa(threadIndex))
-	os.MkdirAll(userDataDir, 0755)
-
-	userAgent := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/117.0.0.0 Safari/537.36"
-
-	// Create a new context with the user data directory
-	taskCtx, taskCancel := chromedp.NewContext(allocCtx,
-		chromedp.WithLogf(debugLogger.Printf),
-	)
-	defer taskCancel()
-
-	// Configure Chrome options for anti-detection
-	opts := []chromedp.RunBrowserOption{
-		chromedp.WindowSize(CHROME_WINDOW_WIDTH, CHROME_WINDOW_HEIGHT),
-		chromedp.UserAgent(userAgent),
-		chromedp.NoDefaultBrowserCheck,
-		chromedp.Flag("disable-blink-features", "AutomationControlled"),
-		chromedp.Flag("disable-infobars", true),
-		chromedp.Flag("excludeSwitches", "enable-automation"),
-		chromedp.Flag("disable-extensions", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.UserDataDir(userDataDir), // Persist session
+	pool := sharedPool
+	if pool == nil {
+		pool = newTabPool(ctx, chromeBrowser{opts: chromeOptions(chromePath)}, 1)
+		defer pool.Close()
 	}
 
-	// Apply options
-	err := chromedp.Run(taskCtx, opts...)
+	taskCtx, release, err := pool.Lease(ctx)
 	if err != nil {
 		stdLog.Printf("Worker %d: Failed to run Chromedp with options: %v", threadIndex, err)
 		debugLogger.Printf("Worker %d: Chromedp run error: %v", threadIndex, err)
-		return
+		return err
 	}
+	taskCtx = workerContext(taskCtx, ctx)
 
-	// Enable verbose logging for chromedp
-	chromedp.ListenTarget(taskCtx, func(ev interface{}) {
-		switch ev := ev.(type) {
-		case *chromelog.EventEntryAdded:
-			debugLogger.Printf("Chromedp Log: %s", ev.Entry.Text)
-		}
-	})
-
-	// Check if session is valid
-	sessionValid := checkSession(taskCtx)
-	if sessionValid {
-		stdLog.Printf("Thread %d: Existing session found, no login required.", threadIndex)
-		debugLogger.Printf("Thread %d: Existing session confirmed.", threadIndex)
-	} else {
-		stdLog.Printf("Thread %d: No valid session found, attempting to log in.", threadIndex)
-		debugLogger.Printf("Thread %d: Session invalid, performing login.", threadIndex)
-		if err := performLogin(taskCtx, userEmail, userPassword); err != nil {
-			stdLog.Printf("Thread %d: Failed to login: %v", threadIndex, err)
-			debugLogger.Printf("Thread %d: Login error: %v", threadIndex, err)
-			return
-		}
-		stdLog.Printf("Thread %d: Logged in successfully.", threadIndex)
-		debugLogger.Printf("Thread %d: Login successful.", threadIndex)
+	// Take the shared session, or log in and share it
+	sessionGen, err := ensureSession(taskCtx, threadIndex, -1)
+	if errors.Is(err, errLoginGivenUp) {
+		// The bot is paused until the user restarts it
+		release(false)
+		waitWhilePaused(ctx)
+		return ctx.Err()
 	}
+	if err != nil {
+		release(true)
+		stdLog.Printf("Thread %d: Failed to login: %v", threadIndex, err)
+		debugLogger.Printf("Thread %d: Login error: %v", threadIndex, err)
+		notify(EventLoginFailed, "Login Failed", fmt.Sprintf("Could not log in: %v", err))
+		return err
+	}
+	release(false)
 
 	// Initial delay after login/session check (3-7 seconds)
 	initialWait := time.Duration(rand.Intn(4000)+3000) * time.Millisecond
 	stdLog.Printf("Thread %d: Initial wait for %v before starting to bid.", threadIndex, initialWait)
 	debugLogger.Printf("Thread %d: Sleeping for %v before bidding loop.", threadIndex, initialWait)
-	time.Sleep(initialWait)
+	sleepUnlessStopped(ctx, initialWait)
 
-	// Main bidding loop
+	// Main bidding loop. The page is leased per scan so that in shared
+	// mode an idle worker does not hold a tab.
+	failures := 0
+	var lastActiveCheck, lastOutcomeCheck time.Time
 	for atomic.LoadInt32(&stopFlag) == 0 {
+		beat(ctx)
+		if !waitWhilePaused(ctx) {
+			break
+		}
+		if !breaker.Allow() {
+			sleepUnlessStopped(ctx, time.Second)
+			continue
+		}
+		if !scheduler.WaitForScan(ctx) {
+			breaker.Release()
+			break
+		}
+		taskCtx, release, err := pool.Lease(ctx)
+		if err != nil {
+			breaker.Release()
+			if atomic.LoadInt32(&stopFlag) != 0 || ctx.Err() != nil {
+				break
+			}
+			stdLog.Printf("Thread %d: Could not get a browser page, stopping worker: %v", threadIndex, err)
+			debugLogger.Printf("Thread %d: Page lease error: %v", threadIndex, err)
+			return err
+		}
+		taskCtx = workerContext(taskCtx, ctx)
+		sessionGen = refreshSession(taskCtx, threadIndex, sessionGen)
+
+		// One worker follows up on placed bids to learn which ones were won
+		if threadIndex == 0 && !isDryRun() && time.Since(lastOutcomeCheck) > OUTCOME_CHECK_INTERVAL {
+			lastOutcomeCheck = time.Now()
+			lastActiveCheck = lastOutcomeCheck // It counts active orders too
+			if err := checkOutcomes(taskCtx, threadIndex); err != nil {
+				debugLogger.Printf("Thread %d: Outcome check error: %v", threadIndex, err)
+			}
+		}
+		// and keeps the active order count fresh for the active-order limit
+		if threadIndex == 0 && cfg.MaxActiveOrders > 0 && time.Since(lastActiveCheck) > ACTIVE_ORDERS_CHECK_INTERVAL {
+			lastActiveCheck = time.Now()
+			if err := refreshActiveOrders(taskCtx); err != nil {
+				debugLogger.Printf("Thread %d: %v", threadIndex, err)
+			}
+		}
+
 		processed, err := findAndHandleSingleOrder(taskCtx, threadIndex)
-		if atomic.LoadInt32(&stopFlag) != 0 {
+		breaker.Release()
+		var loginErr error
+		if errors.Is(err, ErrLoggedOut) && atomic.LoadInt32(&stopFlag) == 0 {
+			stdLog.Printf("Thread %d: Session expired, logging in again.", threadIndex)
+			debugLogger.Printf("Thread %d: Logged out: %v", threadIndex, err)
+			sessionGen, loginErr = relogin(taskCtx, threadIndex, sessionGen)
+		}
+		release(errors.Is(err, ErrBrowserDead) && !stopRequested(taskCtx))
+		if atomic.LoadInt32(&stopFlag) != 0 || ctx.Err() != nil {
 			break
 		}
 		if err != nil {
-			if isContextError(err) {
-				stdLog.Printf("Thread %d: No orders processed or context error, continuing.", threadIndex)
-				debugLogger.Printf("Thread %d: Context error: %v", threadIndex, err)
-				continue
-			} else {
-				stdLog.Printf("Thread %d: Error processing order: %v", threadIndex, err)
-				debugLogger.Printf("Thread %d: Unexpected error: %v", threadIndex, err)
-				continue
+			failures++
+			emitErrorWebhook(threadIndex, "", err)
+			switch {
+			case errors.Is(err, ErrBrowserDead):
+				stdLog.Printf("Thread %d: Browser is gone, stopping worker: %v", threadIndex, err)
+				debugLogger.Printf("Thread %d: Browser dead: %v", threadIndex, err)
+				return err
+			case errors.Is(err, ErrLoggedOut):
+				if loginErr == nil {
+					failures = 0
+					continue
+				}
+				debugLogger.Printf("Thread %d: Re-login error: %v", threadIndex, loginErr)
+				if !errors.Is(loginErr, errLoginGivenUp) {
+					notify(EventLoginFailed, "Login Failed", fmt.Sprintf("Could not log in again: %v", loginErr))
+				}
+			default:
+				stdLog.Printf("Thread %d: Error processing orders: %v", threadIndex, err)
+				debugLogger.Printf("Thread %d: Scan error: %v", threadIndex, err)
 			}
+
+			delay := retryPolicyFor(StageList).Delay(failures)
+			debugLogger.Printf("Thread %d: Backing off for %v after %d consecutive failures.", threadIndex, delay, failures)
+			sleepUnlessStopped(ctx, delay)
+			continue
 		}
+		failures = 0
 
 		if !processed {
-			stdLog.Printf("Thread %d: No orders processed, continuing.", threadIndex)
 			debugLogger.Printf("Thread %d: No orders found during this iteration.", threadIndex)
-			continue
 		}
-
-		// No sleep to ensure immediate responsiveness
 	}
 	debugLogger.Printf("Worker %d exiting loop.", threadIndex)
+	return ctx.Err()
+}
+
+// chromeOptions configures a Chrome process. Each one gets a temporary
+// profile; the login comes from the shared session instead.
+func chromeOptions(chromePath string) []chromedp.ExecAllocatorOption {
+	userAgent := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/117.0.0.0 Safari/537.36"
+	width, height := chromeWindowSize()
+
+	// Configure Chrome options for anti-detection
+	return append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.ExecPath(chromePath),
+		chromedp.Flag("headless", cfg.ChromeHeadless),
+		chromedp.WindowSize(width, height),
+		chromedp.UserAgent(userAgent),
+		chromedp.NoDefaultBrowserCheck,
+		chromedp.Flag("disable-blink-features", "AutomationControlled"),
+		chromedp.Flag("disable-infobars", true),
+		chromedp.Flag("excludeSwitches", "enable-automation"),
+		chromedp.Flag("disable-extensions", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-gpu", true),
+	)
 }
 
 func checkSession(ctx context.Context) bool {
 	ctxCheck, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	err := chromedp.Run(ctxCheck,
-		chromedp.Navigate("https://essayshark.com/writer/orders/"),
-		chromedp.WaitVisible(`#available_orders_list_container`, chromedp.ByID),
-	)
+	err := loadPage(ctxCheck, StageList, ORDERS_PAGE_URL)
+	if err == nil {
+		err = pageFrom(ctx).WaitVisible(ctxCheck, `#available_orders_list_container`)
+	}
 	if err != nil {
 		debugLogger.Printf("Session check failed: %v", err)
 		return false
@@ -1507,68 +747,95 @@ func checkSession(ctx context.Context) bool {
 	return true
 }
 
-func performLogin(ctx context.Context, email, password string) error {
-	ctxLogin, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	err := chromedp.Run(ctxLogin,
-		chromedp.Navigate("https://essayshark.com/log-in.html"),
+// performLogin types the credentials as key events, which Page does not
+// offer, so it drives chromedp directly. Anything other than the plain
+// login form, before or after submitting it, is handed to the user.
+func performLogin(ctx context.Context, threadIndex int, email, password string) error {
+	ctxForm, cancel := context.WithTimeout(ctx, LOGIN_FORM_TIMEOUT)
+	err := chromedp.Run(ctxForm,
+		chromedp.Navigate(LOGIN_PAGE_URL),
 		chromedp.WaitVisible(`input[name="login"]`, chromedp.ByQuery),
 		chromedp.WaitVisible(`input[name="password"]`, chromedp.ByQuery),
+	)
+	cancel()
+	if err != nil {
+		state, probeErr := probeLogin(ctx)
+		if probeErr != nil || state.LoginForm {
+			return fmt.Errorf("error during login: %w", err)
+		}
+		if !state.Orders {
+			reason := fmt.Sprintf("The site shows an unexpected page (%s) instead of the login form.", state.URL)
+			if err := assistLogin(ctx, threadIndex, reason, "", loginFormShown); err != nil {
+				return fmt.Errorf("error during login: %w", err)
+			}
+		}
+		if state, err = probeLogin(ctx); err == nil && state.Orders {
+			return nil
+		}
+	}
+
+	ctxLogin, cancel := context.WithTimeout(ctx, LOGIN_SUBMIT_TIMEOUT)
+	defer cancel()
+	err = chromedp.Run(ctxLogin,
+		chromedp.WaitVisible(`input[name="login"]`, chromedp.ByQuery),
 		chromedp.Clear(`input[name="login"]`, chromedp.ByQuery),
 		chromedp.SendKeys(`input[name="login"]`, email),
 		chromedp.Clear(`input[name="password"]`, chromedp.ByQuery),
 		chromedp.SendKeys(`input[name="password"]`, password),
 		chromedp.Click(`button.bb-button[type="submit"]`, chromedp.NodeVisible),
-		chromedp.WaitVisible(`#available_orders_list_container`, chromedp.ByID),
 	)
 	if err != nil {
 		return fmt.Errorf("error during login: %w", err)
 	}
-
+	if err := awaitLoginResult(ctx, threadIndex); err != nil {
+		return fmt.Errorf("error during login: %w", err)
+	}
 	return nil
 }
 
 func findAndHandleSingleOrder(ctx context.Context, threadIndex int) (bool, error) {
-	ctxOrders, cancel := context.WithTimeout(ctx, 20*time.Second)
-	defer cancel()
-
-	err := chromedp.Run(ctxOrders,
-		chromedp.Navigate("https://essayshark.com/writer/orders/"),
-		chromedp.WaitVisible(`tr.order_container`, chromedp.ByQuery),
-	)
+	var hasOrders bool
+	err := withRetry(ctx, StageList, threadIndex, func() error {
+		var err error
+		hasOrders, err = loadOrdersPage(ctx)
+		return err
+	})
 	if err != nil {
 		debugLogger.Printf("Thread %d: Error navigating to orders page: %v", threadIndex, err)
+		snapshotFailure(ctx, threadIndex, "", err)
 		return false, err
 	}
-
-	var result struct {
-		Links     []string `json:"links"`
-		Services  []string `json:"services"`
-		Deadlines []string `json:"deadlines"`
+	if !hasOrders {
+		scheduler.ReportScan(nil)
+		return false, nil
 	}
 
-	err = chromedp.Run(ctxOrders,
-		chromedp.Evaluate(`
-			(function(){
-				let rows = document.querySelectorAll("tr.order_container");
-				let data = {links: [], services: [], deadlines: []};
-				for (let row of rows) {
-					let topicLink = row.querySelector("td.topictitle a");
-					let serviceEl = row.querySelector("div.service_type");
-					let deadlineEl = row.querySelector("td.td_deadline span.d-deadline + span.d-left");
-					data.links.push(topicLink ? topicLink.href : "");
-					data.services.push(serviceEl ? serviceEl.textContent.trim() : "");
-					data.deadlines.push(deadlineEl ? deadlineEl.textContent.trim() : "");
-				}
-				return data;
-			})()
-		`, &result),
-	)
+	result, err := parseOrderList(ctx)
 	if err != nil {
 		debugLogger.Printf("Thread %d: Error evaluating orders: %v", threadIndex, err)
+		err = newPipelineError(StageList, `tr.order_container`, err)
+		snapshotFailure(ctx, threadIndex, "", err)
 		return false, err
 	}
+	recorder.Capture(ctx, CaptureList, threadIndex)
+	market.RecordScan(result, time.Now())
+
+	fresh := make(map[string]bool)
+	for _, link := range scheduler.ReportScan(result.Links) {
+		fresh[link] = true
+	}
+	for i, link := range result.Links {
+		if fresh[link] {
+			emitWebhook(HookOrderDiscovered, map[string]string{
+				"order_url": link, "service_type": result.Services[i], "deadline": result.Deadlines[i],
+			})
+		}
+	}
+
+	if reason := updateBidCapStatus(); reason != "" {
+		debugLogger.Printf("Thread %d: Not opening orders, %s.", threadIndex, reason)
+		return false, nil
+	}
 
 	orderLinks := result.Links
 	serviceTypes := result.Services
@@ -1581,6 +848,7 @@ func findAndHandleSingleOrder(ctx context.Context, threadIndex int) (bool, error
 		if atomic.LoadInt32(&stopFlag) != 0 {
 			return false, nil
 		}
+		beat(ctx)
 
 		orderLock.Lock()
 		_, alreadyProcessing := orderToThreadMap[orderUrl]
@@ -1591,18 +859,17 @@ func findAndHandleSingleOrder(ctx context.Context, threadIndex int) (bool, error
 		orderToThreadMap[orderUrl] = threadIndex
 		orderLock.Unlock()
 
-		if shouldDiscardServiceType(serviceTypes[i]) {
-			discardOrder(orderUrl)
+		if reason := orderDiscardReason(serviceTypes[i], deadlineTexts[i], time.Now()); reason != "" {
+			discardOrder(orderUrl, reason)
+			if fresh[orderUrl] {
+				emitWebhook(HookOrderFiltered, map[string]string{"order_url": orderUrl, "reason": reason})
+			}
 			orderLock.Lock()
 			delete(orderToThreadMap, orderUrl)
 			orderLock.Unlock()
 			continue
 		}
-
-		dh := convertDeadlineToHours(deadlineTexts[i])
-		if dh != -1 && (dh < cfg.MinDeadlineHours || dh > cfg.MaxDeadlineHours) {
-			stdLog.Printf("Thread %d: Order %s deadline (%dh) out of range.", threadIndex, orderUrl, dh)
-			discardOrder(orderUrl)
+		if skippedByScript(orderUrl) {
 			orderLock.Lock()
 			delete(orderToThreadMap, orderUrl)
 			orderLock.Unlock()
@@ -1613,25 +880,59 @@ func findAndHandleSingleOrder(ctx context.Context, threadIndex int) (bool, error
 		ctxOrderDetail, cancelOrderDetail := context.WithTimeout(ctx, 20*time.Second)
 		defer cancelOrderDetail()
 
-		err = chromedp.Run(ctxOrderDetail,
-			chromedp.Navigate(orderUrl),
-			chromedp.WaitReady(`body`, chromedp.ByQuery),
-		)
+		err = withRetry(ctx, StageOpen, threadIndex, func() error {
+			return openOrder(ctxOrderDetail, orderUrl)
+		})
 		if err != nil {
 			stdLog.Printf("Thread %d: Failed to open order %s: %v", threadIndex, orderUrl, err)
+			emitErrorWebhook(threadIndex, orderUrl, err)
+			snapshotFailure(ctxOrderDetail, threadIndex, orderUrl, err)
 			orderLock.Lock()
 			delete(orderToThreadMap, orderUrl)
 			orderLock.Unlock()
+			if isFatalForScan(err) {
+				return false, err
+			}
 			continue
 		}
+		recorder.Capture(ctxOrderDetail, CaptureOrder, threadIndex)
 
 		// Handle the order (place bid or apply)
-		err = handleOrder(ctxOrderDetail, orderUrl, threadIndex)
+		listing := orderListing{
+			URL:          orderUrl,
+			ServiceType:  serviceTypes[i],
+			Deadline:     deadlineTexts[i],
+			DiscoveredAt: scheduler.DiscoveredAt(orderUrl),
+		}
+		err = handleOrder(ctxOrderDetail, listing, threadIndex)
+		if errors.Is(err, ErrScriptFailed) || errors.Is(err, errCountdownTooLong) {
+			// Neither is a failure of the page or the site; the order
+			// comes up again next scan.
+			discardOrder(orderUrl, err.Error())
+			orderLock.Lock()
+			delete(orderToThreadMap, orderUrl)
+			orderLock.Unlock()
+			continue
+		}
+		if reason, skipped := scriptSkipReason(err); skipped {
+			discardOrder(orderUrl, reason)
+			rememberScriptSkip(orderUrl)
+			emitWebhook(HookOrderFiltered, map[string]string{"order_url": orderUrl, "reason": reason})
+			orderLock.Lock()
+			delete(orderToThreadMap, orderUrl)
+			orderLock.Unlock()
+			continue
+		}
 		if err != nil {
 			stdLog.Printf("Thread %d: Error handling order %s: %v", threadIndex, orderUrl, err)
+			emitErrorWebhook(threadIndex, orderUrl, err)
+			snapshotFailure(ctxOrderDetail, threadIndex, orderUrl, err)
 			orderLock.Lock()
 			delete(orderToThreadMap, orderUrl)
 			orderLock.Unlock()
+			if isFatalForScan(err) {
+				return false, err
+			}
 			continue
 		}
 
@@ -1646,16 +947,145 @@ func findAndHandleSingleOrder(ctx context.Context, threadIndex int) (bool, error
 	return false, nil // No orders processed
 }
 
-func handleOrder(ctx context.Context, orderUrl string, threadIndex int) error {
+// orderListing is what the orders list showed about one order.
+type orderListing struct {
+	URL          string
+	ServiceType  string
+	Deadline     string
+	DiscoveredAt time.Time
+}
+
+// listedOrders holds the rows of the orders list, index-aligned.
+type listedOrders struct {
+	Links     []string `json:"links"`
+	Services  []string `json:"services"`
+	Deadlines []string `json:"deadlines"`
+}
+
+// parseOrderList reads the order rows from the currently loaded list page.
+func parseOrderList(ctx context.Context) (listedOrders, error) {
+	ctxOrders, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	var result listedOrders
+	err := pageFrom(ctx).Evaluate(ctxOrders, `
+			(function(){
+				let rows = document.querySelectorAll("tr.order_container");
+				let data = {links: [], services: [], deadlines: []};
+				for (let row of rows) {
+					let topicLink = row.querySelector("td.topictitle a");
+					let serviceEl = row.querySelector("div.service_type");
+					let deadlineEl = row.querySelector("td.td_deadline span.d-deadline + span.d-left");
+					data.links.push(topicLink ? topicLink.href : "");
+					data.services.push(serviceEl ? serviceEl.textContent.trim() : "");
+					data.deadlines.push(deadlineEl ? deadlineEl.textContent.trim() : "");
+				}
+				return data;
+			})()
+		`, &result)
+	return result, err
+}
+
+// orderDiscardReason applies the list filters to one row seen at now and
+// returns why it should be skipped, or "" to open it.
+func orderDiscardReason(serviceType, deadlineText string, now time.Time) string {
+	if shouldDiscardServiceType(serviceType) {
+		return fmt.Sprintf("service type %q", serviceType)
+	}
+	deadline, err := parseDeadline(deadlineText, now)
+	switch {
+	case err != nil:
+		if cfg.UnparsedDeadlinePolicy != DEADLINE_POLICY_ACCEPT {
+			return fmt.Sprintf("unreadable deadline %q", deadlineText)
+		}
+		debugLogger.Printf("Accepting order with unreadable deadline: %v", err)
+	case deadline.Overdue():
+		return "deadline already passed"
+	case deadline.Remaining < time.Duration(cfg.MinDeadlineHours)*time.Hour,
+		deadline.Remaining > time.Duration(cfg.MaxDeadlineHours)*time.Hour:
+		return fmt.Sprintf("deadline (%.1fh) out of range", deadline.Remaining.Hours())
+	}
+	return ""
+}
+
+// loadOrdersPage opens the orders list and waits for rows. It returns false
+// with no error when the list is simply empty.
+func loadOrdersPage(ctx context.Context) (bool, error) {
+	if !scheduler.AcquirePageLoad(ctx) {
+		return false, nil
+	}
+
+	ctxOrders, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	err := loadPage(ctxOrders, StageList, ORDERS_PAGE_URL)
+	if errors.Is(err, ErrLoggedOut) {
+		return false, err
+	}
+	if err == nil {
+		err = pageFrom(ctx).WaitVisible(ctxOrders, `tr.order_container`)
+	}
+	if err == nil {
+		return true, nil
+	}
+	if state, probeErr := probePage(ctx); probeErr == nil && state.OrderList && state.Rows == 0 {
+		return false, nil
+	}
+	return false, diagnoseFailure(ctx, StageList, `tr.order_container`, err)
+}
+
+// openOrder navigates to an order page and checks it can still be taken.
+func openOrder(ctx context.Context, orderUrl string) error {
+	if !scheduler.AcquirePageLoad(ctx) {
+		return &PipelineError{Stage: StageOpen, Class: ErrTimeout, Err: errors.New("stopped while waiting for page load budget")}
+	}
+
+	page := pageFrom(ctx)
+	err := page.Navigate(ctx, orderUrl)
+	if err == nil {
+		err = page.WaitReady(ctx, `body`)
+	}
+	if err != nil {
+		return diagnoseFailure(ctx, StageOpen, `body`, err)
+	}
+	state, err := probePage(ctx)
+	if err != nil {
+		return newPipelineError(StageOpen, "", err)
+	}
+	if state.loggedOut() {
+		return &PipelineError{Stage: StageOpen, Class: ErrLoggedOut, Err: fmt.Errorf("redirected to %s", state.URL)}
+	}
+	if state.orderGone() {
+		return &PipelineError{Stage: StageOpen, Class: ErrOrderGone, Err: fmt.Errorf("order %s is no longer available", orderUrl)}
+	}
+	return nil
+}
+
+// errCountdownTooLong means the order page asks to wait longer than the
+// time left to handle the order. The order is left for a later scan.
+var errCountdownTooLong = errors.New("countdown outlasts the time left for the order")
+
+func handleOrder(ctx context.Context, listing orderListing, threadIndex int) error {
+	orderUrl := listing.URL
 	isFixed, err := isFixedPriceOrder(ctx)
 	if err != nil {
-		return fmt.Errorf("error checking if order is fixed-price: %w", err)
+		return fmt.Errorf("error checking if order is fixed-price: %w", newPipelineError(StageOpen, "body", err))
 	}
 
 	if hasCountdown, seconds := checkCountdown(ctx); hasCountdown {
+		wait := time.Duration(seconds) * time.Second
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait+ORDER_COUNTDOWN_HEADROOM {
+			return fmt.Errorf("%w (%d seconds)", errCountdownTooLong, seconds)
+		}
 		stdLog.Printf("Thread %d: Order %s has countdown: %d seconds. Waiting...", threadIndex, orderUrl, seconds)
 		debugLogger.Printf("Thread %d: Waiting for %d seconds due to countdown.", threadIndex, seconds)
-		time.Sleep(time.Duration(seconds) * time.Second)
+		if !sleepUnlessStopped(ctx, wait) {
+			class := ErrTimeout
+			if stopRequested(ctx) {
+				class = ErrStopped
+			}
+			return &PipelineError{Stage: StageOpen, Class: class, Err: errors.New("stopped while waiting for countdown")}
+		}
 	}
 
 	if hasAttachments(ctx) {
@@ -1666,48 +1096,111 @@ func handleOrder(ctx context.Context, orderUrl string, threadIndex int) error {
 		}
 	}
 
+	record := BidRecord{
+		OrderURL:     orderUrl,
+		Thread:       threadIndex,
+		FixedPrice:   isFixed,
+		Simulated:    isDryRun(),
+		ServiceType:  listing.ServiceType,
+		Pages:        orderPageCount(ctx),
+		Strategy:     bidStrategy(isFixed),
+		DiscoveredAt: listing.DiscoveredAt,
+	}
+	if deadline, err := parseDeadline(listing.Deadline, time.Now()); err == nil {
+		record.DeadlineHours = deadline.Remaining.Hours()
+	}
+
+	// The decision script sees fixed-price orders here and bid orders once
+	// the bid form has shown the minimum bid.
+	messageText := cfg.MessageText
+	price := defaultBidPrice
+	if cfg.Script.Enabled {
+		facts := OrderFacts{
+			URL: orderUrl, ServiceType: listing.ServiceType, Deadline: listing.Deadline, DeadlineHours: record.DeadlineHours,
+			Pages: record.Pages, FixedPrice: isFixed, DiscoveredAt: listing.DiscoveredAt,
+		}
+		useDecision := func(d ScriptDecision) {
+			if d.Message != "" {
+				messageText = d.Message
+			}
+			if d.Amount > 0 {
+				record.Strategy = "script"
+			}
+		}
+		if isFixed {
+			d, err := decideWithScript(facts, threadIndex)
+			if err != nil {
+				return err
+			}
+			useDecision(d)
+		} else {
+			price = scriptBidPrice(facts, threadIndex, useDecision)
+		}
+	}
+
 	if isFixed {
-		stdLog.Printf("Thread %d: Order %s is fixed-price. Applying directly.", threadIndex, orderUrl)
-		debugLogger.Printf("Thread %d: Applying for fixed-price order.", threadIndex)
-		err = applyForOrder(ctx)
-		if err != nil {
-			return fmt.Errorf("error applying for fixed-price order: %w", err)
+		if isDryRun() {
+			stdLog.Printf("Thread %d: [DRY RUN] Order %s is fixed-price. Would apply directly.", threadIndex, orderUrl)
+		} else {
+			stdLog.Printf("Thread %d: Order %s is fixed-price. Applying directly.", threadIndex, orderUrl)
+			debugLogger.Printf("Thread %d: Applying for fixed-price order.", threadIndex)
+			err = withRetry(ctx, StageBid, threadIndex, func() error {
+				return applyForOrder(ctx)
+			})
+			if err != nil {
+				return fmt.Errorf("error applying for fixed-price order: %w", err)
+			}
 		}
 	} else {
 		stdLog.Printf("Thread %d: Order %s is not fixed-price. Placing bid.", threadIndex, orderUrl)
 		debugLogger.Printf("Thread %d: Placing bid on order.", threadIndex)
-		err = placeBid(ctx, threadIndex)
+		err = withRetry(ctx, StageBid, threadIndex, func() error {
+			var err error
+			record.Amount, err = placeBid(ctx, orderUrl, threadIndex, price)
+			return err
+		})
 		if err != nil {
 			return fmt.Errorf("error placing bid: %w", err)
 		}
 	}
 
 	if cfg.MessageEnabled {
-		err = sendMessageToClient(ctx, cfg.MessageText)
-		if err != nil {
-			stdLog.Printf("Thread %d: Error sending message for order %s: %v", threadIndex, orderUrl, err)
-			debugLogger.Printf("Thread %d: Message sending error: %v", threadIndex, err)
+		if isDryRun() {
+			stdLog.Printf("Thread %d: [DRY RUN] Would send message for order %s.", threadIndex, orderUrl)
+			record.Message = messageText
+		} else {
+			err = withRetry(ctx, StageMessage, threadIndex, func() error {
+				return sendMessageToClient(ctx, messageText)
+			})
+			if err != nil {
+				stdLog.Printf("Thread %d: Error sending message for order %s: %v", threadIndex, orderUrl, err)
+				debugLogger.Printf("Thread %d: Message sending error: %v", threadIndex, err)
+				emitErrorWebhook(threadIndex, orderUrl, err)
+				snapshotFailure(ctx, threadIndex, orderUrl, err)
+			} else {
+				record.Message = messageText
+				emitWebhook(HookMessageSent, map[string]string{"order_url": orderUrl, "message": messageText})
+			}
 		}
 	}
 
-	// Navigate back to orders page without delay
-	ctxBack, cancelBack := context.WithTimeout(ctx, 10*time.Second)
-	defer cancelBack()
-	err = chromedp.Run(ctxBack, chromedp.Navigate("https://essayshark.com/writer/orders/"))
-	if err != nil {
-		stdLog.Printf("Thread %d: Error navigating back to orders page: %v", threadIndex, err)
-		debugLogger.Printf("Thread %d: Navigation back error: %v", threadIndex, err)
+	record.PlacedAt = time.Now()
+	bidLedger.Record(record)
+	notifyBidPlaced(record)
+	if !record.Simulated {
+		emitWebhook(HookBidPlaced, record)
 	}
+	updateBidCapStatus()
 
+	// The next scheduled scan navigates back to the orders page
 	return nil
 }
 
 func isFixedPriceOrder(ctx context.Context) (bool, error) {
-	var bodyText string
 	ctxCheck, cancelCheck := context.WithTimeout(ctx, 10*time.Second)
 	defer cancelCheck()
 
-	err := chromedp.Run(ctxCheck, chromedp.Text("body", &bodyText))
+	bodyText, err := pageFrom(ctx).Text(ctxCheck, "body")
 	if err != nil {
 		return false, fmt.Errorf("error retrieving page body: %w", err)
 	}
@@ -1719,13 +1212,10 @@ func isFixedPriceOrder(ctx context.Context) (bool, error) {
 }
 
 func checkCountdown(ctx context.Context) (bool, int) {
-	var countdownText string
 	ctxCount, cancelCount := context.WithTimeout(ctx, 5*time.Second)
 	defer cancelCount()
 
-	err := chromedp.Run(ctxCount,
-		chromedp.Text(`#id_read_timeout_sec`, &countdownText, chromedp.NodeVisible, chromedp.ByID),
-	)
+	countdownText, err := pageFrom(ctx).Text(ctxCount, `#id_read_timeout_sec`)
 	if err != nil || countdownText == "" {
 		return false, 0
 	}
@@ -1734,12 +1224,30 @@ func checkCountdown(ctx context.Context) (bool, int) {
 	return true, sec
 }
 
+// orderPageCount reads the page count from the order page, or 0 if it
+// is not shown.
+func orderPageCount(ctx context.Context) int {
+	ctxPages, cancelPages := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelPages()
+
+	bodyText, err := pageFrom(ctx).Text(ctxPages, "body")
+	if err != nil {
+		debugLogger.Printf("Error reading page count: %v", err)
+		return 0
+	}
+	m := pageCountPattern.FindStringSubmatch(bodyText)
+	if m == nil {
+		return 0
+	}
+	pages, _ := strconv.Atoi(m[1])
+	return pages
+}
+
 func hasAttachments(ctx context.Context) bool {
-	var bodyText string
 	ctxAttach, cancelAttach := context.WithTimeout(ctx, 5*time.Second)
 	defer cancelAttach()
 
-	err := chromedp.Run(ctxAttach, chromedp.Text("body", &bodyText))
+	bodyText, err := pageFrom(ctx).Text(ctxAttach, "body")
 	if err != nil {
 		debugLogger.Printf("Error checking attachments: %v", err)
 		return false
@@ -1756,80 +1264,157 @@ func downloadFileIfAvailable(ctx context.Context) error {
 }
 
 func applyForOrder(ctx context.Context) error {
+	if err := guardLiveAction(); err != nil {
+		return err
+	}
 	ctxApply, cancelApply := context.WithTimeout(ctx, 5*time.Second)
 	defer cancelApply()
 
-	err := chromedp.Run(ctxApply,
-		chromedp.Click("#apply_order", chromedp.NodeVisible, chromedp.ByID),
-	)
-	if err != nil {
-		return fmt.Errorf("error clicking apply button: %w", err)
+	// Only waiting for the button may be retried; once clicked, the
+	// application may have been sent.
+	page := pageFrom(ctx)
+	if err := page.WaitVisible(ctxApply, "#apply_order"); err != nil {
+		return fmt.Errorf("error finding apply button: %w", diagnoseFailure(ctx, StageBid, "#apply_order", err))
+	}
+	if err := page.Click(ctxApply, "#apply_order"); err != nil {
+		return fmt.Errorf("error clicking apply button: %w", maybeSubmitted(newPipelineError(StageBid, "#apply_order", err)))
 	}
 	return nil
 }
 
-func placeBid(ctx context.Context, threadIndex int) error {
+// readBidLimits reads the limits a bid must keep to from the bid form's
+// answer to an invalid bid.
+func readBidLimits(errText string) (BidMessage, error) {
+	limits, err := parseBidMessage(errText)
+	if err != nil || limits.Minimum <= 0 {
+		return limits, &PipelineError{Stage: StageBid, Class: ErrBidRejected, Selector: "#id_bid4-error", Err: fmt.Errorf("unexpected bid response %q", errText)}
+	}
+	if limits.Currency != SITE_CURRENCY {
+		return limits, &PipelineError{Stage: StageBid, Class: ErrBidRejected, Selector: "#id_bid4-error",
+			Err: fmt.Errorf("bid currency is %s, expected %s", limits.Currency, SITE_CURRENCY)}
+	}
+	return limits, nil
+}
+
+// defaultBidPrice bids as configured in Settings.
+func defaultBidPrice(limits BidMessage) (Money, error) {
+	return bidAmount(limits), nil
+}
+
+// placeBid reads the bid limits from the bid form and bids the amount price
+// picks for them.
+func placeBid(ctx context.Context, orderUrl string, threadIndex int, price func(BidMessage) (Money, error)) (Money, error) {
 	ctxBid, cancelBid := context.WithTimeout(ctx, 10*time.Second)
 	defer cancelBid()
 
-	err := chromedp.Run(ctxBid,
-		chromedp.SetValue("#id_bid4", "-1.00", chromedp.ByID),
-		chromedp.Click("#apply_order", chromedp.NodeVisible, chromedp.ByID),
-	)
+	// An invalid bid makes the form show the minimum bid. A dry run only
+	// triggers the form's validation instead of submitting it.
+	page := pageFrom(ctx)
+	err := page.SetValue(ctxBid, "#id_bid4", "-1.00")
+	if err == nil {
+		if isDryRun() {
+			err = triggerBidValidation(ctxBid, page)
+		} else {
+			err = page.Click(ctxBid, "#apply_order")
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("error setting bid value or clicking apply: %w", err)
+		return 0, fmt.Errorf("error setting bid value or clicking apply: %w", diagnoseFailure(ctx, StageBid, "#id_bid4", err))
 	}
 
-	var errText string
-	err = chromedp.Run(ctxBid,
-		chromedp.Text("#id_bid4-error", &errText, chromedp.NodeVisible, chromedp.ByID),
-	)
+	errText, err := page.Text(ctxBid, "#id_bid4-error")
 	if err != nil {
-		return fmt.Errorf("error retrieving bid error message: %w", err)
+		return 0, fmt.Errorf("error retrieving bid error message: %w", diagnoseFailure(ctx, StageBid, "#id_bid4-error", err))
 	}
+	recorder.Capture(ctx, CaptureBid, threadIndex)
 
-	minBid := extractMinimumBid(errText)
-	if minBid <= 0 {
+	limits, err := readBidLimits(errText)
+	if err != nil {
 		stdLog.Printf("Thread %d: Invalid minimum bid extracted, skipping.", threadIndex)
-		debugLogger.Printf("Thread %d: Extracted minimum bid is invalid: %f", threadIndex, minBid)
-		return nil
+		debugLogger.Printf("Thread %d: Unusable bid message %q: %v", threadIndex, errText, err)
+		return 0, err
 	}
-
-	err = chromedp.Run(ctxBid,
-		chromedp.SetValue("#id_bid4", fmt.Sprintf("%.2f", minBid), chromedp.ByID),
-		chromedp.Click("#apply_order", chromedp.NodeVisible, chromedp.ByID),
-	)
+	market.RecordMinimumBid(orderUrl, limits.Minimum, time.Now())
+	bid, err := price(limits)
 	if err != nil {
-		return fmt.Errorf("error setting minimum bid or clicking apply: %w", err)
+		return 0, err
 	}
 
-	return nil
+	if reason := bidCapReason(time.Now(), bid); reason != "" {
+		stdLog.Printf("Thread %d: Not bidding %s: %s.", threadIndex, bid, reason)
+		return 0, &PipelineError{Stage: StageBid, Class: ErrCapReached, Err: errors.New(reason)}
+	}
+
+	if isDryRun() {
+		stdLog.Printf("Thread %d: [DRY RUN] Would bid %s.", threadIndex, bid)
+		return bid, nil
+	}
+	if err := guardLiveAction(); err != nil {
+		return 0, err
+	}
+
+	// Everything up to here may be retried. The click below submits the
+	// bid, so a failure of it must not run the stage again.
+	if err := page.SetValue(ctxBid, "#id_bid4", bid.Decimal()); err != nil {
+		return 0, fmt.Errorf("error setting bid: %w", newPipelineError(StageBid, "#id_bid4", err))
+	}
+	if err := page.Click(ctxBid, "#apply_order"); err != nil {
+		return 0, fmt.Errorf("error clicking apply: %w", maybeSubmitted(newPipelineError(StageBid, "#apply_order", err)))
+	}
+
+	return bid, nil
+}
+
+// bidStrategy names how the bid amount is chosen, for the analytics
+// report.
+func bidStrategy(fixedPrice bool) string {
+	switch {
+	case fixedPrice:
+		return "fixed price"
+	case cfg.BidMarkupPercent > 0:
+		return fmt.Sprintf("minimum +%g%%", cfg.BidMarkupPercent)
+	}
+	return "minimum"
 }
 
-func extractMinimumBid(errorMessage string) float64 {
-	var amount float64
-	fmt.Sscanf(errorMessage, "Minimum bid is $%f", &amount)
-	return amount
+// bidAmount is the minimum bid plus the configured markup, rounded up to
+// the cent so it never falls below the intended amount, and held to the
+// maximum if the form has one. A maximum below the minimum is ignored
+// rather than bidding under the minimum.
+func bidAmount(limits BidMessage) Money {
+	basisPoints := int64(math.Round(cfg.BidMarkupPercent * 100))
+	if basisPoints < 0 {
+		basisPoints = 0
+	}
+	bid := limits.Minimum.AddPercent(basisPoints, RoundUp)
+	if limits.Maximum >= limits.Minimum && bid > limits.Maximum {
+		bid = limits.Maximum
+	}
+	return bid
 }
 
 func sendMessageToClient(ctx context.Context, msg string) error {
+	if err := guardLiveAction(); err != nil {
+		return err
+	}
 	ctxMsg, cancelMsg := context.WithTimeout(ctx, 5*time.Second)
 	defer cancelMsg()
 
-	err := chromedp.Run(ctxMsg,
-		chromedp.SetValue("#id_body", msg, chromedp.ByID),
-		chromedp.Click("#id_send_message", chromedp.NodeVisible, chromedp.ByID),
-	)
+	page := pageFrom(ctx)
+	err := page.SetValue(ctxMsg, "#id_body", msg)
+	if err == nil {
+		err = page.Click(ctxMsg, "#id_send_message")
+	}
 	if err != nil {
-		return fmt.Errorf("error sending message: %w", err)
+		return fmt.Errorf("error sending message: %w", diagnoseFailure(ctx, StageMessage, "#id_send_message", err))
 	}
 
 	return nil
 }
 
-func discardOrder(orderUrl string) {
-	stdLog.Printf("Discarding order %s.", orderUrl)
-	debugLogger.Printf("Order %s discarded based on service type.", orderUrl)
+func discardOrder(orderUrl, reason string) {
+	stdLog.Printf("Discarding order %s: %s.", orderUrl, reason)
+	debugLogger.Printf("Order %s discarded: %s.", orderUrl, reason)
 }
 
 func shouldDiscardServiceType(serviceType string) bool {
@@ -1843,18 +1428,6 @@ func shouldDiscardServiceType(serviceType string) bool {
 	return false
 }
 
-func convertDeadlineToHours(deadlineText string) int {
-	if deadlineText == "" {
-		return -1
-	}
-	var days, hours int
-	_, err := fmt.Sscanf(deadlineText, "%dd %dh", &days, &hours)
-	if err != nil {
-		return -1
-	}
-	return days*24 + hours
-}
-
 func loadConfig() {
 	configPath := getConfigPath()
 	data, err := ioutil.ReadFile(configPath)
@@ -1864,6 +1437,9 @@ func loadConfig() {
 		setDefaultConfig()
 		return
 	}
+	// Start from the defaults so settings added since the file was saved
+	// get sensible values.
+	setDefaultConfig()
 	err = json.Unmarshal(data, cfg)
 	if err != nil {
 		stdLog.Println("Error parsing config file. Using default settings.")
@@ -1879,7 +1455,23 @@ func setDefaultConfig() {
 	cfg.DiscardEditing = false
 	cfg.MinDeadlineHours = DEFAULT_MIN_DEADLINE_HS
 	cfg.MaxDeadlineHours = DEFAULT_MAX_DEADLINE_HS
+	cfg.UnparsedDeadlinePolicy = DEADLINE_POLICY_SKIP
 	cfg.ThreadCount = DEFAULT_THREAD_COUNT
+	cfg.RetryPolicies = defaultRetryPolicyConfig()
+	cfg.BreakerThreshold = DEFAULT_BREAKER_THRESHOLD
+	cfg.BreakerCooldownSec = DEFAULT_BREAKER_COOLDOWN_SEC
+	cfg.ScanIntervalMs = DEFAULT_SCAN_INTERVAL_MS
+	cfg.MinScanIntervalMs = DEFAULT_MIN_SCAN_INTERVAL_MS
+	cfg.MaxScanIntervalMs = DEFAULT_MAX_SCAN_INTERVAL_MS
+	cfg.MaxPageLoadsPerMinute = DEFAULT_MAX_PAGE_LOADS_PER_MIN
+	cfg.Notifications = defaultNotificationConfig()
+	cfg.Script = ScriptConfig{File: DEFAULT_SCRIPT_FILE, TimeoutMs: DEFAULT_SCRIPT_TIMEOUT_MS}
+	cfg.FailureSnapshotLimit = DEFAULT_FAILURE_SNAPSHOT_LIMIT
+	cfg.BrowserMode = BROWSER_MODE_PER_THREAD
+	cfg.ChromeHeadless = true
+	cfg.RequestBlocking = defaultRequestBlocking()
+	cfg.ChromeWindowWidth = CHROME_WINDOW_WIDTH
+	cfg.ChromeWindowHeight = CHROME_WINDOW_HEIGHT
 }
 
 func saveConfig() {
@@ -1919,44 +1511,18 @@ func getSysfilesDir() string {
 	return filepath.Join(cwd, SYSFILES_FOLDER)
 }
 
-func findChromeExecutable() (string, error) {
-	// Attempt to find Chrome executable based on OS
-	if isWindows() {
-		paths := []string{
-			`C:\Program Files\Google\Chrome\Application\chrome.exe`,
-			`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
-		}
-		for _, p := range paths {
-			if fileExists(p) {
-				return p, nil
-			}
-		}
-	} else if isMac() {
-		p := `/Applications/Google Chrome.app/Contents/MacOS/Google Chrome`
-		if fileExists(p) {
-			return p, nil
-		}
-	} else { // Assume Linux
-		paths := []string{
-			`/usr/bin/google-chrome`,
-			`/usr/bin/chromium-browser`,
-			`/usr/bin/chrome`,
-		}
-		for _, p := range paths {
-			if fileExists(p) {
-				return p, nil
-			}
+func ensureFolders() {
+	sysfiles := getSysfilesDir()
+	for _, dir := range []string{
+		sysfiles,
+		filepath.Join(sysfiles, DOWNLOADS_FOLDER),
+		filepath.Join(sysfiles, USERFILES_FOLDER),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			stdLog.Printf("Error creating folder %s: %v", dir, err)
+			debugLogger.Printf("Folder create error: %v", err)
 		}
 	}
-	return "", fmt.Errorf("Chrome executable not found")
-}
-
-func isWindows() bool {
-	return strings.Contains(strings.ToLower(os.Getenv("OS")), "windows")
-}
-
-func isMac() bool {
-	return strings.Contains(strings.ToLower(os.Getenv("OSTYPE")), "darwin")
 }
 
 func fileExists(path string) bool {
@@ -0,0 +1,692 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	MARKET_FOLDER         = "market"
+	MARKET_RETENTION_DAYS = 90
+
+	// Consecutive scans further apart than this mean the bot was not
+	// watching in between, so listing times across the gap are unknown.
+	MARKET_SCAN_GAP = 5 * time.Minute
+)
+
+// Market event kinds.
+const (
+	MarketScan       = "scan"        // one read of the orders list
+	MarketOrder      = "order"       // the details of a newly listed order
+	MarketMinimumBid = "minimum_bid" // the minimum bid the bid form showed
+)
+
+// MarketEvent is one line of sysfiles/market/YYYY-MM-DD.jsonl. A scan
+// holds the full list of order IDs when it is the first of its file or
+// follows a gap, and otherwise only what changed since the previous scan,
+// which for most scans is nothing.
+type MarketEvent struct {
+	Kind          string    `json:"kind"`
+	At            time.Time `json:"at"`
+	Full          bool      `json:"full,omitempty"`    // Orders is the whole list
+	Orders        []string  `json:"orders,omitempty"`  // order IDs listed by a full scan
+	Added         []string  `json:"added,omitempty"`   // order IDs listed since the previous scan
+	Removed       []string  `json:"removed,omitempty"` // order IDs gone since the previous scan
+	OrderID       string    `json:"order_id,omitempty"`
+	ServiceType   string    `json:"service_type,omitempty"`
+	Deadline      string    `json:"deadline,omitempty"`
+	DeadlineHours float64   `json:"deadline_hours,omitempty"`
+	MinimumBid    Money     `json:"minimum_bid,omitempty"`
+}
+
+// MarketRecorder appends every scan of the orders list to daily files, with
+// the details of each order the first time it is listed.
+type MarketRecorder struct {
+	mu        sync.Mutex
+	described map[string]time.Time // last time each order was listed
+	listed    map[string]bool      // orders listed by the last recorded scan
+	lastScan  time.Time            // zero until a full scan is on disk
+}
+
+var market = &MarketRecorder{described: make(map[string]time.Time)}
+
+func getMarketDir() string {
+	return filepath.Join(getSysfilesDir(), MARKET_FOLDER)
+}
+
+func marketFilePath(day time.Time) string {
+	return filepath.Join(getMarketDir(), day.Format("2006-01-02")+".jsonl")
+}
+
+// RecordScan records the rows of one scan of the orders list. The lock is
+// held through the write so scans from several workers reach the file in
+// the order their changes were worked out.
+func (m *MarketRecorder) RecordScan(result listedOrders, now time.Time) {
+	scan := MarketEvent{Kind: MarketScan, At: now}
+	var events []MarketEvent
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	listed := make(map[string]bool)
+	var ids []string
+	for i, link := range result.Links {
+		if link == "" {
+			continue
+		}
+		id := orderID(link)
+		if listed[id] {
+			continue
+		}
+		listed[id] = true
+		ids = append(ids, id)
+		if _, ok := m.described[id]; !ok {
+			order := MarketEvent{Kind: MarketOrder, At: now, OrderID: id, ServiceType: result.Services[i], Deadline: result.Deadlines[i]}
+			if deadline, err := parseDeadline(order.Deadline, now); err == nil {
+				order.DeadlineHours = deadline.Remaining.Hours()
+			}
+			events = append(events, order)
+		}
+		m.described[id] = now
+	}
+	for id, t := range m.described {
+		if now.Sub(t) > SEEN_ORDER_RETENTION {
+			delete(m.described, id)
+		}
+	}
+
+	// Every file starts with a full list, so a report can start reading at
+	// any file, and so does every scan after a gap or a failed write.
+	if m.lastScan.IsZero() || now.Sub(m.lastScan) > MARKET_SCAN_GAP || marketFilePath(now) != marketFilePath(m.lastScan) {
+		scan.Full, scan.Orders = true, ids
+	} else {
+		for _, id := range ids {
+			if !m.listed[id] {
+				scan.Added = append(scan.Added, id)
+			}
+		}
+		for id := range m.listed {
+			if !listed[id] {
+				scan.Removed = append(scan.Removed, id)
+			}
+		}
+		sort.Strings(scan.Removed)
+	}
+
+	if m.write(append(events, scan)) {
+		m.listed, m.lastScan = listed, now
+	} else {
+		m.lastScan = time.Time{}
+	}
+}
+
+// RecordMinimumBid records the minimum bid the bid form showed for an order.
+func (m *MarketRecorder) RecordMinimumBid(orderUrl string, minimum Money, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.write([]MarketEvent{{Kind: MarketMinimumBid, At: now, OrderID: orderID(orderUrl), MinimumBid: minimum}})
+}
+
+// write appends events to the file of the first one's day and reports
+// whether they all reached it. m.mu must be held.
+func (m *MarketRecorder) write(events []MarketEvent) bool {
+	var buf []byte
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			debugLogger.Printf("Market event marshal error: %v", err)
+			return false
+		}
+		buf = append(append(buf, data...), '\n')
+	}
+
+	if err := os.MkdirAll(getMarketDir(), 0755); err != nil {
+		debugLogger.Printf("Market folder create error: %v", err)
+		return false
+	}
+	f, err := os.OpenFile(marketFilePath(events[0].At), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		debugLogger.Printf("Market write error: %v", err)
+		return false
+	}
+	defer f.Close()
+	if _, err := f.Write(buf); err != nil {
+		debugLogger.Printf("Market write error: %v", err)
+		return false
+	}
+	return true
+}
+
+// pruneMarketData deletes the daily files older than MARKET_RETENTION_DAYS.
+func pruneMarketData(now time.Time) {
+	cutoff := now.AddDate(0, 0, -MARKET_RETENTION_DAYS).Format("2006-01-02")
+	files, _ := filepath.Glob(filepath.Join(getMarketDir(), "*.jsonl"))
+	for _, file := range files {
+		if strings.TrimSuffix(filepath.Base(file), ".jsonl") < cutoff {
+			if err := os.Remove(file); err != nil {
+				debugLogger.Printf("Market prune error: %v", err)
+			}
+		}
+	}
+}
+
+// readMarketEvents calls fn for every event of the daily files that can
+// hold events of [from, to), in file order. Events outside the period are
+// passed on too: the scans before from are what later changes apply to.
+func readMarketEvents(from, to time.Time, fn func(MarketEvent)) error {
+	for day := from.AddDate(0, 0, -1); !day.After(to); day = day.AddDate(0, 0, 1) {
+		f, err := os.Open(marketFilePath(day))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			var e MarketEvent
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				debugLogger.Printf("Market skipping bad line: %v", err)
+				continue
+			}
+			fn(e)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VolumeBucket is the order volume seen in one hour of the day or one
+// weekday, in the schedule's timezone.
+type VolumeBucket struct {
+	Label     string  `json:"label"`
+	Days      int     `json:"days"` // days with scans in this bucket
+	Scans     int     `json:"scans"`
+	NewOrders int     `json:"new_orders"`
+	NewPerDay float64 `json:"new_per_day"`
+	AvgListed float64 `json:"avg_listed"` // orders listed per scan
+
+	days      map[string]bool
+	listedSum int
+}
+
+// ShareBucket is the part of the listed orders in one group.
+type ShareBucket struct {
+	Label   string  `json:"label"`
+	Orders  int     `json:"orders"`
+	Share   float64 `json:"share"`
+	Passing int     `json:"passing"` // orders the current filters would open
+}
+
+// ListingStats describe how long orders stay listed. Only orders whose
+// appearance and disappearance were both watched count.
+type ListingStats struct {
+	Samples    int            `json:"samples"`
+	P50Minutes float64        `json:"p50_minutes"`
+	P90Minutes float64        `json:"p90_minutes"`
+	MaxMinutes float64        `json:"max_minutes"`
+	Histogram  []ListingCount `json:"histogram"`
+}
+
+type ListingCount struct {
+	Label  string `json:"label"`
+	Orders int    `json:"orders"`
+}
+
+// MinimumBidStats summarize the minimum bids of one day or service type.
+type MinimumBidStats struct {
+	Label   string `json:"label"`
+	Samples int    `json:"samples"`
+	Min     Money  `json:"min"`
+	Avg     Money  `json:"avg"`
+	Max     Money  `json:"max"`
+
+	sum Money
+}
+
+func (s *MinimumBidStats) add(amount Money) {
+	if s.Samples == 0 || amount < s.Min {
+		s.Min = amount
+	}
+	if amount > s.Max {
+		s.Max = amount
+	}
+	s.Samples++
+	s.sum += amount
+	s.Avg = Money(divRound(int64(s.sum), int64(s.Samples), RoundHalfUp))
+}
+
+// MarketReport is the market activity seen by the bot's scans in a period.
+type MarketReport struct {
+	GeneratedAt          time.Time         `json:"generated_at"`
+	From                 time.Time         `json:"from"`
+	To                   time.Time         `json:"to"`
+	Timezone             string            `json:"timezone"`
+	Scans                int               `json:"scans"`
+	Orders               int               `json:"orders"`
+	Passing              int               `json:"passing"`
+	ByHour               []VolumeBucket    `json:"by_hour"`
+	ByWeekday            []VolumeBucket    `json:"by_weekday"`
+	Listing              ListingStats      `json:"listing_time"`
+	ServiceTypes         []ShareBucket     `json:"service_types"`
+	Deadlines            []ShareBucket     `json:"deadlines"`
+	MinimumBidsByDay     []MinimumBidStats `json:"minimum_bids_by_day"`
+	MinimumBidsByService []MinimumBidStats `json:"minimum_bids_by_service"`
+}
+
+var listingHistogram = []struct {
+	label string
+	below time.Duration
+}{
+	{"under 1m", time.Minute},
+	{"1-5m", 5 * time.Minute},
+	{"5-15m", 15 * time.Minute},
+	{"15-60m", time.Hour},
+	{"1-6h", 6 * time.Hour},
+	{"over 6h", 1<<63 - 1},
+}
+
+// marketReportBuilder summarizes market events as they are read, so a
+// report over many days never holds more than one entry per order.
+type marketReportBuilder struct {
+	report    MarketReport
+	loc       *time.Location
+	byHour    []VolumeBucket
+	byWeekday []VolumeBucket
+
+	listed   map[string]bool      // orders listed by the last scan
+	lastScan time.Time            // zero before the first scan
+	appeared map[string]time.Time // first sighting of orders seen appearing
+	listings []time.Duration
+
+	services      map[string]string // service type of each order
+	serviceShares map[string]*ShareBucket
+	deadlines     map[string]*ShareBucket
+	bidsByDay     map[string]*MinimumBidStats
+	bidsByService map[string]*MinimumBidStats
+}
+
+// newMarketReportBuilder starts the report of [from, to). Hours and
+// weekdays are read in loc.
+func newMarketReportBuilder(from, to time.Time, loc *time.Location) *marketReportBuilder {
+	b := &marketReportBuilder{
+		report:        MarketReport{GeneratedAt: time.Now(), From: from, To: to, Timezone: loc.String()},
+		loc:           loc,
+		byHour:        make([]VolumeBucket, 24),
+		byWeekday:     make([]VolumeBucket, 7),
+		listed:        make(map[string]bool),
+		appeared:      make(map[string]time.Time),
+		services:      make(map[string]string),
+		serviceShares: make(map[string]*ShareBucket),
+		deadlines:     make(map[string]*ShareBucket),
+		bidsByDay:     make(map[string]*MinimumBidStats),
+		bidsByService: make(map[string]*MinimumBidStats),
+	}
+	for h := range b.byHour {
+		b.byHour[h].Label = fmt.Sprintf("%02d:00", h)
+	}
+	for d := range b.byWeekday {
+		b.byWeekday[d].Label = time.Weekday(d).String()
+	}
+	return b
+}
+
+func (b *marketReportBuilder) inPeriod(t time.Time) bool {
+	return !t.Before(b.report.From) && t.Before(b.report.To)
+}
+
+// add takes the next event in file order. Scans outside the period still
+// bring the list of orders up to date; everything else outside it is
+// ignored.
+func (b *marketReportBuilder) add(e MarketEvent) {
+	if e.Kind == MarketScan {
+		b.addScan(e)
+		return
+	}
+	if !b.inPeriod(e.At) {
+		return
+	}
+	local := e.At.In(b.loc)
+	switch e.Kind {
+	case MarketOrder:
+		if _, ok := b.services[e.OrderID]; ok {
+			return
+		}
+		b.services[e.OrderID] = orEmpty(e.ServiceType, "unknown")
+		b.report.Orders++
+		b.byHour[local.Hour()].NewOrders++
+		b.byWeekday[local.Weekday()].NewOrders++
+
+		passing := orderDiscardReason(e.ServiceType, e.Deadline, e.At) == ""
+		if passing {
+			b.report.Passing++
+		}
+		for _, group := range []struct {
+			buckets map[string]*ShareBucket
+			label   string
+		}{{b.serviceShares, b.services[e.OrderID]}, {b.deadlines, deadlineBucket(e.DeadlineHours)}} {
+			s, ok := group.buckets[group.label]
+			if !ok {
+				s = &ShareBucket{Label: group.label}
+				group.buckets[group.label] = s
+			}
+			s.Orders++
+			if passing {
+				s.Passing++
+			}
+		}
+	case MarketMinimumBid:
+		if e.MinimumBid <= 0 {
+			return
+		}
+		for _, group := range []struct {
+			stats map[string]*MinimumBidStats
+			label string
+		}{{b.bidsByDay, local.Format("2006-01-02")}, {b.bidsByService, orEmpty(b.services[e.OrderID], "unknown")}} {
+			s, ok := group.stats[group.label]
+			if !ok {
+				s = &MinimumBidStats{Label: group.label}
+				group.stats[group.label] = s
+			}
+			s.add(e.MinimumBid)
+		}
+	}
+}
+
+// addScan applies a scan to the list of orders. A listing time counts only
+// if the scans just before an order appeared and just after it went were
+// close enough to have seen it come and go.
+func (b *marketReportBuilder) addScan(e MarketEvent) {
+	var added, removed []string
+	if e.Full || len(e.Orders) > 0 {
+		listed := make(map[string]bool, len(e.Orders))
+		for _, id := range e.Orders {
+			listed[id] = true
+			if !b.listed[id] {
+				added = append(added, id)
+			}
+		}
+		for id := range b.listed {
+			if !listed[id] {
+				removed = append(removed, id)
+			}
+		}
+		b.listed = listed
+	} else {
+		added, removed = e.Added, e.Removed
+		for _, id := range removed {
+			delete(b.listed, id)
+		}
+		for _, id := range added {
+			b.listed[id] = true
+		}
+	}
+
+	watched := !b.lastScan.IsZero() && e.At.Sub(b.lastScan) <= MARKET_SCAN_GAP
+	if !watched {
+		// Nothing listed across a gap was seen the whole time.
+		b.appeared = make(map[string]time.Time)
+	}
+	inPeriod := b.inPeriod(e.At)
+	for _, id := range removed {
+		if first, ok := b.appeared[id]; ok && watched && inPeriod {
+			b.listings = append(b.listings, b.lastScan.Sub(first))
+		}
+		delete(b.appeared, id)
+	}
+	if watched && inPeriod {
+		for _, id := range added {
+			b.appeared[id] = e.At
+		}
+	}
+	b.lastScan = e.At
+
+	if !inPeriod {
+		return
+	}
+	b.report.Scans++
+	local := e.At.In(b.loc)
+	day := local.Format("2006-01-02")
+	for _, v := range []*VolumeBucket{&b.byHour[local.Hour()], &b.byWeekday[local.Weekday()]} {
+		if v.days == nil {
+			v.days = make(map[string]bool)
+		}
+		v.days[day] = true
+		v.Scans++
+		v.listedSum += len(b.listed)
+	}
+}
+
+// finish completes the report.
+func (b *marketReportBuilder) finish() MarketReport {
+	report := b.report
+	for _, buckets := range [][]VolumeBucket{b.byHour, b.byWeekday} {
+		for i := range buckets {
+			v := &buckets[i]
+			v.Days = len(v.days)
+			if v.Days > 0 {
+				v.NewPerDay = float64(v.NewOrders) / float64(v.Days)
+			}
+			if v.Scans > 0 {
+				v.AvgListed = float64(v.listedSum) / float64(v.Scans)
+			}
+		}
+	}
+	report.ByHour, report.ByWeekday = b.byHour, b.byWeekday
+
+	listed := b.listings
+	sort.Slice(listed, func(i, j int) bool { return listed[i] < listed[j] })
+	report.Listing.Samples = len(listed)
+	if len(listed) > 0 {
+		report.Listing.P50Minutes = percentile(listed, 50).Minutes()
+		report.Listing.P90Minutes = percentile(listed, 90).Minutes()
+		report.Listing.MaxMinutes = listed[len(listed)-1].Minutes()
+	}
+	for i, h := range listingHistogram {
+		count := 0
+		for _, d := range listed {
+			if d < h.below && (i == 0 || d >= listingHistogram[i-1].below) {
+				count++
+			}
+		}
+		report.Listing.Histogram = append(report.Listing.Histogram, ListingCount{Label: h.label, Orders: count})
+	}
+
+	report.ServiceTypes = sortedShares(b.serviceShares, report.Orders)
+	report.Deadlines = sortedShares(b.deadlines, report.Orders)
+	report.MinimumBidsByDay = sortedMinimumBids(b.bidsByDay, func(a, b MinimumBidStats) bool { return a.Label < b.Label })
+	report.MinimumBidsByService = sortedMinimumBids(b.bidsByService, func(a, b MinimumBidStats) bool { return a.Samples > b.Samples })
+	return report
+}
+
+// buildMarketReport summarizes the events of [from, to), given in file
+// order. Hours and weekdays are read in loc.
+func buildMarketReport(events []MarketEvent, from, to time.Time, loc *time.Location) MarketReport {
+	b := newMarketReportBuilder(from, to, loc)
+	for _, e := range events {
+		b.add(e)
+	}
+	return b.finish()
+}
+
+func sortedShares(buckets map[string]*ShareBucket, total int) []ShareBucket {
+	var out []ShareBucket
+	for _, b := range buckets {
+		if total > 0 {
+			b.Share = float64(b.Orders) / float64(total)
+		}
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Orders != out[j].Orders {
+			return out[i].Orders > out[j].Orders
+		}
+		return out[i].Label < out[j].Label
+	})
+	return out
+}
+
+func sortedMinimumBids(stats map[string]*MinimumBidStats, less func(a, b MinimumBidStats) bool) []MinimumBidStats {
+	var out []MinimumBidStats
+	for _, s := range stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if less(out[i], out[j]) != less(out[j], out[i]) {
+			return less(out[i], out[j])
+		}
+		return out[i].Label < out[j].Label
+	})
+	return out
+}
+
+// loadMarketReport builds the market report for the last days days.
+func loadMarketReport(days int) (MarketReport, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+	b := newMarketReportBuilder(from, to, cfg.Schedule.location())
+	if err := readMarketEvents(from, to, b.add); err != nil {
+		return MarketReport{}, fmt.Errorf("error reading market data: %w", err)
+	}
+	return b.finish(), nil
+}
+
+func loadMarketReportView(days int) (exportableReport, error) { return loadMarketReport(days) }
+
+func (r MarketReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the report in long form: one figure per row.
+func (r MarketReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	row := func(section, label, metric, value string) {
+		cw.Write([]string{section, label, metric, value})
+	}
+	itoa, ftoa := strconv.Itoa, func(f float64) string { return strconv.FormatFloat(f, 'f', 2, 64) }
+
+	row("section", "label", "metric", "value")
+	row("summary", "all", "scans", itoa(r.Scans))
+	row("summary", "all", "orders", itoa(r.Orders))
+	row("summary", "all", "passing_filters", itoa(r.Passing))
+	for _, set := range []struct {
+		section string
+		buckets []VolumeBucket
+	}{{"hour_of_day", r.ByHour}, {"weekday", r.ByWeekday}} {
+		for _, b := range set.buckets {
+			row(set.section, b.Label, "days", itoa(b.Days))
+			row(set.section, b.Label, "scans", itoa(b.Scans))
+			row(set.section, b.Label, "new_orders", itoa(b.NewOrders))
+			row(set.section, b.Label, "new_per_day", ftoa(b.NewPerDay))
+			row(set.section, b.Label, "avg_listed", ftoa(b.AvgListed))
+		}
+	}
+	row("listing_time", "all", "samples", itoa(r.Listing.Samples))
+	row("listing_time", "all", "p50_minutes", ftoa(r.Listing.P50Minutes))
+	row("listing_time", "all", "p90_minutes", ftoa(r.Listing.P90Minutes))
+	row("listing_time", "all", "max_minutes", ftoa(r.Listing.MaxMinutes))
+	for _, h := range r.Listing.Histogram {
+		row("listing_time", h.Label, "orders", itoa(h.Orders))
+	}
+	for _, set := range []struct {
+		section string
+		buckets []ShareBucket
+	}{{"service_type", r.ServiceTypes}, {"deadline", r.Deadlines}} {
+		for _, b := range set.buckets {
+			row(set.section, b.Label, "orders", itoa(b.Orders))
+			row(set.section, b.Label, "share", strconv.FormatFloat(b.Share, 'f', 4, 64))
+			row(set.section, b.Label, "passing_filters", itoa(b.Passing))
+		}
+	}
+	for _, set := range []struct {
+		section string
+		stats   []MinimumBidStats
+	}{{"minimum_bid_by_day", r.MinimumBidsByDay}, {"minimum_bid_by_service", r.MinimumBidsByService}} {
+		for _, s := range set.stats {
+			row(set.section, s.Label, "samples", itoa(s.Samples))
+			row(set.section, s.Label, "min", s.Min.Decimal())
+			row(set.section, s.Label, "avg", s.Avg.Decimal())
+			row(set.section, s.Label, "max", s.Max.Decimal())
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeReportFormat writes the report as "csv" or "json".
+func (r MarketReport) writeReportFormat(w io.Writer, format string) error {
+	switch format {
+	case "csv":
+		return r.WriteCSV(w)
+	case "json":
+		return r.WriteJSON(w)
+	}
+	return fmt.Errorf("unknown report format %q, expected csv or json", format)
+}
+
+func (r MarketReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Market %s - %s (%s)\n", r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04"), r.Timezone)
+	fmt.Fprintf(&b, "%d scans, %d orders listed, %d would pass the current filters\n", r.Scans, r.Orders, r.Passing)
+
+	for _, set := range []struct {
+		title   string
+		buckets []VolumeBucket
+	}{{"By hour of day", r.ByHour}, {"By weekday", r.ByWeekday}} {
+		fmt.Fprintf(&b, "\n%-14s %6s %8s %8s %8s\n", strings.ToUpper(set.title), "DAYS", "NEW", "NEW/DAY", "LISTED")
+		for _, v := range set.buckets {
+			fmt.Fprintf(&b, "%-14s %6d %8d %8.1f %8.1f %s\n", v.Label, v.Days, v.NewOrders, v.NewPerDay, v.AvgListed,
+				strings.Repeat("#", int(v.NewPerDay+0.5)))
+		}
+	}
+
+	fmt.Fprintf(&b, "\nTime listed (%d orders): p50 %.1fm, p90 %.1fm, max %.1fm\n",
+		r.Listing.Samples, r.Listing.P50Minutes, r.Listing.P90Minutes, r.Listing.MaxMinutes)
+	for _, h := range r.Listing.Histogram {
+		fmt.Fprintf(&b, "  %-10s %6d\n", h.Label, h.Orders)
+	}
+
+	for _, set := range []struct {
+		title   string
+		buckets []ShareBucket
+	}{{"Service type", r.ServiceTypes}, {"Deadline", r.Deadlines}} {
+		fmt.Fprintf(&b, "\n%-30s %6s %7s %8s\n", strings.ToUpper(set.title), "ORDERS", "SHARE", "PASSING")
+		for _, s := range set.buckets {
+			fmt.Fprintf(&b, "%-30s %6d %6.1f%% %8d\n", s.Label, s.Orders, s.Share*100, s.Passing)
+		}
+	}
+
+	for _, set := range []struct {
+		title string
+		stats []MinimumBidStats
+	}{{"Minimum bid by day", r.MinimumBidsByDay}, {"Minimum bid by service", r.MinimumBidsByService}} {
+		fmt.Fprintf(&b, "\n%-30s %6s %9s %9s %9s\n", strings.ToUpper(set.title), "BIDS", "MIN", "AVG", "MAX")
+		for _, s := range set.stats {
+			fmt.Fprintf(&b, "%-30s %6d %9s %9s %9s\n", s.Label, s.Samples, s.Min, s.Avg, s.Max)
+		}
+	}
+	return b.String()
+}
+
+// marketContent builds the Market screen.
+func marketContent(w fyne.Window) fyne.CanvasObject {
+	return reportContent(w, "market-report", []string{"csv", "json"}, loadMarketReportView)
+}
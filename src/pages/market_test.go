@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+var marketStart = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+// marketMinute is the time of a scan the given number of minutes after
+// marketStart.
+func marketMinute(minutes int) time.Time {
+	return marketStart.Add(time.Duration(minutes) * time.Minute)
+}
+
+func fullScan(minutes int, ids ...string) MarketEvent {
+	return MarketEvent{Kind: MarketScan, At: marketMinute(minutes), Full: true, Orders: ids}
+}
+
+func changeScan(minutes int, added, removed []string) MarketEvent {
+	return MarketEvent{Kind: MarketScan, At: marketMinute(minutes), Added: added, Removed: removed}
+}
+
+func TestBuildMarketReportListingTime(t *testing.T) {
+	events := []MarketEvent{
+		fullScan(0, "1"),                       // 1 was already listed: its start is unknown
+		changeScan(1, []string{"2"}, nil),      // 2 appears
+		changeScan(2, []string{"3"}, nil),      // 3 appears and is still listed at the end
+		changeScan(3, nil, nil),                // nothing changed
+		changeScan(4, nil, []string{"1", "2"}), // 2 was last seen at minute 3
+	}
+	report := buildMarketReport(events, marketStart, marketMinute(60), time.UTC)
+
+	if report.Scans != 5 {
+		t.Errorf("scans = %d, want 5", report.Scans)
+	}
+	if report.Listing.Samples != 1 || report.Listing.MaxMinutes != 2 {
+		t.Errorf("listing = %+v, want one order listed for 2 minutes", report.Listing)
+	}
+	if got := report.Listing.Histogram[1]; got.Label != "1-5m" || got.Orders != 1 {
+		t.Errorf("histogram bucket = %+v, want 1-5m with one order", got)
+	}
+	// Listed per scan: 1, 2, 3, 3, 1.
+	if got := report.ByHour[10].AvgListed; got != 2 {
+		t.Errorf("average listed = %v, want 2", got)
+	}
+}
+
+func TestBuildMarketReportScanGap(t *testing.T) {
+	events := []MarketEvent{
+		fullScan(0),
+		fullScan(1, "1"),       // 1 appears...
+		fullScan(20, "2"),      // ...and is gone after a gap, when 2 shows up
+		fullScan(21, "2", "3"), // 3 appears after the gap
+		fullScan(22),
+	}
+	report := buildMarketReport(events, marketStart, marketMinute(60), time.UTC)
+
+	// 1 went unwatched and 2 was first seen right after the gap; only 3 was
+	// seen coming and going.
+	if report.Listing.Samples != 1 || report.Listing.MaxMinutes != 0 {
+		t.Errorf("listing = %+v, want only order 3, listed for one scan", report.Listing)
+	}
+}
+
+func TestBuildMarketReportPeriod(t *testing.T) {
+	events := []MarketEvent{
+		fullScan(0, "1"),
+		changeScan(1, []string{"2"}, nil),
+		{Kind: MarketOrder, At: marketMinute(1), OrderID: "2", ServiceType: "Editing"},
+		changeScan(2, nil, nil),
+		changeScan(3, nil, []string{"2"}),
+		changeScan(70, nil, nil),
+	}
+	// The period starts after 2 appeared and ends before the last scan.
+	report := buildMarketReport(events, marketMinute(2), marketMinute(60), time.UTC)
+
+	if report.Scans != 2 {
+		t.Errorf("scans = %d, want 2", report.Scans)
+	}
+	if report.Orders != 0 {
+		t.Errorf("orders = %d, want the order listed before the period left out", report.Orders)
+	}
+	if report.Listing.Samples != 0 {
+		t.Errorf("listing = %+v, want no sample for an order that appeared before the period", report.Listing)
+	}
+	// The scans before the period still say what was listed: 1 and 2, then 1.
+	if got := report.ByHour[10].AvgListed; got != 1.5 {
+		t.Errorf("average listed = %v, want 1.5", got)
+	}
+}
+
+func TestMarketRecorderWritesChanges(t *testing.T) {
+	setupOrderTest(t)
+	recorder := &MarketRecorder{described: make(map[string]time.Time)}
+	list := func(ids ...int) listedOrders {
+		var l listedOrders
+		for _, id := range ids {
+			l.Links = append(l.Links, fmt.Sprintf("https://essayshark.com/writer/orders/%d.html", 100000000+id))
+			l.Services = append(l.Services, "Editing")
+			l.Deadlines = append(l.Deadlines, "")
+		}
+		return l
+	}
+
+	recorder.RecordScan(list(1, 2), marketMinute(0))
+	recorder.RecordScan(list(1, 2), marketMinute(1))
+	recorder.RecordScan(list(2, 3), marketMinute(2))
+	recorder.RecordScan(list(3), marketMinute(20))
+
+	var scans []MarketEvent
+	orders := 0
+	if err := readMarketEvents(marketStart, marketMinute(60), func(e MarketEvent) {
+		switch e.Kind {
+		case MarketScan:
+			scans = append(scans, e)
+		case MarketOrder:
+			orders++
+		}
+	}); err != nil {
+		t.Fatalf("readMarketEvents: %v", err)
+	}
+	if orders != 3 {
+		t.Errorf("order events = %d, want one per order", orders)
+	}
+	if len(scans) != 4 {
+		t.Fatalf("scan events = %d, want 4", len(scans))
+	}
+	want := []string{
+		"full [100000001 100000002] [] []",
+		"changes [] [] []",
+		"changes [] [100000003] [100000001]",
+		"full [100000003] [] []", // after a gap
+	}
+	for i, e := range scans {
+		kind := "changes"
+		if e.Full {
+			kind = "full"
+		}
+		got := fmt.Sprintf("%s %v %v %v", kind, e.Orders, e.Added, e.Removed)
+		if got != want[i] {
+			t.Errorf("scan %d = %s, want %s", i, got, want[i])
+		}
+	}
+
+	data, err := os.ReadFile(marketFilePath(marketStart))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unchanged := strings.Split(string(data), "\n")[3]; strings.Contains(unchanged, "orders") {
+		t.Errorf("unchanged scan written as %s, want no order list", unchanged)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SITE_CURRENCY is the currency every bid on the site is made in. Amounts
+// without a currency marker are assumed to be in it; amounts in any other
+// currency are rejected rather than converted.
+const SITE_CURRENCY = "USD"
+
+// Money is an amount in cents of SITE_CURRENCY. Sums and comparisons are
+// plain integer arithmetic, so they are exact.
+type Money int64
+
+// Rounding says what to do with fractions of a cent.
+type Rounding int
+
+const (
+	RoundHalfUp Rounding = iota // 0.5 cent and above rounds up
+	RoundUp                     // any fraction rounds up
+	RoundDown                   // fractions are dropped
+)
+
+var errInvalidMoney = errors.New("invalid amount")
+
+var currencySymbols = map[string]string{
+	"$": "USD", "us$": "USD", "usd": "USD",
+	"€": "EUR", "eur": "EUR",
+	"£": "GBP", "gbp": "GBP",
+}
+
+// Decimal formats m without a currency symbol, e.g. "1234.50", as the bid
+// form expects it.
+func (m Money) Decimal() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, int64(m)/100, int64(m)%100)
+}
+
+func (m Money) String() string {
+	if m < 0 {
+		return "-$" + (-m).Decimal()
+	}
+	return "$" + m.Decimal()
+}
+
+// AddPercent returns m increased by basisPoints hundredths of a percent.
+func (m Money) AddPercent(basisPoints int64, mode Rounding) Money {
+	return m + Money(divRound(int64(m)*basisPoints, 10000, mode))
+}
+
+// MarshalJSON writes m as a JSON number with two decimals, so files stay
+// readable and compatible with amounts stored as floats.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("%w: %s", errInvalidMoney, data)
+		}
+		text = number.String()
+	}
+	parsed, err := parseMoneyAmount(text, RoundHalfUp)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// divRound divides a non-negative n by d with the given rounding.
+func divRound(n, d int64, mode Rounding) int64 {
+	q, r := n/d, n%d
+	switch {
+	case r == 0:
+	case mode == RoundUp:
+		q++
+	case mode == RoundHalfUp && 2*r >= d:
+		q++
+	}
+	return q
+}
+
+// parseMoneyAmount parses a plain amount such as "1,234.50", "1.234,50",
+// "12" or "12.5". When both separators appear the last one is the decimal
+// point. A lone dot is a decimal point; a lone comma is one unless exactly
+// three digits follow it, as in "1,234". Precision beyond cents is rounded
+// with mode.
+func parseMoneyAmount(text string, mode Rounding) (Money, error) {
+	num := strings.ReplaceAll(strings.TrimSpace(text), " ", "")
+	if num == "" {
+		return 0, fmt.Errorf("%w: empty", errInvalidMoney)
+	}
+
+	lastDot, lastComma := strings.LastIndex(num, "."), strings.LastIndex(num, ",")
+	decimalAt := -1
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		decimalAt = max(lastDot, lastComma)
+	case lastDot >= 0 || lastComma >= 0:
+		sep := max(lastDot, lastComma)
+		if strings.Count(num, num[sep:sep+1]) == 1 && (num[sep] == '.' || len(num)-sep-1 != 3) {
+			decimalAt = sep
+		}
+	}
+
+	whole, frac := num, ""
+	if decimalAt >= 0 {
+		whole, frac = num[:decimalAt], num[decimalAt+1:]
+	}
+	whole = strings.NewReplacer(",", "", ".", "").Replace(whole)
+	if whole == "" {
+		whole = "0"
+	}
+	if !isDigits(whole) || !isDigits(frac) || len(whole) > 13 {
+		return 0, fmt.Errorf("%w: %q", errInvalidMoney, text)
+	}
+
+	units, _ := strconv.ParseInt(whole, 10, 64)
+	frac += "00"
+	cents, _ := strconv.ParseInt(frac[:2], 10, 64)
+	total := units*100 + cents
+	if rest := strings.TrimRight(frac[2:], "0"); rest != "" {
+		switch {
+		case mode == RoundUp:
+			total++
+		case mode == RoundHalfUp && rest[0] >= '5':
+			total++
+		}
+	}
+	return Money(total), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// BidMessage holds the limits read from a bid validation message. A zero
+// field was not present in the message.
+type BidMessage struct {
+	Minimum  Money
+	Maximum  Money
+	Currency string
+}
+
+var moneyInTextPattern = regexp.MustCompile(`(?i)(us\$|\$|€|£|usd|eur|gbp)?\s*(\d[\d.,]*\d|\d)\s*(usd|eur|gbp|\$|€|£)?`)
+
+// Phrases that say which limit the amount after them is. The phrase ending
+// closest to the amount wins; on a tie the longer one does.
+var bidLimitPhrases = []struct {
+	phrase  string
+	maximum bool
+}{
+	{"min", false}, {"at least", false}, {"greater than", false}, {"more than", false},
+	{"not less than", false}, {"no less than", false}, {"not be less than", false},
+	{"higher than", false}, {"lowest", false}, {"between", false},
+	{"max", true}, {"at most", true}, {"less than", true}, {"lower than", true}, {"up to", true},
+	{"not more than", true}, {"no more than", true}, {"not be more than", true},
+	{"not be greater than", true}, {"not exceed", true}, {"highest", true},
+}
+
+// parseBidMessage reads the minimum and maximum bid from the messages the
+// bid form shows, whatever their wording, e.g. "Minimum bid is $1,234.50",
+// "Your bid must be at least 12 USD" or "Bid must be between $5 and $100".
+// An amount without a limit phrase is the minimum if none was found yet,
+// or the upper end of a "between" range; otherwise it is not a limit, like
+// the 2 in "Minimum bid is $5 for 2 pages". Amounts without a currency
+// marker are skipped when others have one. A maximum below the minimum is
+// dropped.
+func parseBidMessage(text string) (BidMessage, error) {
+	msg := BidMessage{}
+	matches := moneyInTextPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return msg, fmt.Errorf("%w: no amount in %q", errInvalidMoney, text)
+	}
+	marked := func(m []int) bool { return m[2] >= 0 || m[6] >= 0 }
+	anyMarked := false
+	for _, m := range matches {
+		anyMarked = anyMarked || marked(m)
+	}
+
+	prev := 0
+	between := false
+	for _, m := range matches {
+		prefix := strings.ToLower(text[prev:m[0]])
+		prev = m[1]
+		isMax, found := bidLimitKind(prefix)
+		upperEnd := between && !found && msg.Maximum == 0
+		if anyMarked && !marked(m) && !upperEnd {
+			continue
+		}
+
+		amount, err := parseMoneyAmount(text[m[4]:m[5]], RoundHalfUp)
+		if err != nil {
+			return msg, err
+		}
+		currency := SITE_CURRENCY
+		for _, i := range []int{2, 6} {
+			if m[i] >= 0 {
+				currency = currencySymbols[strings.ToLower(text[m[i]:m[i+1]])]
+			}
+		}
+		if msg.Currency != "" && msg.Currency != currency {
+			return msg, fmt.Errorf("%w: mixed currencies in %q", errInvalidMoney, text)
+		}
+		msg.Currency = currency
+
+		switch {
+		case found && isMax:
+			msg.Maximum = amount
+		case found:
+			msg.Minimum = amount
+			between = strings.Contains(prefix, "between")
+		case upperEnd:
+			msg.Maximum = amount
+		case msg.Minimum == 0:
+			msg.Minimum = amount
+		}
+	}
+	if msg.Maximum > 0 && msg.Maximum < msg.Minimum {
+		msg.Maximum = 0
+	}
+	return msg, nil
+}
+
+func bidLimitKind(prefix string) (maximum bool, found bool) {
+	bestEnd, bestLen := -1, 0
+	for _, p := range bidLimitPhrases {
+		i := strings.LastIndex(prefix, p.phrase)
+		if i < 0 {
+			continue
+		}
+		end := i + len(p.phrase)
+		if end > bestEnd || (end == bestEnd && len(p.phrase) > bestLen) {
+			bestEnd, bestLen, maximum = end, len(p.phrase), p.maximum
+		}
+	}
+	return maximum, bestEnd >= 0
+}
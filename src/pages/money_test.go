@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoneyAmount(t *testing.T) {
+	tests := []struct {
+		text    string
+		mode    Rounding
+		want    Money
+		wantErr bool
+	}{
+		{text: "12", want: 1200},
+		{text: "12.5", want: 1250},
+		{text: "12.50", want: 1250},
+		{text: " 12.05 ", want: 1205},
+		{text: ".75", want: 75},
+		{text: "1,234.50", want: 123450},
+		{text: "1.234,50", want: 123450},
+		{text: "1 234,50", want: 123450},
+		{text: "1,234", want: 123400},
+		{text: "1,234,567", want: 123456700},
+		{text: "1.234.567,89", want: 123456789},
+		{text: "12,5", want: 1250},
+		{text: "12,50", want: 1250},
+		{text: "12.345", want: 1235},
+		{text: "12.345", mode: RoundDown, want: 1234},
+		{text: "12.341", mode: RoundUp, want: 1235},
+		{text: "12.3400", mode: RoundUp, want: 1234},
+		{text: "12.344", mode: RoundHalfUp, want: 1234},
+		{text: "", wantErr: true},
+		{text: "€12", wantErr: true},
+		{text: "$12", wantErr: true},
+		{text: "12 USD", wantErr: true},
+		{text: "abc", wantErr: true},
+		{text: "1-2", wantErr: true},
+		{text: "99999999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMoneyAmount(tt.text, tt.mode)
+		if tt.wantErr {
+			if !errors.Is(err, errInvalidMoney) {
+				t.Errorf("parseMoneyAmount(%q) = %s, %v; want errInvalidMoney", tt.text, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseMoneyAmount(%q) = %s, %v; want %s", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyAddPercent(t *testing.T) {
+	tests := []struct {
+		m           Money
+		basisPoints int64
+		mode        Rounding
+		want        Money
+	}{
+		{m: 1200, basisPoints: 0, mode: RoundUp, want: 1200},
+		{m: 1200, basisPoints: 1000, mode: RoundUp, want: 1320},
+		{m: 1205, basisPoints: 1000, mode: RoundUp, want: 1326},      // 1325.5
+		{m: 1205, basisPoints: 1000, mode: RoundHalfUp, want: 1326},  // 1325.5
+		{m: 1205, basisPoints: 1000, mode: RoundDown, want: 1325},    // 1325.5
+		{m: 1001, basisPoints: 1, mode: RoundUp, want: 1002},         // 1001.1001
+		{m: 1001, basisPoints: 1, mode: RoundHalfUp, want: 1001},     // 1001.1001
+		{m: 999, basisPoints: 250, mode: RoundHalfUp, want: 1024},    // 1023.975
+		{m: 999, basisPoints: 250, mode: RoundDown, want: 1023},      // 1023.975
+		{m: 123456, basisPoints: 10000, mode: RoundUp, want: 246912}, // doubled
+		{m: 1, basisPoints: 4999, mode: RoundHalfUp, want: 1},        // 1.4999
+		{m: 1, basisPoints: 5000, mode: RoundHalfUp, want: 2},        // 1.5
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.AddPercent(tt.basisPoints, tt.mode); got != tt.want {
+			t.Errorf("%s.AddPercent(%d, %d) = %s, want %s", tt.m, tt.basisPoints, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestParseBidMessage(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    BidMessage
+		wantErr bool
+	}{
+		{text: "Minimum bid is $12.00", want: BidMessage{Minimum: 1200, Currency: "USD"}},
+		{text: "Minimum bid is $1,234.50", want: BidMessage{Minimum: 123450, Currency: "USD"}},
+		{text: "Your bid must be at least 12.05 USD", want: BidMessage{Minimum: 1205, Currency: "USD"}},
+		{text: "Ensure this value is greater than or equal to 7.5.", want: BidMessage{Minimum: 750, Currency: "USD"}},
+		{text: "Bid must be between $5 and $100", want: BidMessage{Minimum: 500, Maximum: 10000, Currency: "USD"}},
+		{text: "Bid must be between 5 and 100", want: BidMessage{Minimum: 500, Maximum: 10000, Currency: "USD"}},
+		{text: "Bid must be between $5 and 100", want: BidMessage{Minimum: 500, Maximum: 10000, Currency: "USD"}},
+		{text: "Max bid is $50, min bid is $10", want: BidMessage{Minimum: 1000, Maximum: 5000, Currency: "USD"}},
+		{text: "Bid must not be more than $80 and not less than $20", want: BidMessage{Minimum: 2000, Maximum: 8000, Currency: "USD"}},
+		{text: "Minimum bid is €12,50", want: BidMessage{Minimum: 1250, Currency: "EUR"}},
+		{text: "Minimum bid is 1.234,50 EUR", want: BidMessage{Minimum: 123450, Currency: "EUR"}},
+		{text: "Minimum bid is £8", want: BidMessage{Minimum: 800, Currency: "GBP"}},
+		{text: "$15.00", want: BidMessage{Minimum: 1500, Currency: "USD"}},
+		// A count after the minimum is not a maximum.
+		{text: "Minimum bid is $5 for 2 pages", want: BidMessage{Minimum: 500, Currency: "USD"}},
+		{text: "Minimum bid is 5 for 2 pages", want: BidMessage{Minimum: 500, Currency: "USD"}},
+		{text: "Minimum bid for 2 pages is $5", want: BidMessage{Minimum: 500, Currency: "USD"}},
+		// A maximum below the minimum is dropped.
+		{text: "Minimum bid is $20, maximum $10", want: BidMessage{Minimum: 2000, Currency: "USD"}},
+		{text: "Please try again later", wantErr: true},
+		{text: "Minimum bid is $12 or €10", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseBidMessage(tt.text)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseBidMessage(%q) = %+v, want an error", tt.text, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseBidMessage(%q) = %+v, %v; want %+v", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestBidAmountStaysAtOrAboveMinimum(t *testing.T) {
+	saved := *cfg
+	defer func() { *cfg = saved }()
+
+	tests := []struct {
+		limits BidMessage
+		markup float64
+		want   Money
+	}{
+		{limits: BidMessage{Minimum: 1000}, markup: 10, want: 1100},
+		{limits: BidMessage{Minimum: 1000, Maximum: 1050}, markup: 10, want: 1050},
+		{limits: BidMessage{Minimum: 500, Maximum: 200}, markup: 0, want: 500},
+		{limits: BidMessage{Minimum: 500, Maximum: 200}, markup: 10, want: 550},
+	}
+	for _, tt := range tests {
+		cfg.BidMarkupPercent = tt.markup
+		if got := bidAmount(tt.limits); got != tt.want {
+			t.Errorf("bidAmount(%+v) with %g%% = %s, want %s", tt.limits, tt.markup, got, tt.want)
+		}
+	}
+}
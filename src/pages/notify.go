@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	stdLog "log"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	DEFAULT_HIGH_VALUE_BID = Money(5000)
+	DEFAULT_SMTP_PORT      = 587
+	NOTIFY_REPEAT_INTERVAL = 5 * time.Minute
+
+	// The SMTP password is a credential, so it is read from the environment
+	// instead of being saved with the rest of the config.
+	SMTP_PASSWORD_ENV = "BIDBOT_SMTP_PASSWORD"
+)
+
+// EventKind names a bot event that can trigger a notification.
+type EventKind string
+
+const (
+	EventHighValueBid   EventKind = "high_value_bid"
+	EventOrderWon       EventKind = "order_won"
+	EventLoginFailed    EventKind = "login_failed"
+	EventBreakerTripped EventKind = "breaker_tripped"
+	EventCapReached     EventKind = "cap_reached"
+	EventScriptError    EventKind = "script_error"
+	EventWorkerFailed   EventKind = "worker_failed"
+	EventLoginAssist    EventKind = "login_assist"
+)
+
+var eventKinds = []struct {
+	kind  EventKind
+	label string
+}{
+	{EventHighValueBid, "Bid on a high-value order"},
+	{EventOrderWon, "Order won"},
+	{EventLoginFailed, "Login failed"},
+	{EventBreakerTripped, "Circuit breaker tripped"},
+	{EventCapReached, "Bid cap reached"},
+	{EventScriptError, "Decision script error"},
+	{EventWorkerFailed, "Worker given up on"},
+	{EventLoginAssist, "Login needs your input"},
+}
+
+// NotificationConfig controls which events are reported and how.
+type NotificationConfig struct {
+	Desktop           bool               `json:"desktop"`
+	Events            map[EventKind]bool `json:"events"`
+	HighValueAmount   Money              `json:"high_value_amount"`
+	QuietHoursEnabled bool               `json:"quiet_hours_enabled"`
+	QuietHours        TimeRange          `json:"quiet_hours"`
+	Email             EmailConfig        `json:"email"`
+}
+
+// EmailConfig is the SMTP server notifications are mailed through. Any
+// local SMTP stand-in works for testing; leave Username empty to skip
+// authentication.
+type EmailConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	From     string `json:"from"`
+	To       string `json:"to"` // comma separated
+}
+
+var lastNotified = struct {
+	sync.Mutex
+	at map[string]time.Time
+}{at: make(map[string]time.Time)}
+
+func defaultNotificationConfig() NotificationConfig {
+	events := make(map[EventKind]bool)
+	for _, e := range eventKinds {
+		events[e.kind] = true
+	}
+	return NotificationConfig{
+		Desktop:         true,
+		Events:          events,
+		HighValueAmount: DEFAULT_HIGH_VALUE_BID,
+		QuietHours:      TimeRange{Start: "22:00", End: "07:00"},
+		Email:           EmailConfig{Port: DEFAULT_SMTP_PORT},
+	}
+}
+
+// notify reports an event on the desktop and by email, as configured.
+// Identical notifications are sent at most once per NOTIFY_REPEAT_INTERVAL.
+func notify(kind EventKind, title, message string) {
+	n := cfg.Notifications
+	if !n.Events[kind] {
+		return
+	}
+	now := time.Now()
+	if inQuietHours(now) {
+		debugLogger.Printf("Notification held back in quiet hours: %s: %s", title, message)
+		return
+	}
+
+	key := string(kind) + "\x00" + message
+	lastNotified.Lock()
+	if at, ok := lastNotified.at[key]; ok && now.Sub(at) < NOTIFY_REPEAT_INTERVAL {
+		lastNotified.Unlock()
+		return
+	}
+	lastNotified.at[key] = now
+	lastNotified.Unlock()
+
+	debugLogger.Printf("Notification %s: %s: %s", kind, title, message)
+	if n.Desktop {
+		if a := fyne.CurrentApp(); a != nil {
+			a.SendNotification(fyne.NewNotification(title, message))
+		}
+	}
+	if n.Email.Enabled {
+		go func() {
+			if err := sendEmail(n.Email, title, message); err != nil {
+				stdLog.Printf("Error sending notification email: %v", err)
+				debugLogger.Printf("Notification email error: %v", err)
+			}
+		}()
+	}
+}
+
+// notifyBidPlaced reports live bids at or above the high-value amount.
+func notifyBidPlaced(record BidRecord) {
+	threshold := cfg.Notifications.HighValueAmount
+	if record.Simulated || threshold <= 0 || record.Amount < threshold {
+		return
+	}
+	notify(EventHighValueBid, "High-Value Bid Placed",
+		fmt.Sprintf("Bid %s on %s", record.Amount, record.OrderURL))
+}
+
+// inQuietHours reports whether now falls in the quiet hours, read in the
+// schedule's timezone.
+func inQuietHours(now time.Time) bool {
+	n := cfg.Notifications
+	if !n.QuietHoursEnabled {
+		return false
+	}
+	quiet := WorkSchedule{Enabled: true, Timezone: cfg.Schedule.Timezone, Weekly: make(map[string][]TimeRange)}
+	for _, day := range weekdayKeys {
+		quiet.Weekly[day] = []TimeRange{n.QuietHours}
+	}
+	return quiet.IsOpen(now)
+}
+
+func sendEmail(ec EmailConfig, subject, body string) error {
+	var to []string
+	for _, addr := range strings.Split(ec.To, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	if ec.Host == "" || ec.From == "" || len(to) == 0 {
+		return fmt.Errorf("email notifications need a server, a sender and a recipient")
+	}
+
+	var auth smtp.Auth
+	if ec.Username != "" {
+		auth = smtp.PlainAuth("", ec.Username, os.Getenv(SMTP_PASSWORD_ENV), ec.Host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: [Bidding Bot] %s\r\nDate: %s\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		ec.From, strings.Join(to, ", "), subject, time.Now().Format(time.RFC1123Z), body)
+
+	addr := net.JoinHostPort(ec.Host, strconv.Itoa(ec.Port))
+	if err := smtp.SendMail(addr, auth, ec.From, to, []byte(msg)); err != nil {
+		return fmt.Errorf("error sending email via %s: %w", addr, err)
+	}
+	return nil
+}
+
+// notificationSettingsContent builds the Notifications screen.
+func notificationSettingsContent(w fyne.Window) fyne.CanvasObject {
+	n := cfg.Notifications
+
+	desktopCheck := widget.NewCheck("Desktop Notifications", func(v bool) {})
+	desktopCheck.SetChecked(n.Desktop)
+
+	eventChecks := make(map[EventKind]*widget.Check)
+	eventBox := container.NewVBox()
+	for _, e := range eventKinds {
+		check := widget.NewCheck(e.label, func(v bool) {})
+		check.SetChecked(n.Events[e.kind])
+		eventChecks[e.kind] = check
+		eventBox.Add(check)
+	}
+
+	highValueEntry := widget.NewEntry()
+	highValueEntry.SetText(n.HighValueAmount.Decimal())
+
+	quietCheck := widget.NewCheck("Quiet Hours (no notifications)", func(v bool) {})
+	quietCheck.SetChecked(n.QuietHoursEnabled)
+	quietEntry := widget.NewEntry()
+	quietEntry.SetPlaceHolder("22:00-07:00")
+	quietEntry.SetText(n.QuietHours.Start + "-" + n.QuietHours.End)
+
+	emailCheck := widget.NewCheck("Email Notifications", func(v bool) {})
+	emailCheck.SetChecked(n.Email.Enabled)
+	smtpHostEntry := widget.NewEntry()
+	smtpHostEntry.SetPlaceHolder("smtp.example.com")
+	smtpHostEntry.SetText(n.Email.Host)
+	smtpPortEntry := widget.NewEntry()
+	smtpPortEntry.SetText(strconv.Itoa(n.Email.Port))
+	smtpUserEntry := widget.NewEntry()
+	smtpUserEntry.SetPlaceHolder("Empty for no login (password from " + SMTP_PASSWORD_ENV + ")")
+	smtpUserEntry.SetText(n.Email.Username)
+	fromEntry := widget.NewEntry()
+	fromEntry.SetText(n.Email.From)
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("you@example.com, other@example.com")
+	toEntry.SetText(n.Email.To)
+
+	// read collects the form into a config, or reports the first bad field.
+	read := func() (NotificationConfig, error) {
+		out := cfg.Notifications
+		out.Desktop = desktopCheck.Checked
+		out.Events = make(map[EventKind]bool)
+		for kind, check := range eventChecks {
+			out.Events[kind] = check.Checked
+		}
+		hv, err := parseMoneyAmount(strings.TrimPrefix(strings.TrimSpace(highValueEntry.Text), "$"), RoundDown)
+		if err != nil {
+			return out, fmt.Errorf("invalid high-value amount: %w", err)
+		}
+		out.HighValueAmount = hv
+
+		start, end, ok := strings.Cut(quietEntry.Text, "-")
+		quiet := TimeRange{Start: strings.TrimSpace(start), End: strings.TrimSpace(end)}
+		if _, _, err := parseClock(quiet.Start); !ok || err != nil {
+			return out, fmt.Errorf("invalid quiet hours, expected HH:MM-HH:MM")
+		}
+		if _, _, err := parseClock(quiet.End); err != nil {
+			return out, fmt.Errorf("invalid quiet hours, expected HH:MM-HH:MM")
+		}
+		out.QuietHoursEnabled = quietCheck.Checked
+		out.QuietHours = quiet
+
+		port, err := strconv.Atoi(smtpPortEntry.Text)
+		if err != nil || port <= 0 {
+			return out, fmt.Errorf("invalid SMTP port")
+		}
+		out.Email = EmailConfig{
+			Enabled:  emailCheck.Checked,
+			Host:     strings.TrimSpace(smtpHostEntry.Text),
+			Port:     port,
+			Username: strings.TrimSpace(smtpUserEntry.Text),
+			From:     strings.TrimSpace(fromEntry.Text),
+			To:       toEntry.Text,
+		}
+		return out, nil
+	}
+
+	saveButton := widget.NewButton("Save Notifications", func() {
+		n, err := read()
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		cfg.Notifications = n
+		saveConfig()
+		dialog.ShowInformation("Settings Saved", "Your notification settings have been saved.", w)
+	})
+
+	// The test ignores the event toggles and quiet hours and mails
+	// synchronously so delivery errors can be shown.
+	testButton := widget.NewButton("Send Test Notification", func() {
+		n, err := read()
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		const title, message = "Test Notification", "Notifications from the bidding bot are working."
+		if n.Desktop {
+			fyne.CurrentApp().SendNotification(fyne.NewNotification(title, message))
+		}
+		if !n.Email.Enabled {
+			return
+		}
+		go func() {
+			err := sendEmail(n.Email, title, message)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				dialog.ShowInformation("Email Sent", "A test email was sent to "+n.Email.To+".", w)
+			})
+		}()
+	})
+
+	return container.NewVBox(
+		desktopCheck,
+		widget.NewLabel("Notify me about:"), eventBox,
+		widget.NewLabel("High-Value Bid from ($):"), highValueEntry,
+		quietCheck, quietEntry,
+		emailCheck,
+		widget.NewLabel("SMTP Server:"), smtpHostEntry,
+		widget.NewLabel("SMTP Port:"), smtpPortEntry,
+		widget.NewLabel("SMTP Username:"), smtpUserEntry,
+		widget.NewLabel("From:"), fromEntry,
+		widget.NewLabel("To:"), toEntry,
+		container.NewHBox(saveButton, testButton),
+	)
+}
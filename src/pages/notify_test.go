@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpMessage is what the fake SMTP server received for one mail.
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer accepts mail on a local port and sends every message it
+// receives on the returned channel.
+func fakeSMTPServer(t *testing.T) (EmailConfig, <-chan smtpMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan smtpMessage, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, messages)
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return EmailConfig{Enabled: true, Host: "127.0.0.1", Port: addr.Port, From: "bot@example.com", To: "me@example.com, you@example.com"}, messages
+}
+
+func serveSMTP(conn net.Conn, messages chan<- smtpMessage) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var msg smtpMessage
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			msg.data = data.String()
+			messages <- msg
+			msg = smtpMessage{}
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSendEmail(t *testing.T) {
+	ec, messages := fakeSMTPServer(t)
+
+	if err := sendEmail(ec, "Order Won", "Order 123456789 is yours."); err != nil {
+		t.Fatalf("sendEmail: %v", err)
+	}
+	msg := <-messages
+	if msg.from != "bot@example.com" {
+		t.Errorf("envelope sender = %q, want bot@example.com", msg.from)
+	}
+	if got := strings.Join(msg.to, ","); got != "me@example.com,you@example.com" {
+		t.Errorf("envelope recipients = %q, want both addresses", got)
+	}
+	for _, want := range []string{
+		"From: bot@example.com\r\n",
+		"To: me@example.com, you@example.com\r\n",
+		"Subject: [Bidding Bot] Order Won\r\n",
+		"\r\n\r\nOrder 123456789 is yours.\r\n",
+	} {
+		if !strings.Contains(msg.data, want) {
+			t.Errorf("message %q does not contain %q", msg.data, want)
+		}
+	}
+}
+
+func TestSendEmailNeedsRecipient(t *testing.T) {
+	ec := EmailConfig{Host: "127.0.0.1", Port: 25, From: "bot@example.com", To: " , "}
+	if err := sendEmail(ec, "subject", "body"); err == nil {
+		t.Error("sendEmail without a recipient succeeded")
+	}
+}
+
+func TestNotifyEventToggles(t *testing.T) {
+	setupOrderTest(t)
+	ec, messages := fakeSMTPServer(t)
+	cfg.Notifications.Desktop = false
+	cfg.Notifications.Email = ec
+	cfg.Notifications.Events[EventCapReached] = false
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10) // unique, past the repeat check
+
+	notify(EventCapReached, "Bid Cap Reached", "turned off "+suffix)
+	notify(EventOrderWon, "Order Won", "turned on "+suffix)
+
+	select {
+	case msg := <-messages:
+		if !strings.Contains(msg.data, "Subject: [Bidding Bot] Order Won") {
+			t.Errorf("mailed %q, want only the event that is turned on", msg.data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email for an event that is turned on")
+	}
+	select {
+	case msg := <-messages:
+		t.Errorf("mailed %q for an event that is turned off", msg.data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestInQuietHoursAcrossMidnight(t *testing.T) {
+	setupOrderTest(t)
+	cfg.Schedule.Timezone = "UTC"
+	cfg.Notifications.QuietHoursEnabled = true
+	cfg.Notifications.QuietHours = TimeRange{Start: "22:00", End: "07:00"}
+
+	tests := []struct {
+		clock string
+		want  bool
+	}{
+		{"21:59", false},
+		{"22:00", true},
+		{"23:30", true},
+		{"00:00", true},
+		{"03:15", true},
+		{"06:59", true},
+		{"07:00", false},
+		{"12:00", false},
+	}
+	for _, tt := range tests {
+		h, m, _ := parseClock(tt.clock)
+		now := time.Date(2026, 3, 4, h, m, 0, 0, time.UTC)
+		if got := inQuietHours(now); got != tt.want {
+			t.Errorf("inQuietHours(%s) = %t, want %t", tt.clock, got, tt.want)
+		}
+	}
+
+	cfg.Notifications.QuietHoursEnabled = false
+	if inQuietHours(time.Date(2026, 3, 4, 23, 0, 0, 0, time.UTC)) {
+		t.Error("in quiet hours while they are turned off")
+	}
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+const testOrderURL = "https://essayshark.com/writer/orders/123456789.html"
+
+// setupOrderTest isolates the global state the order pipeline touches and
+// runs the test in an empty directory, so sysfiles/ is written there.
+func setupOrderTest(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cfg = &Config{}
+	setDefaultConfig()
+	bidLedger = &BidLedger{}
+	breaker = newCircuitBreaker()
+	atomic.StoreInt32(&dryRunActive, 0)
+	t.Cleanup(func() { atomic.StoreInt32(&dryRunActive, 0) })
+}
+
+// bidOrderDocument is an order page whose bid form answers an invalid bid
+// with message and records the bid submitted after it.
+func bidOrderDocument(message string) *fakeDocument {
+	doc := newFakeDocument().
+		text("body", "Order details\nPages: 3 pages\nDeadline: 2d 4h").
+		text("#apply_order", "Apply").
+		input("#id_bid4")
+	doc.click("#apply_order", func(d *fakeDocument) {
+		if d.values["#id_bid4"] == "-1.00" {
+			d.texts["#id_bid4-error"] = message
+		}
+	})
+	return doc
+}
+
+func TestPlaceBid(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		markup    float64
+		want      Money
+		wantClass error
+	}{
+		{name: "minimum", message: "Minimum bid is $12.00", want: 1200},
+		{name: "markup rounds up", message: "Your bid must be at least 12.05 USD", markup: 10, want: 1326},
+		{name: "markup capped at maximum", message: "Bid must be between $10 and $10.50", markup: 50, want: 1050},
+		{name: "unreadable message", message: "Please try again later", wantClass: ErrBidRejected},
+		{name: "other currency", message: "Minimum bid is €12.00", wantClass: ErrBidRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupOrderTest(t)
+			cfg.BidMarkupPercent = tt.markup
+			page := newFakePage(testOrderURL, bidOrderDocument(tt.message))
+			ctx := withPage(context.Background(), page)
+
+			got, err := placeBid(ctx, testOrderURL, 0, defaultBidPrice)
+			if tt.wantClass != nil {
+				if !errors.Is(err, tt.wantClass) {
+					t.Fatalf("placeBid error = %v, want %v", err, tt.wantClass)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("placeBid: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("placeBid = %s, want %s", got, tt.want)
+			}
+			if submitted := page.value("#id_bid4"); submitted != tt.want.Decimal() {
+				t.Errorf("submitted bid %q, want %q", submitted, tt.want.Decimal())
+			}
+		})
+	}
+}
+
+// fastRetries keeps the retry policies' attempt counts but waits only a
+// millisecond between attempts.
+func fastRetries() {
+	for stage, p := range defaultRetryPolicies {
+		p.InitialDelayMs, p.MaxDelayMs = 1, 1
+		cfg.RetryPolicies[string(stage)] = p
+	}
+}
+
+func TestPlaceBidRetriesFailedRead(t *testing.T) {
+	setupOrderTest(t)
+	fastRetries()
+	doc := bidOrderDocument("Minimum bid is $12.00").
+		failNext("text #id_bid4-error", fmt.Errorf("fake: %w", context.DeadlineExceeded))
+	page := newFakePage(testOrderURL, doc)
+	ctx := withPage(context.Background(), page)
+
+	var got Money
+	err := withRetry(ctx, StageBid, 0, func() error {
+		var err error
+		got, err = placeBid(ctx, testOrderURL, 0, defaultBidPrice)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("placeBid: %v", err)
+	}
+	if got != 1200 {
+		t.Errorf("placeBid = %s, want $12.00", got)
+	}
+	if n := page.count("text #id_bid4-error"); n != 2 {
+		t.Errorf("bid message read %d times, want 2", n)
+	}
+}
+
+func TestPlaceBidGivesUpOnMissingButton(t *testing.T) {
+	setupOrderTest(t)
+	fastRetries()
+	missing := fmt.Errorf("fake: %w", chromedp.ErrNotVisible)
+	doc := bidOrderDocument("Minimum bid is $12.00").failNext("click #apply_order", missing, missing, missing)
+	page := newFakePage(testOrderURL, doc)
+	ctx := withPage(context.Background(), page)
+
+	err := withRetry(ctx, StageBid, 0, func() error {
+		_, err := placeBid(ctx, testOrderURL, 0, defaultBidPrice)
+		return err
+	})
+	if !errors.Is(err, ErrSelectorMissing) {
+		t.Fatalf("placeBid error = %v, want %v", err, ErrSelectorMissing)
+	}
+	if n, want := page.count("click #apply_order"), retryPolicyFor(StageBid).MaxAttempts; n != want {
+		t.Errorf("apply clicked %d times, want %d", n, want)
+	}
+	if breaker.counts[StageBid] != 1 {
+		t.Errorf("breaker counted %d bid failures, want 1", breaker.counts[StageBid])
+	}
+}
+
+func TestPlaceBidDoesNotRetryFailedSubmit(t *testing.T) {
+	setupOrderTest(t)
+	fastRetries()
+	timeout := fmt.Errorf("fake: %w", context.DeadlineExceeded)
+	// The click with -1.00 works, the click submitting the bid times out.
+	doc := bidOrderDocument("Minimum bid is $12.00").failNext("click #apply_order", nil, timeout)
+	page := newFakePage(testOrderURL, doc)
+	ctx := withPage(context.Background(), page)
+
+	err := withRetry(ctx, StageBid, 0, func() error {
+		_, err := placeBid(ctx, testOrderURL, 0, defaultBidPrice)
+		return err
+	})
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, errMaybeSubmitted) {
+		t.Fatalf("placeBid error = %v, want a timeout after submitting", err)
+	}
+	if n := page.count("click #apply_order"); n != 2 {
+		t.Errorf("apply clicked %d times, want 2 (probe and submit, no retry)", n)
+	}
+}
+
+func TestApplyForOrderDoesNotRetryFailedSubmit(t *testing.T) {
+	setupOrderTest(t)
+	fastRetries()
+	doc := newFakeDocument().text("#apply_order", "Apply").
+		failNext("click #apply_order", fmt.Errorf("fake: %w", context.DeadlineExceeded))
+	page := newFakePage(testOrderURL, doc)
+	ctx := withPage(context.Background(), page)
+
+	err := withRetry(ctx, StageBid, 0, func() error { return applyForOrder(ctx) })
+	if !errors.Is(err, errMaybeSubmitted) {
+		t.Fatalf("applyForOrder error = %v, want %v", err, errMaybeSubmitted)
+	}
+	if n := page.count("click #apply_order"); n != 1 {
+		t.Errorf("apply clicked %d times, want 1", n)
+	}
+}
+
+func TestPlaceBidCapReached(t *testing.T) {
+	setupOrderTest(t)
+	cfg.MaxBidValuePerDay = 1000
+	page := newFakePage(testOrderURL, bidOrderDocument("Minimum bid is $12.00"))
+
+	_, err := placeBid(withPage(context.Background(), page), testOrderURL, 0, defaultBidPrice)
+	if !errors.Is(err, ErrCapReached) {
+		t.Fatalf("placeBid error = %v, want %v", err, ErrCapReached)
+	}
+	if page.did("set #id_bid4=12.00") {
+		t.Error("bid was submitted over the daily value cap")
+	}
+}
+
+func TestPlaceBidDryRunDoesNotSubmit(t *testing.T) {
+	setupOrderTest(t)
+	atomic.StoreInt32(&dryRunActive, 1)
+	doc := bidOrderDocument("unused")
+	doc.evalFunc("dispatchEvent", func(d *fakeDocument) interface{} {
+		d.texts["#id_bid4-error"] = "Minimum bid is $15.00"
+		return nil
+	})
+	page := newFakePage(testOrderURL, doc)
+
+	got, err := placeBid(withPage(context.Background(), page), testOrderURL, 0, defaultBidPrice)
+	if err != nil {
+		t.Fatalf("placeBid: %v", err)
+	}
+	if got != 1500 {
+		t.Errorf("placeBid = %s, want $15.00", got)
+	}
+	if page.did("click #apply_order") {
+		t.Error("dry run clicked the apply button")
+	}
+}
+
+func TestPlaceBidMissingForm(t *testing.T) {
+	setupOrderTest(t)
+	page := newFakePage(testOrderURL, newFakeDocument().eval("loginForm", pageState{URL: testOrderURL, Ready: "complete"}))
+
+	_, err := placeBid(withPage(context.Background(), page), testOrderURL, 0, defaultBidPrice)
+	if !errors.Is(err, ErrSelectorMissing) {
+		t.Fatalf("placeBid error = %v, want %v", err, ErrSelectorMissing)
+	}
+}
+
+func TestHandleOrderBidsAndSendsMessage(t *testing.T) {
+	setupOrderTest(t)
+	cfg.MessageEnabled = true
+	cfg.MessageText = "Hello, I can start right away."
+	doc := bidOrderDocument("Minimum bid is $20.00").input("#id_body").text("#id_send_message", "Send")
+	page := newFakePage(testOrderURL, doc)
+	discovered := time.Now().Add(-2 * time.Second)
+
+	listing := orderListing{URL: testOrderURL, ServiceType: "Writing", Deadline: "2d 4h", DiscoveredAt: discovered}
+	if err := handleOrder(withPage(context.Background(), page), listing, 1); err != nil {
+		t.Fatalf("handleOrder: %v", err)
+	}
+
+	if !page.did("click #id_send_message") || page.value("#id_body") != cfg.MessageText {
+		t.Errorf("message not sent, actions %v", page.actions)
+	}
+	records := bidLedger.Records(false)
+	if len(records) != 1 {
+		t.Fatalf("ledger has %d bids, want 1", len(records))
+	}
+	rec := records[0]
+	if rec.Amount != 2000 || rec.FixedPrice || rec.Pages != 3 || rec.ServiceType != "Writing" ||
+		rec.Message != cfg.MessageText || !rec.DiscoveredAt.Equal(discovered) {
+		t.Errorf("unexpected bid record %+v", rec)
+	}
+	if rec.DeadlineHours < 51.9 || rec.DeadlineHours > 52 {
+		t.Errorf("DeadlineHours = %v, want 52", rec.DeadlineHours)
+	}
+}
+
+func TestHandleOrderFixedPrice(t *testing.T) {
+	setupOrderTest(t)
+	doc := newFakeDocument().
+		text("body", "Your bid: This field is disabled for fixed-price orders.").
+		text("#apply_order", "Apply")
+	page := newFakePage(testOrderURL, doc)
+
+	if err := handleOrder(withPage(context.Background(), page), orderListing{URL: testOrderURL}, 0); err != nil {
+		t.Fatalf("handleOrder: %v", err)
+	}
+	if !page.did("click #apply_order") {
+		t.Error("fixed-price order was not applied for")
+	}
+	if page.did("set #id_bid4=-1.00") {
+		t.Error("bid form was used on a fixed-price order")
+	}
+	if records := bidLedger.Records(false); len(records) != 1 || !records[0].FixedPrice {
+		t.Errorf("ledger = %+v, want one fixed-price record", records)
+	}
+}
+
+func TestHandleOrderCountdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds string
+		skip    bool
+	}{
+		{name: "fits", seconds: "1"},
+		{name: "outlasts the budget", seconds: "30", skip: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupOrderTest(t)
+			page := newFakePage(testOrderURL, bidOrderDocument("Minimum bid is $12.00").text("#id_read_timeout_sec", tt.seconds))
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+			defer cancel()
+
+			start := time.Now()
+			err := handleOrder(withPage(ctx, page), orderListing{URL: testOrderURL}, 0)
+			if skipped := errors.Is(err, errCountdownTooLong); skipped != tt.skip {
+				t.Fatalf("handleOrder error = %v, want countdown skip %t", err, tt.skip)
+			}
+			if tt.skip {
+				if time.Since(start) > time.Second {
+					t.Errorf("waited %s before skipping", time.Since(start))
+				}
+				if page.did("click #apply_order") {
+					t.Error("skipped order was bid on")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("handleOrder: %v", err)
+			}
+			if time.Since(start) < time.Second {
+				t.Errorf("bid after %s, before the countdown ran out", time.Since(start))
+			}
+		})
+	}
+}
+
+func TestHandleOrderScriptDecisions(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   Money
+		skip   bool
+		failed bool
+	}{
+		{name: "default", script: "function decide(order) {}", want: 1200},
+		{name: "amount", script: "function decide(order) { return {action: 'bid', amount: order.minimum_bid + 3} }", want: 1500},
+		{name: "skip", script: "function decide(order) { return {action: 'skip', reason: 'too short'} }", skip: true},
+		{name: "error", script: "function decide(order) { return order.missing.field }", failed: true},
+		{name: "apply on bid order", script: "function decide(order) { return {action: 'apply'} }", failed: true},
+		{name: "amount with skip", script: "function decide(order) { return {action: 'skip', amount: 20} }", failed: true},
+		{name: "below minimum", script: "function decide(order) { return {action: 'bid', amount: 5} }", failed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupOrderTest(t)
+			cfg.Script.Enabled = true
+			cfg.Script.File = filepath.Join(t.TempDir(), "decide.js")
+			if err := os.WriteFile(cfg.Script.File, []byte(tt.script), 0644); err != nil {
+				t.Fatal(err)
+			}
+			page := newFakePage(testOrderURL, bidOrderDocument("Minimum bid is $12.00"))
+
+			err := handleOrder(withPage(context.Background(), page), orderListing{URL: testOrderURL}, 0)
+			if _, skipped := scriptSkipReason(err); skipped != tt.skip {
+				t.Fatalf("handleOrder error = %v, want skip %t", err, tt.skip)
+			}
+			if failed := errors.Is(err, ErrScriptFailed); failed != tt.failed {
+				t.Fatalf("handleOrder error = %v, want script failure %t", err, tt.failed)
+			}
+			if tt.skip || tt.failed {
+				if page.did("click #apply_order") && page.value("#id_bid4") != "-1.00" {
+					t.Error("skipped order was bid on")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("handleOrder: %v", err)
+			}
+			if records := bidLedger.Records(false); len(records) != 1 || records[0].Amount != tt.want {
+				t.Errorf("ledger = %+v, want one bid of %s", records, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleOrderScriptBidOnFixedPrice(t *testing.T) {
+	setupOrderTest(t)
+	cfg.Script.Enabled = true
+	cfg.Script.File = filepath.Join(t.TempDir(), "decide.js")
+	if err := os.WriteFile(cfg.Script.File, []byte("function decide(order) { return {action: 'bid', amount: 20} }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc := newFakeDocument().
+		text("body", "Your bid: This field is disabled for fixed-price orders.").
+		text("#apply_order", "Apply")
+	page := newFakePage(testOrderURL, doc)
+
+	err := handleOrder(withPage(context.Background(), page), orderListing{URL: testOrderURL}, 0)
+	if !errors.Is(err, ErrScriptFailed) {
+		t.Fatalf("handleOrder error = %v, want %v", err, ErrScriptFailed)
+	}
+	if page.did("click #apply_order") {
+		t.Error("applied for an order the script failed on")
+	}
+}
+
+func TestOpenOrderClassifiesPage(t *testing.T) {
+	tests := []struct {
+		name  string
+		state pageState
+		want  error
+	}{
+		{name: "available", state: pageState{URL: testOrderURL, Ready: "complete"}},
+		{name: "gone", state: pageState{URL: testOrderURL, Text: "sorry, this order has been assigned"}, want: ErrOrderGone},
+		{name: "logged out", state: pageState{URL: "https://essayshark.com/log-in.html", LoginForm: true}, want: ErrLoggedOut},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupOrderTest(t)
+			page := newFakePage("about:blank", newFakeDocument())
+			page.docs[testOrderURL] = newFakeDocument().eval("loginForm", tt.state)
+
+			err := openOrder(withPage(context.Background(), page), testOrderURL)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("openOrder error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOrderList(t *testing.T) {
+	want := listedOrders{
+		Links:     []string{testOrderURL, ""},
+		Services:  []string{"Editing", "Writing"},
+		Deadlines: []string{"5h", "2d 1h"},
+	}
+	page := newFakePage(ORDERS_PAGE_URL, newFakeDocument().eval("tr.order_container", want))
+
+	got, err := parseOrderList(withPage(context.Background(), page))
+	if err != nil {
+		t.Fatalf("parseOrderList: %v", err)
+	}
+	if len(got.Links) != 2 || got.Links[0] != testOrderURL || got.Services[1] != "Writing" || got.Deadlines[1] != "2d 1h" {
+		t.Errorf("parseOrderList = %+v, want %+v", got, want)
+	}
+}
+
+func TestOrderDiscardReasonServiceType(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		service            string
+		assignments, edits bool
+		discard            bool
+	}{
+		{service: "Writing help or assignments", discard: false},
+		{service: "Writing help or assignments", assignments: true, discard: true},
+		{service: "WRITING HELP OR ASSIGNMENTS", assignments: true, discard: true},
+		{service: "Editing", discard: false},
+		{service: "Editing", edits: true, discard: true},
+		{service: "Writing", assignments: true, edits: true, discard: false},
+	}
+
+	for _, tt := range tests {
+		cfg = &Config{}
+		setDefaultConfig()
+		cfg.DiscardAssignments, cfg.DiscardEditing = tt.assignments, tt.edits
+		reason := orderDiscardReason(tt.service, "1d 0h", now)
+		if (reason != "") != tt.discard {
+			t.Errorf("orderDiscardReason(%q) with assignments=%t editing=%t = %q, want discard %t",
+				tt.service, tt.assignments, tt.edits, reason, tt.discard)
+		}
+	}
+}
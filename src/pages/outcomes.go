@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	stdLog "log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	MY_BIDS_PAGE_URL       = "https://essayshark.com/writer/orders/my_bids/"
+	OUTCOMES_FILE_NAME     = "outcomes.jsonl"
+	OUTCOME_CHECK_INTERVAL = 15 * time.Minute
+
+	// A bid must be this old before its absence from the bid list counts,
+	// since the site may take a moment to list it.
+	OUTCOME_GRACE = 5 * time.Minute
+	// A bid still undecided after this long is recorded as expired. It is
+	// shorter than BID_MEMORY_RETENTION so the ledger still holds the bid.
+	OUTCOME_EXPIRE_AFTER = 36 * time.Hour
+)
+
+// Outcome is what became of a bid.
+type Outcome string
+
+const (
+	OutcomeWon     Outcome = "won"
+	OutcomeLost    Outcome = "lost"
+	OutcomeExpired Outcome = "expired"
+)
+
+// BidOutcome is one line of sysfiles/outcomes.jsonl.
+type BidOutcome struct {
+	OrderURL  string    `json:"order_url"`
+	OrderID   string    `json:"order_id"`
+	Outcome   Outcome   `json:"outcome"`
+	Amount    Money     `json:"amount"`
+	BidAt     time.Time `json:"bid_at"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// OutcomeTracker remembers which bids already have an outcome.
+type OutcomeTracker struct {
+	mu      sync.Mutex
+	decided map[string]BidOutcome // by order ID
+}
+
+var outcomes = &OutcomeTracker{decided: make(map[string]BidOutcome)}
+
+var orderIDPattern = regexp.MustCompile(`(\d{4,})`)
+
+func getOutcomesFilePath() string {
+	return filepath.Join(getSysfilesDir(), OUTCOMES_FILE_NAME)
+}
+
+// orderID extracts a stable key for an order URL: the last long number in
+// its path, or the path itself.
+func orderID(orderUrl string) string {
+	path := orderUrl
+	if u, err := url.Parse(orderUrl); err == nil {
+		path = u.Path
+	}
+	if ids := orderIDPattern.FindAllString(path, -1); len(ids) > 0 {
+		return ids[len(ids)-1]
+	}
+	return strings.TrimSuffix(path, "/")
+}
+
+// Load reads the outcomes recorded by earlier runs.
+func (t *OutcomeTracker) Load() {
+	decided, err := readOutcomes()
+	if err != nil {
+		debugLogger.Printf("Outcomes read error: %v", err)
+	}
+	t.mu.Lock()
+	t.decided = decided
+	t.mu.Unlock()
+}
+
+// readOutcomes reads sysfiles/outcomes.jsonl keyed by order ID.
+func readOutcomes() (map[string]BidOutcome, error) {
+	decided := make(map[string]BidOutcome)
+	f, err := os.Open(getOutcomesFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return decided, nil
+		}
+		return decided, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var o BidOutcome
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			debugLogger.Printf("Outcomes skipping bad line: %v", err)
+			continue
+		}
+		decided[o.OrderID] = o
+	}
+	return decided, scanner.Err()
+}
+
+// get returns the outcome recorded for an order, if any.
+func (t *OutcomeTracker) get(id string) (BidOutcome, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	o, ok := t.decided[id]
+	return o, ok
+}
+
+func (t *OutcomeTracker) record(o BidOutcome) {
+	t.mu.Lock()
+	t.decided[o.OrderID] = o
+	t.mu.Unlock()
+
+	data, err := json.Marshal(o)
+	if err != nil {
+		debugLogger.Printf("Outcome marshal error: %v", err)
+		return
+	}
+	f, err := os.OpenFile(getOutcomesFilePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		debugLogger.Printf("Outcomes write error: %v", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+// readOrderLinks opens one of the writer's order lists and returns the
+// order links on it.
+func readOrderLinks(ctx context.Context, pageUrl string) ([]string, error) {
+	if !scheduler.AcquirePageLoad(ctx) {
+		return nil, fmt.Errorf("stopped while waiting for page load budget")
+	}
+	ctxList, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	// A login page has no orders, which would read as every bid lost
+	var links []string
+	page := pageFrom(ctx)
+	err := loadPage(ctxList, StageList, pageUrl)
+	if err == nil {
+		err = page.WaitReady(ctxList, `body`)
+	}
+	if err == nil {
+		err = page.Evaluate(ctxList, `Array.from(document.querySelectorAll("tr.order_container td.topictitle a")).map(a => a.href)`, &links)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading order list %s: %w", pageUrl, err)
+	}
+	return links, nil
+}
+
+// checkOutcomes matches the bot's live bids against the writer's "my
+// bids" and "in progress" lists and records the bids that were decided.
+// "My bids" is read first: an order assigned between the two reads then
+// shows up in progress rather than in neither list. A bid recorded as lost
+// or expired still becomes won if the order turns up in progress later.
+func checkOutcomes(ctx context.Context, threadIndex int) error {
+	myBids, err := readOrderLinks(ctx, MY_BIDS_PAGE_URL)
+	if err != nil {
+		return err
+	}
+	inProgress, err := readOrderLinks(ctx, ACTIVE_ORDERS_PAGE_URL)
+	if err != nil {
+		return err
+	}
+	setActiveOrderCount(len(inProgress))
+
+	won := make(map[string]bool)
+	for _, link := range inProgress {
+		won[orderID(link)] = true
+	}
+	open := make(map[string]bool)
+	for _, link := range myBids {
+		open[orderID(link)] = true
+	}
+	// An empty bid list may just mean the page changed, so absence only
+	// counts as lost while the list shows something.
+	listReadable := len(myBids) > 0
+
+	now := time.Now()
+	for _, rec := range bidLedger.Records(false) {
+		id := orderID(rec.OrderURL)
+		if prev, ok := outcomes.get(id); ok && (prev.Outcome == OutcomeWon || !won[id]) {
+			continue
+		}
+		age := now.Sub(rec.PlacedAt)
+		var outcome Outcome
+		switch {
+		case won[id]:
+			outcome = OutcomeWon
+		case age > OUTCOME_EXPIRE_AFTER:
+			outcome = OutcomeExpired
+		case listReadable && !open[id] && age > OUTCOME_GRACE:
+			outcome = OutcomeLost
+		default:
+			continue
+		}
+
+		o := BidOutcome{OrderURL: rec.OrderURL, OrderID: id, Outcome: outcome, Amount: rec.Amount, BidAt: rec.PlacedAt, DecidedAt: now}
+		outcomes.record(o)
+		debugLogger.Printf("Thread %d: Order %s outcome: %s", threadIndex, rec.OrderURL, outcome)
+		if outcome == OutcomeWon {
+			stdLog.Printf("Order won: %s (bid %s).", rec.OrderURL, rec.Amount)
+			notify(EventOrderWon, "Order Won", fmt.Sprintf("You were assigned %s (bid %s).", rec.OrderURL, rec.Amount))
+			emitWebhook(HookOrderWon, o)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// orderListPage is a fake page showing myBids on the "my bids" list and
+// inProgress on the "in progress" list.
+func orderListPage(myBids, inProgress []string) *fakePage {
+	list := func(links []string) *fakeDocument {
+		return newFakeDocument().eval("td.topictitle a", links)
+	}
+	page := newFakePage(MY_BIDS_PAGE_URL, list(myBids))
+	page.docs[ACTIVE_ORDERS_PAGE_URL] = list(inProgress)
+	return page
+}
+
+const otherOrderURL = "https://essayshark.com/writer/orders/987654321.html"
+
+// setupOutcomeTest starts with a ten-minute-old bid on testOrderURL and
+// no outcomes.
+func setupOutcomeTest(t *testing.T) {
+	setupOrderTest(t)
+	ensureFolders()
+	cfg.Notifications.Events = nil
+	scheduler = newScanScheduler()
+	outcomes = &OutcomeTracker{decided: make(map[string]BidOutcome)}
+	bidLedger.Record(BidRecord{OrderURL: testOrderURL, Amount: 1200, PlacedAt: time.Now().Add(-10 * time.Minute)})
+}
+
+func TestCheckOutcomes(t *testing.T) {
+	setupOutcomeTest(t)
+	id := orderID(testOrderURL)
+
+	// Assigned: the order moved from "my bids" to "in progress".
+	page := orderListPage([]string{otherOrderURL}, []string{testOrderURL})
+	if err := checkOutcomes(withPage(context.Background(), page), 0); err != nil {
+		t.Fatalf("checkOutcomes: %v", err)
+	}
+	if o, _ := outcomes.get(id); o.Outcome != OutcomeWon {
+		t.Fatalf("outcome = %q, want %q", o.Outcome, OutcomeWon)
+	}
+	if first := page.actions[0]; first != "navigate "+MY_BIDS_PAGE_URL {
+		t.Errorf("first action = %q, want \"my bids\" read first", first)
+	}
+}
+
+func TestCheckOutcomesLostThenWon(t *testing.T) {
+	setupOutcomeTest(t)
+	id := orderID(testOrderURL)
+
+	// Missing from both lists reads as lost...
+	ctx := withPage(context.Background(), orderListPage([]string{otherOrderURL}, nil))
+	if err := checkOutcomes(ctx, 0); err != nil {
+		t.Fatalf("checkOutcomes: %v", err)
+	}
+	if o, _ := outcomes.get(id); o.Outcome != OutcomeLost {
+		t.Fatalf("outcome = %q, want %q", o.Outcome, OutcomeLost)
+	}
+
+	// ...until the order turns up in progress.
+	ctx = withPage(context.Background(), orderListPage([]string{otherOrderURL}, []string{testOrderURL}))
+	if err := checkOutcomes(ctx, 0); err != nil {
+		t.Fatalf("checkOutcomes: %v", err)
+	}
+	if o, _ := outcomes.get(id); o.Outcome != OutcomeWon {
+		t.Fatalf("outcome = %q, want %q", o.Outcome, OutcomeWon)
+	}
+
+	// The outcome file keeps both lines; the last one counts on reload.
+	decided, err := readOutcomes()
+	if err != nil {
+		t.Fatalf("readOutcomes: %v", err)
+	}
+	if decided[id].Outcome != OutcomeWon {
+		t.Errorf("reloaded outcome = %q, want %q", decided[id].Outcome, OutcomeWon)
+	}
+
+	// A won order is final.
+	ctx = withPage(context.Background(), orderListPage([]string{otherOrderURL}, nil))
+	if err := checkOutcomes(ctx, 0); err != nil {
+		t.Fatalf("checkOutcomes: %v", err)
+	}
+	if o, _ := outcomes.get(id); o.Outcome != OutcomeWon {
+		t.Errorf("outcome after the order left progress = %q, want %q", o.Outcome, OutcomeWon)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	stdLog "log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pause reasons are keyed so independent subsystems can hold the bot
+// paused at the same time; workers only continue once every key is gone.
+var pauseState = struct {
+	sync.Mutex
+	reasons map[string]string
+}{reasons: make(map[string]string)}
+
+// pauseBot holds all workers before their next page load until resumeBot is
+// called with the same key.
+func pauseBot(key, detail string) {
+	pauseState.Lock()
+	_, already := pauseState.reasons[key]
+	pauseState.reasons[key] = detail
+	pauseState.Unlock()
+
+	if !already {
+		stdLog.Printf("Bot paused: %s", detail)
+		debugLogger.Printf("Pause %q set: %s", key, detail)
+	}
+	refreshStatus()
+}
+
+func resumeBot(key string) {
+	pauseState.Lock()
+	_, had := pauseState.reasons[key]
+	delete(pauseState.reasons, key)
+	pauseState.Unlock()
+
+	if had {
+		stdLog.Printf("Pause reason %q cleared.", key)
+		debugLogger.Printf("Pause %q cleared.", key)
+	}
+	refreshStatus()
+}
+
+func clearPauses() {
+	pauseState.Lock()
+	pauseState.reasons = make(map[string]string)
+	pauseState.Unlock()
+	refreshStatus()
+}
+
+// pauseReasons returns the details of all active pauses in a stable order.
+func pauseReasons() []string {
+	pauseState.Lock()
+	defer pauseState.Unlock()
+	keys := make([]string, 0, len(pauseState.reasons))
+	for k := range pauseState.reasons {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	details := make([]string, 0, len(keys))
+	for _, k := range keys {
+		details = append(details, pauseState.reasons[k])
+	}
+	return details
+}
+
+func isPaused() bool {
+	pauseState.Lock()
+	defer pauseState.Unlock()
+	return len(pauseState.reasons) > 0
+}
+
+// waitWhilePaused blocks while the bot is paused. It returns false if the
+// bot was stopped or ctx ended while waiting.
+func waitWhilePaused(ctx context.Context) bool {
+	for isPaused() {
+		beat(ctx)
+		if atomic.LoadInt32(&stopFlag) != 0 {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	return atomic.LoadInt32(&stopFlag) == 0
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	stdLog "log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	BROWSER_MODE_REMOTE   = "remote"
+	REMOTE_LOOKUP_TIMEOUT = 5 * time.Second
+)
+
+// remoteAddress turns a configured address into one chromedp's remote
+// allocator accepts: a DevTools websocket URL is used as is, and a bare
+// host:port gets a ws:// scheme so the allocator looks the websocket up.
+func remoteAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", fmt.Errorf("no remote debugging address set")
+	}
+	if !strings.Contains(address, "://") {
+		address = "ws://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", fmt.Errorf("error parsing remote debugging address: %w", err)
+	}
+	switch u.Scheme {
+	case "ws", "wss", "http", "https":
+	default:
+		return "", fmt.Errorf("unsupported remote debugging address scheme %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		return "", fmt.Errorf("remote debugging address %q has no port", address)
+	}
+	return address, nil
+}
+
+// debuggingURL returns the DevTools websocket URL of the browser at the
+// configured address, asking the browser for it unless the address is one
+// already.
+func debuggingURL(ctx context.Context) (string, error) {
+	address, err := remoteAddress(cfg.RemoteDebuggingURL)
+	if err != nil {
+		return "", err
+	}
+	u, _ := url.Parse(address)
+	if strings.Contains(u.Path, "/devtools/browser/") {
+		return address, nil
+	}
+	scheme := "http"
+	if u.Scheme == "wss" || u.Scheme == "https" {
+		scheme = "https"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, REMOTE_LOOKUP_TIMEOUT)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+u.Host+"/json/version", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error reaching Chrome at %s: %w", u.Host, err)
+	}
+	defer resp.Body.Close()
+	var version struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return "", fmt.Errorf("error reading Chrome version info: %w", err)
+	}
+	if version.WebSocketDebuggerURL == "" {
+		return "", fmt.Errorf("Chrome at %s reported no debugging URL", u.Host)
+	}
+	return version.WebSocketDebuggerURL, nil
+}
+
+// remoteChrome is a Chrome the user started with remote debugging; worker
+// pages are tabs in it. chromedp closes a remote browser when the context
+// that connected to it ends, so the connection is kept for the life of
+// the app and shared by every start of the bot instead. Stopping the bot
+// closes only the tabs it opened.
+type remoteChrome struct {
+	mu          sync.Mutex
+	address     string
+	browserCtx  context.Context // the tab that holds the connection
+	allocCancel context.CancelFunc
+}
+
+var remoteBrowser = &remoteChrome{}
+
+func (b *remoteChrome) NewPage(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	browserCtx, err := b.connect()
+	if err != nil {
+		return nil, nil, err
+	}
+	tabCtx, cancel, err := openTab(browserCtx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening tab in remote Chrome: %w", err)
+	}
+	return tabCtx, cancel, nil
+}
+
+// connect returns the connection to the configured address, connecting
+// again if the address changed or the browser went away.
+func (b *remoteChrome) connect() (context.Context, error) {
+	address, err := remoteAddress(cfg.RemoteDebuggingURL)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.browserCtx != nil && b.browserCtx.Err() == nil && b.address == address {
+		return b.browserCtx, nil
+	}
+
+	// The old connection is for another address or its browser went
+	// away; drop it and the tab that held it.
+	if b.allocCancel != nil {
+		b.allocCancel()
+		b.address, b.browserCtx, b.allocCancel = "", nil, nil
+	}
+
+	// Not derived from the bot's context: ending it would close the
+	// user's browser.
+	allocCtx, allocCancel := chromedp.NewRemoteAllocator(context.Background(), address)
+	browserCtx, _, err := openTab(allocCtx)
+	if err != nil {
+		allocCancel()
+		return nil, fmt.Errorf("error connecting to Chrome at %s: %w", address, err)
+	}
+	context.AfterFunc(browserCtx, registerBrowser(browserCtx))
+	b.address, b.browserCtx, b.allocCancel = address, browserCtx, allocCancel
+	stdLog.Printf("Connected to Chrome at %s.", address)
+	debugLogger.Printf("Remote browser connected: %s", address)
+	return browserCtx, nil
+}
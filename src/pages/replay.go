@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+const REPLAY_EXPECTED_FILE = "expected.json"
+
+// ReplayDecision is what the parsing and decision code made of one
+// captured page.
+type ReplayDecision struct {
+	Seq    int         `json:"seq"`
+	Kind   CaptureKind `json:"kind"`
+	URL    string      `json:"url"`
+	Result []string    `json:"result"`
+}
+
+var headTagPattern = regexp.MustCompile(`(?i)<head[^>]*>`)
+
+// runReplay feeds a capture session back through the parsers offline and
+// prints the decisions. If the session has an expected.json the decisions
+// are compared to it; update rewrites it instead. It returns the process
+// exit code.
+func runReplay(dir string, update bool, chromePath string) int {
+	data, err := os.ReadFile(filepath.Join(dir, CAPTURE_CONFIG_FILE))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading config snapshot: %v\n", err)
+		return 2
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing config snapshot: %v\n", err)
+		return 2
+	}
+	entries, err := readCaptureIndex(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(),
+		append(chromedp.DefaultExecAllocatorOptions[:], chromedp.ExecPath(chromePath))...)
+	defer cancelAlloc()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	// Captured pages must never run their scripts or reach the site.
+	err = chromedp.Run(ctx,
+		network.Enable(),
+		network.OverrideNetworkState(true, 0, 0, 0),
+		emulation.SetScriptExecutionDisabled(true),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting replay browser: %v\n", err)
+		return 2
+	}
+
+	var decisions []ReplayDecision
+	listings := make(map[string]orderListing)
+	for _, entry := range entries {
+		decision := ReplayDecision{Seq: entry.Seq, Kind: entry.Kind, URL: entry.URL}
+		doc, err := os.ReadFile(filepath.Join(dir, entry.File))
+		if err == nil {
+			err = loadCapturedPage(ctx, entry.URL, string(doc))
+		}
+		if err != nil {
+			decision.Result = []string{"error: " + err.Error()}
+		} else {
+			decision.Result = replayDecide(ctx, entry, listings)
+		}
+		decisions = append(decisions, decision)
+		fmt.Printf("#%d %s %s\n", decision.Seq, decision.Kind, decision.URL)
+		for _, line := range decision.Result {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+
+	got, err := json.MarshalIndent(decisions, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling decisions: %v\n", err)
+		return 2
+	}
+	expectedPath := filepath.Join(dir, REPLAY_EXPECTED_FILE)
+	if update {
+		if err := os.WriteFile(expectedPath, got, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", expectedPath, err)
+			return 2
+		}
+		fmt.Printf("Wrote %s\n", expectedPath)
+		return 0
+	}
+
+	want, err := os.ReadFile(expectedPath)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", expectedPath, err)
+		return 2
+	}
+	var expected []ReplayDecision
+	if err := json.Unmarshal(want, &expected); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", expectedPath, err)
+		return 2
+	}
+	mismatches := diffDecisions(expected, decisions)
+	for _, m := range mismatches {
+		fmt.Println("MISMATCH " + m)
+	}
+	if len(mismatches) > 0 {
+		return 1
+	}
+	fmt.Printf("All %d decisions match %s\n", len(decisions), REPLAY_EXPECTED_FILE)
+	return 0
+}
+
+// loadCapturedPage shows doc as the current document. A <base> tag keeps
+// links resolving against the page's original URL.
+func loadCapturedPage(ctx context.Context, url, doc string) error {
+	base := fmt.Sprintf(`<base href="%s">`, html.EscapeString(url))
+	if loc := headTagPattern.FindStringIndex(doc); loc != nil {
+		doc = doc[:loc[1]] + base + doc[loc[1]:]
+	} else {
+		doc = base + doc
+	}
+
+	ctxLoad, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return chromedp.Run(ctxLoad,
+		chromedp.Navigate("about:blank"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			tree, err := page.GetFrameTree().Do(ctx)
+			if err != nil {
+				return err
+			}
+			return page.SetDocumentContent(tree.Frame.ID, doc).Do(ctx)
+		}),
+	)
+}
+
+// replayDecide runs the same checks the live pipeline runs on a captured
+// page, as of the time it was captured. listings holds the rows of the
+// list pages replayed so far, which bid decisions need as the live
+// pipeline does.
+func replayDecide(ctx context.Context, entry CaptureEntry, listings map[string]orderListing) []string {
+	switch entry.Kind {
+	case CaptureList:
+		orders, err := parseOrderList(ctx)
+		if err != nil {
+			return []string{"error: " + err.Error()}
+		}
+		var result []string
+		for i, link := range orders.Links {
+			if _, ok := listings[link]; !ok {
+				listings[link] = orderListing{URL: link, ServiceType: orders.Services[i], Deadline: orders.Deadlines[i], DiscoveredAt: entry.CapturedAt}
+			}
+			decision := "open"
+			if reason := orderDiscardReason(orders.Services[i], orders.Deadlines[i], entry.CapturedAt); reason != "" {
+				decision = "discard: " + reason
+			}
+			result = append(result, fmt.Sprintf("%s: %s", link, decision))
+		}
+		return result
+	case CaptureOrder:
+		fixed, err := isFixedPriceOrder(ctx)
+		if err != nil {
+			return []string{"error: " + err.Error()}
+		}
+		hasCountdown, seconds := checkCountdown(ctx)
+		return []string{
+			fmt.Sprintf("fixed_price: %t", fixed),
+			fmt.Sprintf("countdown: %t (%ds)", hasCountdown, seconds),
+			fmt.Sprintf("attachments: %t", hasAttachments(ctx)),
+		}
+	case CaptureBid:
+		ctxBid, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		errText, err := pageFrom(ctx).Text(ctxBid, "#id_bid4-error")
+		if err != nil {
+			return []string{"error: " + err.Error()}
+		}
+		limits, err := readBidLimits(errText)
+		if err != nil {
+			return []string{"rejected: " + err.Error()}
+		}
+		result := []string{fmt.Sprintf("minimum_bid: %s %s", limits.Minimum.Decimal(), limits.Currency)}
+		return append(result, replayBid(ctx, entry, listings[entry.URL], limits)...)
+	}
+	return []string{fmt.Sprintf("error: unknown capture kind %q", entry.Kind)}
+}
+
+// replayBid picks the bid for limits the way placeBid does: the configured
+// markup or the decision script's amount, then the bid caps. The caps see
+// only the bids of the replay, which makes none.
+func replayBid(ctx context.Context, entry CaptureEntry, listing orderListing, limits BidMessage) []string {
+	strategy := bidStrategy(false)
+	price := defaultBidPrice
+	if cfg.Script.Enabled {
+		facts := OrderFacts{
+			URL: entry.URL, ServiceType: listing.ServiceType, Deadline: listing.Deadline,
+			Pages: orderPageCount(ctx), DiscoveredAt: listing.DiscoveredAt,
+		}
+		if deadline, err := parseDeadline(listing.Deadline, entry.CapturedAt); err == nil {
+			facts.DeadlineHours = deadline.Remaining.Hours()
+		}
+		price = scriptBidPrice(facts, entry.Thread, func(d ScriptDecision) {
+			if d.Amount > 0 {
+				strategy = "script"
+			}
+		})
+	}
+
+	bid, err := price(limits)
+	if reason, skipped := scriptSkipReason(err); skipped {
+		return []string{"skip: " + reason}
+	}
+	if err != nil {
+		return []string{"error: " + err.Error()}
+	}
+	result := []string{fmt.Sprintf("bid: %s (%s)", bid.Decimal(), strategy)}
+	if reason := bidCapReason(entry.CapturedAt, bid); reason != "" {
+		result = append(result, "cap: "+reason)
+	}
+	return result
+}
+
+// diffDecisions describes every decision that differs from the expected
+// one.
+func diffDecisions(expected, got []ReplayDecision) []string {
+	var mismatches []string
+	for i := 0; i < len(expected) || i < len(got); i++ {
+		switch {
+		case i >= len(got):
+			mismatches = append(mismatches, fmt.Sprintf("#%d: missing from replay", expected[i].Seq))
+		case i >= len(expected):
+			mismatches = append(mismatches, fmt.Sprintf("#%d: not in expected results", got[i].Seq))
+		default:
+			want, _ := json.Marshal(expected[i])
+			have, _ := json.Marshal(got[i])
+			if !bytes.Equal(want, have) {
+				mismatches = append(mismatches, fmt.Sprintf("#%d: expected %s, got %s", got[i].Seq, want, have))
+			}
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	capturedBodyPattern     = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	capturedTagPattern      = regexp.MustCompile(`<[^>]+>`)
+	capturedSpacePattern    = regexp.MustCompile(`\s+`)
+	capturedIDPattern       = regexp.MustCompile(`<(\w+)[^>]*\bid="([^"]+)"[^>]*>([^<]*)</(\w+)>`)
+	capturedRowPattern      = regexp.MustCompile(`(?s)<tr class="order_container">(.*?)</tr>`)
+	capturedLinkPattern     = regexp.MustCompile(`<td class="topictitle">\s*<a href="([^"]+)"`)
+	capturedServicePattern  = regexp.MustCompile(`<div class="service_type">([^<]*)</div>`)
+	capturedDeadlinePattern = regexp.MustCompile(`<span class="d-deadline">[^<]*</span>\s*<span class="d-left">([^<]*)</span>`)
+)
+
+func capturedText(html string) string {
+	return strings.TrimSpace(capturedSpacePattern.ReplaceAllString(capturedTagPattern.ReplaceAllString(html, " "), " "))
+}
+
+// capturedDocument stands in for the replay browser: it answers the
+// selectors the bot reads from the markup of a captured page at pageURL.
+func capturedDocument(pageURL, html string) *fakeDocument {
+	doc := newFakeDocument()
+	if m := capturedBodyPattern.FindStringSubmatch(html); m != nil {
+		doc.text("body", capturedText(m[1]))
+	}
+	for _, m := range capturedIDPattern.FindAllStringSubmatch(html, -1) {
+		if m[1] == m[4] {
+			doc.text("#"+m[2], capturedText(m[3]))
+		}
+	}
+
+	base, _ := url.Parse(pageURL)
+	var rows listedOrders
+	for _, row := range capturedRowPattern.FindAllStringSubmatch(html, -1) {
+		link, service, deadline := "", "", ""
+		if m := capturedLinkPattern.FindStringSubmatch(row[1]); m != nil {
+			if u, err := base.Parse(m[1]); err == nil {
+				link = u.String()
+			}
+		}
+		if m := capturedServicePattern.FindStringSubmatch(row[1]); m != nil {
+			service = strings.TrimSpace(m[1])
+		}
+		if m := capturedDeadlinePattern.FindStringSubmatch(row[1]); m != nil {
+			deadline = strings.TrimSpace(m[1])
+		}
+		rows.Links = append(rows.Links, link)
+		rows.Services = append(rows.Services, service)
+		rows.Deadlines = append(rows.Deadlines, deadline)
+	}
+	return doc.eval("tr.order_container", rows)
+}
+
+// TestReplayFixture replays the capture session in testdata/replay and
+// compares the decisions to its expected.json.
+func TestReplayFixture(t *testing.T) {
+	dir, err := filepath.Abs(filepath.Join("testdata", "replay"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setupOrderTest(t)
+	data, err := os.ReadFile(filepath.Join(dir, CAPTURE_CONFIG_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		t.Fatalf("config snapshot: %v", err)
+	}
+	entries, err := readCaptureIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decisions []ReplayDecision
+	listings := make(map[string]orderListing)
+	for _, entry := range entries {
+		html, err := os.ReadFile(filepath.Join(dir, entry.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		page := newFakePage(entry.URL, capturedDocument(entry.URL, string(html)))
+		decisions = append(decisions, ReplayDecision{Seq: entry.Seq, Kind: entry.Kind, URL: entry.URL,
+			Result: replayDecide(withPage(context.Background(), page), entry, listings)})
+	}
+
+	want, err := os.ReadFile(filepath.Join(dir, REPLAY_EXPECTED_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []ReplayDecision
+	if err := json.Unmarshal(want, &expected); err != nil {
+		t.Fatalf("%s: %v", REPLAY_EXPECTED_FILE, err)
+	}
+	for _, m := range diffDecisions(expected, decisions) {
+		t.Error(m)
+	}
+}
+
+func TestReplayBidScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{name: "amount", script: "function decide(order) { return {action: 'bid', amount: order.pages * 5} }",
+			want: []string{"minimum_bid: 12.00 USD", "bid: 15.00 (script)"}},
+		{name: "default", script: "function decide(order) {}",
+			want: []string{"minimum_bid: 12.00 USD", "bid: 12.00 (minimum)"}},
+		{name: "skip", script: "function decide(order) { if (order.service_type == 'Essay writing') return {action: 'skip', reason: 'no essays'} }",
+			want: []string{"minimum_bid: 12.00 USD", "skip: decision script: no essays"}},
+		{name: "over cap", script: "function decide(order) { return {action: 'bid', amount: 40} }",
+			want: []string{"minimum_bid: 12.00 USD", "bid: 40.00 (script)", "cap: bid of $40.00 would exceed the daily value cap ($0.00 of $30.00 used)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupOrderTest(t)
+			cfg.MaxBidValuePerDay = 3000
+			cfg.Script.Enabled = true
+			cfg.Script.File = filepath.Join(t.TempDir(), "decide.js")
+			if err := os.WriteFile(cfg.Script.File, []byte(tt.script), 0644); err != nil {
+				t.Fatal(err)
+			}
+			page := newFakePage(testOrderURL, bidOrderDocument("").
+				text("#id_bid4-error", "Minimum bid is $12.00"))
+			entry := CaptureEntry{Seq: 1, Kind: CaptureBid, URL: testOrderURL, CapturedAt: time.Now()}
+			listings := map[string]orderListing{testOrderURL: {URL: testOrderURL, ServiceType: "Essay writing", Deadline: "2d 4h"}}
+
+			got := replayDecide(withPage(context.Background(), page), entry, listings)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("replayDecide = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
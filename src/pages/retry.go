@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy controls how often a stage is retried and how long a worker
+// waits between attempts.
+type RetryPolicy struct {
+	MaxAttempts    int     `json:"max_attempts"`
+	InitialDelayMs int     `json:"initial_delay_ms"`
+	MaxDelayMs     int     `json:"max_delay_ms"`
+	Multiplier     float64 `json:"multiplier"`
+}
+
+var defaultRetryPolicies = map[Stage]RetryPolicy{
+	StageList:    {MaxAttempts: 3, InitialDelayMs: 2000, MaxDelayMs: 60000, Multiplier: 2},
+	StageOpen:    {MaxAttempts: 2, InitialDelayMs: 500, MaxDelayMs: 5000, Multiplier: 2},
+	StageBid:     {MaxAttempts: 2, InitialDelayMs: 300, MaxDelayMs: 2000, Multiplier: 2},
+	StageMessage: {MaxAttempts: 2, InitialDelayMs: 500, MaxDelayMs: 3000, Multiplier: 2},
+}
+
+func defaultRetryPolicyConfig() map[string]RetryPolicy {
+	policies := make(map[string]RetryPolicy, len(defaultRetryPolicies))
+	for stage, p := range defaultRetryPolicies {
+		policies[string(stage)] = p
+	}
+	return policies
+}
+
+// retryPolicyFor returns the configured policy for stage, falling back to
+// the defaults for any field left unset.
+func retryPolicyFor(stage Stage) RetryPolicy {
+	def := defaultRetryPolicies[stage]
+	p, ok := cfg.RetryPolicies[string(stage)]
+	if !ok {
+		return def
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.InitialDelayMs <= 0 {
+		p.InitialDelayMs = def.InitialDelayMs
+	}
+	if p.MaxDelayMs <= 0 {
+		p.MaxDelayMs = def.MaxDelayMs
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = def.Multiplier
+	}
+	return p
+}
+
+// Delay returns the wait before retry number attempt (1-based), with
+// +/-20% jitter so workers do not retry in lockstep.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	ms := float64(p.InitialDelayMs) * math.Pow(p.Multiplier, float64(attempt-1))
+	if ms > float64(p.MaxDelayMs) {
+		ms = float64(p.MaxDelayMs)
+	}
+	ms *= 0.8 + rand.Float64()*0.4
+	return time.Duration(ms) * time.Millisecond
+}
+
+// errMaybeSubmitted marks a failure of the click that submits a bid or an
+// application. The click may have gone through, so running the stage again
+// could submit twice.
+var errMaybeSubmitted = errors.New("submit may have gone through")
+
+// maybeSubmitted marks err as a failure of a submit click.
+func maybeSubmitted(err error) error {
+	return fmt.Errorf("%w: %w", errMaybeSubmitted, err)
+}
+
+// isRetryable reports whether retrying the same stage can help.
+func isRetryable(err error) bool {
+	if errors.Is(err, errDryRun) || errors.Is(err, errMaybeSubmitted) {
+		return false
+	}
+	class := errorClass(err)
+	return class == ErrTimeout || class == ErrSelectorMissing || class == ErrUnexpected
+}
+
+// withRetry runs fn under the retry policy of stage and reports the final
+// outcome to the circuit breaker.
+func withRetry(ctx context.Context, stage Stage, threadIndex int, fn func() error) error {
+	policy := retryPolicyFor(stage)
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			breaker.RecordSuccess(stage)
+			return nil
+		}
+		if stopRequested(ctx) {
+			return stoppedError(stage, err)
+		}
+		if !isRetryable(err) || attempt >= policy.MaxAttempts {
+			breaker.RecordFailure(err)
+			return err
+		}
+		delay := policy.Delay(attempt)
+		debugLogger.Printf("Thread %d: %s stage attempt %d/%d failed (%v), retrying in %v.",
+			threadIndex, stage, attempt, policy.MaxAttempts, err, delay)
+		if !sleepUnlessStopped(ctx, delay) {
+			return err
+		}
+	}
+}
+
+// sleepUnlessStopped waits for d and returns false early if the bot is
+// stopped or ctx is done.
+func sleepUnlessStopped(ctx context.Context, d time.Duration) bool {
+	deadline := time.Now().Add(d)
+	for {
+		beat(ctx)
+		if atomic.LoadInt32(&stopFlag) != 0 {
+			return false
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return true
+		}
+		if remaining > 250*time.Millisecond {
+			remaining = 250 * time.Millisecond
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(remaining):
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, InitialDelayMs: 100, MaxDelayMs: 1000, Multiplier: 3}
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{attempt: 0, base: 100 * time.Millisecond},
+		{attempt: 1, base: 100 * time.Millisecond},
+		{attempt: 2, base: 300 * time.Millisecond},
+		{attempt: 3, base: 900 * time.Millisecond},
+		{attempt: 4, base: 1000 * time.Millisecond}, // capped
+		{attempt: 10, base: 1000 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		low, high := tt.base*8/10, tt.base*12/10
+		for i := 0; i < 50; i++ {
+			if d := p.Delay(tt.attempt); d < low || d > high {
+				t.Fatalf("Delay(%d) = %v, want within %v..%v", tt.attempt, d, low, high)
+			}
+		}
+	}
+}
+
+func TestRetryPolicyForFillsDefaults(t *testing.T) {
+	saved := *cfg
+	defer func() { *cfg = saved }()
+	cfg.RetryPolicies = map[string]RetryPolicy{string(StageBid): {MaxAttempts: 4}}
+
+	got := retryPolicyFor(StageBid)
+	def := defaultRetryPolicies[StageBid]
+	want := RetryPolicy{MaxAttempts: 4, InitialDelayMs: def.InitialDelayMs, MaxDelayMs: def.MaxDelayMs, Multiplier: def.Multiplier}
+	if got != want {
+		t.Errorf("retryPolicyFor = %+v, want %+v", got, want)
+	}
+	if got := retryPolicyFor(StageList); got != defaultRetryPolicies[StageList] {
+		t.Errorf("unconfigured stage = %+v, want defaults", got)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	saved := *cfg
+	defer func() { *cfg = saved }()
+	breaker = newCircuitBreaker()
+	timeout := &PipelineError{Stage: StageOpen, Class: ErrTimeout, Err: errors.New("slow")}
+	gone := &PipelineError{Stage: StageOpen, Class: ErrOrderGone}
+
+	tests := []struct {
+		name      string
+		results   []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "succeeds at once", results: []error{nil}, wantCalls: 1},
+		{name: "succeeds on retry", results: []error{timeout, timeout, nil}, wantCalls: 3},
+		{name: "gives up after max attempts", results: []error{timeout, timeout, timeout, timeout}, wantCalls: 3, wantErr: ErrTimeout},
+		{name: "not retryable", results: []error{gone, nil}, wantCalls: 1, wantErr: ErrOrderGone},
+		{name: "submit may have gone through", results: []error{maybeSubmitted(timeout), nil}, wantCalls: 1, wantErr: errMaybeSubmitted},
+	}
+
+	cfg.RetryPolicies = map[string]RetryPolicy{string(StageOpen): {MaxAttempts: 3, InitialDelayMs: 20, MaxDelayMs: 1000, Multiplier: 2}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			start := time.Now()
+			err := withRetry(context.Background(), StageOpen, 0, func() error {
+				err := tt.results[calls]
+				calls++
+				return err
+			})
+			elapsed := time.Since(start)
+
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("withRetry error = %v, want %v", err, tt.wantErr)
+			}
+			// Waits of 20ms then 40ms, each within +/-20%.
+			var minWait time.Duration
+			for i := 1; i < calls; i++ {
+				minWait += time.Duration(20<<(i-1)) * time.Millisecond * 8 / 10
+			}
+			if elapsed < minWait || elapsed > minWait*2+200*time.Millisecond {
+				t.Errorf("withRetry took %v for %d calls, want about %v", elapsed, calls, minWait)
+			}
+		})
+	}
+}
+
+func TestWithRetryStopped(t *testing.T) {
+	logs := setupBreakerTest(t)
+	cfg.BreakerThreshold = 1
+
+	// Workers stopped in the middle of a stage see their page context end,
+	// which looks just like a dead browser.
+	for i := 0; i < 3; i++ {
+		workerCtx, stop := context.WithCancel(context.Background())
+		pageCtx, closePage := context.WithCancel(context.Background())
+		ctx := workerContext(pageCtx, workerCtx)
+		calls := 0
+		err := withRetry(ctx, StageList, i, func() error {
+			calls++
+			stop()
+			closePage()
+			return newPipelineError(StageList, "", ctx.Err())
+		})
+		if !errors.Is(err, ErrStopped) || errors.Is(err, ErrBrowserDead) || calls != 1 {
+			t.Errorf("worker %d: withRetry = %v after %d calls, want one call and %v", i, err, calls, ErrStopped)
+		}
+		if isRetryable(err) || wantsFailureSnapshot(err) || !isFatalForScan(err) {
+			t.Errorf("worker %d: %v handled as a failure", i, err)
+		}
+	}
+	if breaker.state != breakerClosed || strings.Contains(logs.String(), "ALERT") {
+		t.Errorf("breaker state = %d, want closed after stops", breaker.state)
+	}
+
+	// A page that ends while its worker goes on is a dead browser.
+	pageCtx, closePage := context.WithCancel(context.Background())
+	ctx := workerContext(pageCtx, context.Background())
+	closePage()
+	err := withRetry(ctx, StageList, 0, func() error { return newPipelineError(StageList, "", ctx.Err()) })
+	if !errors.Is(err, ErrBrowserDead) || breaker.state != breakerOpen {
+		t.Errorf("withRetry = %v, breaker state %d; want %v tripping the breaker", err, breaker.state, ErrBrowserDead)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+	_ "time/tzdata" // Timezones must resolve on Windows too
+)
+
+const (
+	SCHEDULE_PAUSE_KEY   = "schedule"
+	SCHEDULE_DATE_LAYOUT = "2006-01-02"
+)
+
+// scheduleCheckInterval is how often the watcher re-evaluates the
+// schedule.
+var scheduleCheckInterval = 30 * time.Second
+
+var weekdayKeys = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// TimeRange is a daily window in "HH:MM" form. An End at or before Start
+// runs past midnight into the next day; "24:00" means end of day.
+type TimeRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ScheduleException replaces the weekly ranges for one date. No ranges
+// means the bot does not work that day.
+type ScheduleException struct {
+	Date   string      `json:"date"`
+	Ranges []TimeRange `json:"ranges"`
+}
+
+// WorkSchedule limits when the bot takes work.
+type WorkSchedule struct {
+	Enabled    bool                   `json:"enabled"`
+	Timezone   string                 `json:"timezone"`
+	Weekly     map[string][]TimeRange `json:"weekly"`
+	Exceptions []ScheduleException    `json:"exceptions"`
+}
+
+// scheduleStatus is the last transition text shown on the home screen.
+var scheduleStatus atomic.Value
+
+func (s WorkSchedule) location() *time.Location {
+	if s.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		debugLogger.Printf("Unknown schedule timezone %q, using local time: %v", s.Timezone, err)
+		return time.Local
+	}
+	return loc
+}
+
+// rangesFor returns the ranges that start on the given calendar day.
+func (s WorkSchedule) rangesFor(day time.Time) []TimeRange {
+	date := day.Format(SCHEDULE_DATE_LAYOUT)
+	for _, ex := range s.Exceptions {
+		if ex.Date == date {
+			return ex.Ranges
+		}
+	}
+	return s.Weekly[weekdayKeys[day.Weekday()]]
+}
+
+// window is a concrete open interval produced by a TimeRange on a day.
+type window struct {
+	start, end time.Time
+}
+
+func parseClock(value string) (int, int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(value, "%d:%d", &h, &m); err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q: %w", value, err)
+	}
+	if h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, 0, fmt.Errorf("invalid time %q", value)
+	}
+	return h, m, nil
+}
+
+// windowsAround lists the concrete windows of the days from one day before
+// t to days after it, in the schedule's timezone.
+func (s WorkSchedule) windowsAround(t time.Time, days int) []window {
+	loc := s.location()
+	t = t.In(loc)
+	var windows []window
+	for offset := -1; offset <= days; offset++ {
+		day := time.Date(t.Year(), t.Month(), t.Day()+offset, 0, 0, 0, 0, loc)
+		for _, r := range s.rangesFor(day) {
+			sh, sm, err1 := parseClock(r.Start)
+			eh, em, err2 := parseClock(r.End)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			start := time.Date(day.Year(), day.Month(), day.Day(), sh, sm, 0, 0, loc)
+			end := time.Date(day.Year(), day.Month(), day.Day(), eh, em, 0, 0, loc)
+			if !end.After(start) {
+				end = end.AddDate(0, 0, 1)
+			}
+			windows = append(windows, window{start, end})
+		}
+	}
+	return windows
+}
+
+// IsOpen reports whether t falls inside a working window.
+func (s WorkSchedule) IsOpen(t time.Time) bool {
+	if !s.Enabled {
+		return true
+	}
+	for _, w := range s.windowsAround(t, 0) {
+		if !t.Before(w.start) && t.Before(w.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextTransition returns when the open/closed state next changes, looking
+// at most a week ahead. ok is false if it never changes in that time.
+func (s WorkSchedule) NextTransition(t time.Time) (at time.Time, opens bool, ok bool) {
+	if !s.Enabled {
+		return time.Time{}, false, false
+	}
+	var candidates []time.Time
+	for _, w := range s.windowsAround(t, 8) {
+		for _, c := range []time.Time{w.start, w.end} {
+			if c.After(t) {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	current := s.IsOpen(t)
+	for _, c := range candidates {
+		if s.IsOpen(c) != current {
+			return c, !current, true
+		}
+	}
+	return time.Time{}, false, false
+}
+
+// Validate checks every range and exception date.
+func (s WorkSchedule) Validate() error {
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", s.Timezone)
+		}
+	}
+	check := func(where string, ranges []TimeRange) error {
+		for _, r := range ranges {
+			if _, _, err := parseClock(r.Start); err != nil {
+				return fmt.Errorf("%s: %w", where, err)
+			}
+			if _, _, err := parseClock(r.End); err != nil {
+				return fmt.Errorf("%s: %w", where, err)
+			}
+		}
+		return nil
+	}
+	for day, ranges := range s.Weekly {
+		if !isWeekdayKey(day) {
+			return fmt.Errorf("unknown weekday %q", day)
+		}
+		if err := check(day, ranges); err != nil {
+			return err
+		}
+	}
+	for _, ex := range s.Exceptions {
+		if _, err := time.Parse(SCHEDULE_DATE_LAYOUT, ex.Date); err != nil {
+			return fmt.Errorf("invalid exception date %q", ex.Date)
+		}
+		if err := check(ex.Date, ex.Ranges); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isWeekdayKey(key string) bool {
+	for _, k := range weekdayKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// formatScheduleText renders the weekly ranges and exceptions in the
+// line-based form edited on the settings screen, e.g.
+//
+//	monday: 09:00-13:00, 14:00-18:00
+//	2026-12-25: closed
+func formatScheduleText(s WorkSchedule) string {
+	var lines []string
+	for i := 1; i <= len(weekdayKeys); i++ {
+		day := weekdayKeys[i%len(weekdayKeys)] // Monday first
+		if ranges, ok := s.Weekly[day]; ok {
+			lines = append(lines, day+": "+formatRanges(ranges))
+		}
+	}
+	for _, ex := range s.Exceptions {
+		lines = append(lines, ex.Date+": "+formatRanges(ex.Ranges))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatRanges(ranges []TimeRange) string {
+	if len(ranges) == 0 {
+		return "closed"
+	}
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.Start + "-" + r.End
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseScheduleText is the inverse of formatScheduleText. It only fills
+// Weekly and Exceptions.
+func parseScheduleText(text string) (WorkSchedule, error) {
+	s := WorkSchedule{Weekly: make(map[string][]TimeRange)}
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return s, fmt.Errorf("line %d: expected \"day: ranges\"", n+1)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		var ranges []TimeRange
+		if !strings.EqualFold(value, "closed") {
+			for _, part := range strings.Split(value, ",") {
+				start, end, ok := strings.Cut(strings.TrimSpace(part), "-")
+				if !ok {
+					return s, fmt.Errorf("line %d: expected HH:MM-HH:MM, got %q", n+1, part)
+				}
+				ranges = append(ranges, TimeRange{Start: strings.TrimSpace(start), End: strings.TrimSpace(end)})
+			}
+		}
+		if isWeekdayKey(key) {
+			s.Weekly[key] = ranges
+		} else {
+			s.Exceptions = append(s.Exceptions, ScheduleException{Date: key, Ranges: ranges})
+		}
+	}
+	return s, s.Validate()
+}
+
+// applySchedule pauses or resumes the bot for the current time and updates
+// the transition shown on the home screen.
+func applySchedule(now time.Time) {
+	s := cfg.Schedule
+	if !s.Enabled {
+		scheduleStatus.Store("")
+		resumeBot(SCHEDULE_PAUSE_KEY)
+		return
+	}
+
+	next := "no change within a week"
+	if at, opens, ok := s.NextTransition(now); ok {
+		verb := "closes"
+		if opens {
+			verb = "opens"
+		}
+		next = fmt.Sprintf("%s %s", verb, at.Format("Mon Jan 2 15:04 MST"))
+	}
+	scheduleStatus.Store("Schedule: " + next)
+
+	if s.IsOpen(now) {
+		resumeBot(SCHEDULE_PAUSE_KEY)
+	} else {
+		pauseBot(SCHEDULE_PAUSE_KEY, "outside working hours")
+	}
+	refreshStatus()
+}
+
+// runScheduleWatcher re-evaluates the schedule until ctx, the context of
+// one run of the bot, ends.
+func runScheduleWatcher(ctx context.Context) {
+	applySchedule(time.Now())
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+	for atomic.LoadInt32(&stopFlag) == 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			applySchedule(now)
+		}
+	}
+}
+
+func scheduleStatusText() string {
+	text, _ := scheduleStatus.Load().(string)
+	return text
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testSchedule works Monday days, Friday nights into Saturday and Sunday
+// evenings to midnight, with a few dates changed.
+var testSchedule = WorkSchedule{
+	Enabled:  true,
+	Timezone: "UTC",
+	Weekly: map[string][]TimeRange{
+		"monday": {{Start: "09:00", End: "17:00"}},
+		"friday": {{Start: "22:00", End: "02:00"}},
+		"sunday": {{Start: "20:00", End: "24:00"}},
+	},
+	Exceptions: []ScheduleException{
+		{Date: "2026-03-09"}, // Monday, closed
+		{Date: "2026-03-10", Ranges: []TimeRange{{Start: "10:00", End: "12:00"}}}, // Tuesday
+		{Date: "2026-03-12", Ranges: []TimeRange{{Start: "23:00", End: "01:00"}}}, // Thursday night
+		{Date: "2026-03-14"}, // Saturday, closed
+	},
+}
+
+// scheduleTime is the given day of March 2026 at the given clock, UTC.
+// March 2 is a Monday.
+func scheduleTime(day int, clock string) time.Time {
+	h, m, _ := parseClock(clock)
+	return time.Date(2026, 3, day, h, m, 0, 0, time.UTC)
+}
+
+func TestScheduleIsOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		day   int
+		clock string
+		want  bool
+	}{
+		{"monday start", 2, "09:00", true},
+		{"monday before end", 2, "16:59", true},
+		{"monday end", 2, "17:00", false},
+		{"friday before night", 6, "21:59", false},
+		{"friday night", 6, "22:00", true},
+		{"past midnight", 7, "01:59", true},
+		{"night end", 7, "02:00", false},
+		{"saturday", 7, "12:00", false},
+		{"end of day", 8, "23:59", true},
+		{"after end of day", 9, "00:00", false},
+		{"closed date", 9, "12:00", false},
+		{"extra date", 10, "11:00", true},
+		{"weekday without the extra date", 3, "11:00", false},
+		{"date past midnight", 13, "00:30", true},
+		{"night into a closed date", 14, "01:00", true},
+		{"closed date after the night", 14, "02:00", false},
+	}
+	for _, tt := range tests {
+		if got := testSchedule.IsOpen(scheduleTime(tt.day, tt.clock)); got != tt.want {
+			t.Errorf("%s: IsOpen(March %d %s) = %t, want %t", tt.name, tt.day, tt.clock, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNextTransition(t *testing.T) {
+	tests := []struct {
+		name      string
+		day       int
+		clock     string
+		wantDay   int
+		wantClock string
+		wantOpens bool
+	}{
+		{"opens friday night", 6, "12:00", 6, "22:00", true},
+		{"closes past midnight", 6, "23:00", 7, "02:00", false},
+		{"closes at end of day", 8, "21:00", 9, "00:00", false},
+		{"skips the closed date", 9, "00:00", 10, "10:00", true},
+		{"extra date past midnight", 12, "12:00", 12, "23:00", true},
+		{"closes the next day", 12, "23:30", 13, "01:00", false},
+	}
+	for _, tt := range tests {
+		at, opens, ok := testSchedule.NextTransition(scheduleTime(tt.day, tt.clock))
+		want := scheduleTime(tt.wantDay, tt.wantClock)
+		if !ok || !at.Equal(want) || opens != tt.wantOpens {
+			t.Errorf("%s: NextTransition = %s, opens %t, ok %t; want %s, opens %t",
+				tt.name, at, opens, ok, want, tt.wantOpens)
+		}
+	}
+}
+
+func TestScheduleTimezone(t *testing.T) {
+	s := WorkSchedule{
+		Enabled:  true,
+		Timezone: "Asia/Tokyo",
+		Weekly:   map[string][]TimeRange{"tuesday": {{Start: "08:00", End: "10:00"}}},
+	}
+	// 08:30 on Tuesday in Tokyo is 23:30 on Monday in UTC.
+	if !s.IsOpen(scheduleTime(2, "23:30")) {
+		t.Error("closed at 08:30 Tokyo time")
+	}
+	if s.IsOpen(scheduleTime(3, "08:30")) {
+		t.Error("open at 08:30 UTC")
+	}
+}
+
+func TestScheduleDisabled(t *testing.T) {
+	s := testSchedule
+	s.Enabled = false
+	if !s.IsOpen(scheduleTime(9, "12:00")) {
+		t.Error("disabled schedule is closed")
+	}
+	if _, _, ok := s.NextTransition(scheduleTime(9, "12:00")); ok {
+		t.Error("disabled schedule has a transition")
+	}
+}
+
+func TestScheduleWatcherAfterRestart(t *testing.T) {
+	setupOrderTest(t)
+	saved := scheduleCheckInterval
+	scheduleCheckInterval = 10 * time.Millisecond
+	t.Cleanup(func() {
+		botCancel()
+		scheduleCheckInterval = saved
+		clearPauses()
+	})
+	always := make(map[string][]TimeRange)
+	for _, day := range weekdayKeys {
+		always[day] = []TimeRange{{Start: "00:00", End: "24:00"}}
+	}
+	cfg.Schedule = WorkSchedule{Enabled: true, Timezone: "UTC", Weekly: always}
+	waitPaused := func(want bool) {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); isPaused() != want; {
+			if time.Now().After(deadline) {
+				t.Fatalf("paused = %t, want %t", !want, want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// A first run, stopped the way stopBot stops it.
+	beginRun(context.Background())
+	waitPaused(false)
+	atomic.StoreInt32(&stopFlag, 1)
+	botCancel()
+	clearPauses()
+	atomic.StoreInt32(&stopFlag, 0)
+
+	// The second run's watcher still follows the schedule.
+	beginRun(context.Background())
+	cfg.Schedule = WorkSchedule{Enabled: true, Timezone: "UTC"}
+	waitPaused(true)
+	cfg.Schedule.Weekly = always
+	waitPaused(false)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	stdLog "log"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_SCAN_INTERVAL_MS       = 3000
+	DEFAULT_MIN_SCAN_INTERVAL_MS   = 1000
+	DEFAULT_MAX_SCAN_INTERVAL_MS   = 30000
+	DEFAULT_MAX_PAGE_LOADS_PER_MIN = 60
+	SCAN_EMPTY_BACKOFF_FACTOR      = 1.5
+	SEEN_ORDER_RETENTION           = time.Hour
+
+	// SCAN_WAIT_RECHECK is how often a worker waiting for its scan slot
+	// looks at the interval again, so a shorter one takes effect at once.
+	SCAN_WAIT_RECHECK = 250 * time.Millisecond
+)
+
+// ScanScheduler paces all workers together. Scans of the orders list are
+// handed out one interval apart, to whichever worker asks first, and every
+// page load counts against a per-minute budget shared by all workers. The
+// interval shrinks to the minimum when new orders show up and grows
+// towards the maximum while the list stays empty.
+type ScanScheduler struct {
+	mu       sync.Mutex
+	interval time.Duration
+	lastScan time.Time // start of the last scan handed out
+	loads    []time.Time
+	seen     map[string]time.Time // last time each order was listed
+	first    map[string]time.Time // first time each order was listed
+	primed   bool
+}
+
+var scheduler = newScanScheduler()
+
+func newScanScheduler() *ScanScheduler {
+	return &ScanScheduler{seen: make(map[string]time.Time), first: make(map[string]time.Time)}
+}
+
+// Reset forgets the adaptive state, e.g. when the bot is started again.
+func (s *ScanScheduler) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = baseScanInterval()
+	s.lastScan = time.Time{}
+	s.loads = nil
+	s.seen = make(map[string]time.Time)
+	s.first = make(map[string]time.Time)
+	s.primed = false
+}
+
+// WaitForScan sleeps until the next scan is due and claims it. The slot is
+// worked out again every SCAN_WAIT_RECHECK, so when ReportScan shortens the
+// interval the waiting workers move up instead of sleeping out the old
+// one. It returns false if the bot was stopped while waiting.
+func (s *ScanScheduler) WaitForScan(ctx context.Context) bool {
+	for {
+		s.mu.Lock()
+		if s.interval == 0 {
+			s.interval = baseScanInterval()
+		}
+		now := time.Now()
+		wait := s.lastScan.Add(s.interval).Sub(now)
+		if wait <= 0 {
+			s.lastScan = now
+			s.mu.Unlock()
+			return true
+		}
+		s.mu.Unlock()
+
+		if wait > SCAN_WAIT_RECHECK {
+			wait = SCAN_WAIT_RECHECK
+		}
+		if !sleepUnlessStopped(ctx, wait) {
+			return false
+		}
+	}
+}
+
+// AcquirePageLoad blocks until a page load fits in the per-minute budget
+// and records it. It returns false if the bot was stopped while waiting.
+func (s *ScanScheduler) AcquirePageLoad(ctx context.Context) bool {
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		cutoff := now.Add(-time.Minute)
+		kept := s.loads[:0]
+		for _, t := range s.loads {
+			if t.After(cutoff) {
+				kept = append(kept, t)
+			}
+		}
+		s.loads = kept
+
+		limit := maxPageLoadsPerMinute()
+		if len(s.loads) < limit {
+			s.loads = append(s.loads, now)
+			s.mu.Unlock()
+			return true
+		}
+		wait := s.loads[0].Add(time.Minute).Sub(now)
+		s.mu.Unlock()
+
+		debugLogger.Printf("Page load budget of %d/min reached, waiting %v.", limit, wait)
+		if !sleepUnlessStopped(ctx, wait) {
+			return false
+		}
+	}
+}
+
+// ReportScan adapts the scan interval to what the last scan found and
+// returns the links not seen in the last SEEN_ORDER_RETENTION.
+func (s *ScanScheduler) ReportScan(orderLinks []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var fresh []string
+	for _, link := range orderLinks {
+		if link == "" {
+			continue
+		}
+		if _, ok := s.seen[link]; !ok {
+			fresh = append(fresh, link)
+			s.first[link] = now
+		}
+		s.seen[link] = now
+	}
+	newOrders := len(fresh)
+	for link, t := range s.seen {
+		if now.Sub(t) > SEEN_ORDER_RETENTION {
+			delete(s.seen, link)
+			delete(s.first, link)
+		}
+	}
+
+	old := s.interval
+	switch {
+	case len(orderLinks) == 0:
+		next := time.Duration(float64(s.interval) * SCAN_EMPTY_BACKOFF_FACTOR)
+		if next > maxScanInterval() {
+			next = maxScanInterval()
+		}
+		s.interval = next
+	case newOrders > 0 && s.primed:
+		s.interval = minScanInterval()
+	default:
+		s.interval = baseScanInterval()
+	}
+	s.primed = true
+
+	if s.interval != old {
+		debugLogger.Printf("Scan interval %v -> %v (%d orders listed, %d new).", old, s.interval, len(orderLinks), newOrders)
+		if newOrders > 0 && s.interval == minScanInterval() {
+			stdLog.Printf("New orders listed, scanning every %v.", s.interval)
+		}
+	}
+	return fresh
+}
+
+// DiscoveredAt returns when link was first listed, or the zero time if it
+// is not known.
+func (s *ScanScheduler) DiscoveredAt(link string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.first[link]
+}
+
+func baseScanInterval() time.Duration {
+	if cfg.ScanIntervalMs <= 0 {
+		return DEFAULT_SCAN_INTERVAL_MS * time.Millisecond
+	}
+	return time.Duration(cfg.ScanIntervalMs) * time.Millisecond
+}
+
+func minScanInterval() time.Duration {
+	lo := time.Duration(cfg.MinScanIntervalMs) * time.Millisecond
+	if lo <= 0 {
+		lo = DEFAULT_MIN_SCAN_INTERVAL_MS * time.Millisecond
+	}
+	if base := baseScanInterval(); lo > base {
+		lo = base
+	}
+	return lo
+}
+
+func maxScanInterval() time.Duration {
+	hi := time.Duration(cfg.MaxScanIntervalMs) * time.Millisecond
+	if hi <= 0 {
+		hi = DEFAULT_MAX_SCAN_INTERVAL_MS * time.Millisecond
+	}
+	if base := baseScanInterval(); hi < base {
+		hi = base
+	}
+	return hi
+}
+
+func maxPageLoadsPerMinute() int {
+	if cfg.MaxPageLoadsPerMinute <= 0 {
+		return DEFAULT_MAX_PAGE_LOADS_PER_MIN
+	}
+	return cfg.MaxPageLoadsPerMinute
+}
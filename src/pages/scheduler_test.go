@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitForScanSpacesScans(t *testing.T) {
+	setupOrderTest(t)
+	cfg.ScanIntervalMs = 300
+	s := newScanScheduler()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if !s.WaitForScan(context.Background()) {
+			t.Fatal("WaitForScan returned false")
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 600*time.Millisecond {
+		t.Errorf("three scans took %s, want them 300ms apart", elapsed)
+	}
+}
+
+func TestWaitForScanFollowsShorterInterval(t *testing.T) {
+	setupOrderTest(t)
+	cfg.ScanIntervalMs = 3000
+	cfg.MinScanIntervalMs = 100
+	cfg.MaxScanIntervalMs = 30000
+	s := newScanScheduler()
+
+	// An empty list backs the interval off; the next scan is far away.
+	s.WaitForScan(context.Background())
+	s.ReportScan(nil)
+
+	done := make(chan time.Time)
+	go func() {
+		s.WaitForScan(context.Background())
+		done <- time.Now()
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// New orders drop the interval to the minimum, which has already passed.
+	s.ReportScan([]string{testOrderURL})
+	reported := time.Now()
+	select {
+	case at := <-done:
+		if late := at.Sub(reported); late > time.Second {
+			t.Errorf("scan started %s after the interval dropped", late)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("waiting worker kept sleeping out the old interval")
+	}
+}
+
+func TestWaitForScanStops(t *testing.T) {
+	setupOrderTest(t)
+	cfg.ScanIntervalMs = 10000
+	s := newScanScheduler()
+	s.WaitForScan(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if s.WaitForScan(ctx) {
+		t.Error("WaitForScan returned true after its context ended")
+	}
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	stdLog "log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dop251/goja"
+)
+
+const (
+	DEFAULT_SCRIPT_FILE       = "decide.js"
+	DEFAULT_SCRIPT_TIMEOUT_MS = 200
+	SCRIPT_MAX_CALL_STACK     = 256
+)
+
+// Decision script actions.
+const (
+	ScriptSkip  = "skip"
+	ScriptBid   = "bid"
+	ScriptApply = "apply"
+)
+
+// ScriptConfig enables the decision script. File is read from the config
+// directory unless it is an absolute path.
+type ScriptConfig struct {
+	Enabled   bool   `json:"enabled"`
+	File      string `json:"file"`
+	TimeoutMs int    `json:"timeout_ms"`
+}
+
+// ORDER_SCRIPT_EXAMPLE is written by the Script screen when the script file
+// does not exist yet.
+const ORDER_SCRIPT_EXAMPLE = `// decide is called for every order that passes the filters in Settings.
+// Amounts are in dollars; minimum_bid and maximum_bid are 0 for
+// fixed-price orders and when the site shows no limit.
+//
+// order: url, id, service_type, deadline, deadline_hours, pages,
+//        fixed_price, minimum_bid, maximum_bid, discovered_at, now
+//
+// Return {action: "skip" | "bid" | "apply", amount, message, reason}, or
+// nothing to bid or apply with the settings. "bid" is for bid orders and
+// "apply" for fixed-price ones; amount goes only with "bid". The script has no file or
+// network access; console.log writes to the debug log.
+function decide(order) {
+	if (order.pages > 30) {
+		return {action: "skip", reason: "too long"};
+	}
+	if (!order.fixed_price && order.deadline_hours < 24) {
+		return {action: "bid", amount: order.minimum_bid * 1.15};
+	}
+}
+`
+
+// OrderFacts is what the decision script is told about an order.
+type OrderFacts struct {
+	URL           string
+	ServiceType   string
+	Deadline      string
+	DeadlineHours float64
+	Pages         int
+	FixedPrice    bool
+	MinimumBid    Money
+	MaximumBid    Money
+	DiscoveredAt  time.Time
+}
+
+// ScriptDecision is the validated result of the decision script. A zero
+// Amount or empty Message means the configured one.
+type ScriptDecision struct {
+	Action  string
+	Amount  Money
+	Message string
+	Reason  string
+}
+
+var (
+	// ErrScriptSkip is not a failure: the decision script ruled the order
+	// out.
+	ErrScriptSkip = errors.New("skipped by decision script")
+
+	// ErrScriptFailed means the decision script could not judge the order:
+	// it is missing, does not compile, threw, timed out or returned
+	// something invalid. The fault is in the script, not the order or the
+	// site.
+	ErrScriptFailed = errors.New("decision script failed")
+
+	errScriptMissing = errors.New("script defines no decide(order) function")
+)
+
+// scriptCache keeps the compiled script until the file changes.
+var scriptCache struct {
+	sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	program *goja.Program
+}
+
+// scriptState is the last error of the decision script, for the GUI.
+var scriptState struct {
+	sync.Mutex
+	decisions int
+	lastError string
+	errorAt   time.Time
+}
+
+// scriptSkipped remembers the orders the script skipped so they are not
+// opened again on every scan.
+var scriptSkipped = struct {
+	sync.Mutex
+	at map[string]time.Time
+}{at: make(map[string]time.Time)}
+
+func getScriptPath() string {
+	file := cfg.Script.File
+	if file == "" {
+		file = DEFAULT_SCRIPT_FILE
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(getConfigDir(), file)
+}
+
+func scriptTimeout() time.Duration {
+	if cfg.Script.TimeoutMs <= 0 {
+		return DEFAULT_SCRIPT_TIMEOUT_MS * time.Millisecond
+	}
+	return time.Duration(cfg.Script.TimeoutMs) * time.Millisecond
+}
+
+// compiledScript returns the compiled decision script, compiling it again
+// if the file changed since the last call.
+func compiledScript() (*goja.Program, error) {
+	path := getScriptPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading script: %w", err)
+	}
+
+	scriptCache.Lock()
+	defer scriptCache.Unlock()
+	if scriptCache.program != nil && scriptCache.path == path &&
+		scriptCache.modTime.Equal(info.ModTime()) && scriptCache.size == info.Size() {
+		return scriptCache.program, nil
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading script: %w", err)
+	}
+	program, err := goja.Compile(filepath.Base(path), string(src), false)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling script: %w", err)
+	}
+	scriptCache.path, scriptCache.modTime, scriptCache.size, scriptCache.program = path, info.ModTime(), info.Size(), program
+	return program, nil
+}
+
+// runScript calls decide(order) in a fresh runtime, so nothing carries over
+// between orders. The runtime has only the JavaScript built-ins and
+// console.log, and is interrupted once timeout has passed.
+func runScript(program *goja.Program, facts OrderFacts, timeout time.Duration) (ScriptDecision, error) {
+	vm := goja.New()
+	vm.SetMaxCallStackSize(SCRIPT_MAX_CALL_STACK)
+	console := vm.NewObject()
+	console.Set("log", func(call goja.FunctionCall) goja.Value {
+		var parts []string
+		for _, arg := range call.Arguments {
+			parts = append(parts, arg.String())
+		}
+		debugLogger.Printf("Script: %s", strings.Join(parts, " "))
+		return goja.Undefined()
+	})
+	vm.Set("console", console)
+
+	timer := time.AfterFunc(timeout, func() {
+		vm.Interrupt(fmt.Sprintf("time limit of %v exceeded", timeout))
+	})
+	defer timer.Stop()
+
+	if _, err := vm.RunProgram(program); err != nil {
+		return ScriptDecision{}, fmt.Errorf("error running script: %w", err)
+	}
+	decide, ok := goja.AssertFunction(vm.Get("decide"))
+	if !ok {
+		return ScriptDecision{}, errScriptMissing
+	}
+	result, err := decide(goja.Undefined(), vm.ToValue(facts.scriptObject()))
+	var overflow *goja.StackOverflowError
+	if errors.As(err, &overflow) {
+		return ScriptDecision{}, fmt.Errorf("error in decide: call depth over %d", SCRIPT_MAX_CALL_STACK)
+	}
+	if err != nil {
+		return ScriptDecision{}, fmt.Errorf("error in decide: %w", err)
+	}
+	return parseScriptResult(result.Export(), facts)
+}
+
+func (f OrderFacts) scriptObject() map[string]interface{} {
+	dollars := func(m Money) float64 { return float64(m) / 100 }
+	discovered := ""
+	if !f.DiscoveredAt.IsZero() {
+		discovered = f.DiscoveredAt.Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"url":            f.URL,
+		"id":             orderID(f.URL),
+		"service_type":   f.ServiceType,
+		"deadline":       f.Deadline,
+		"deadline_hours": f.DeadlineHours,
+		"pages":          f.Pages,
+		"fixed_price":    f.FixedPrice,
+		"minimum_bid":    dollars(f.MinimumBid),
+		"maximum_bid":    dollars(f.MaximumBid),
+		"discovered_at":  discovered,
+		"now":            time.Now().Format(time.RFC3339),
+	}
+}
+
+// parseScriptResult checks what decide returned. Nothing means the default
+// for the order. An action that does not fit the order, "bid" on a
+// fixed-price order or "apply" on a bid order, is an error rather than
+// being quietly turned into the other, and so is an amount with anything
+// but "bid".
+func parseScriptResult(value interface{}, facts OrderFacts) (ScriptDecision, error) {
+	d := ScriptDecision{Action: ScriptBid}
+	if facts.FixedPrice {
+		d.Action = ScriptApply
+	}
+	if value == nil {
+		return d, nil
+	}
+	result, ok := value.(map[string]interface{})
+	if !ok {
+		return d, fmt.Errorf("decide returned %T, expected an object", value)
+	}
+
+	if action, ok := result["action"]; ok && action != nil {
+		d.Action = strings.ToLower(fmt.Sprint(action))
+	}
+	switch d.Action {
+	case ScriptSkip, ScriptBid, ScriptApply:
+	default:
+		return d, fmt.Errorf("unknown action %q, expected skip, bid or apply", d.Action)
+	}
+	if d.Action == ScriptBid && facts.FixedPrice {
+		return d, errors.New(`action "bid" on a fixed-price order, expected "apply"`)
+	}
+	if d.Action == ScriptApply && !facts.FixedPrice {
+		return d, errors.New(`action "apply" on a bid order, expected "bid"`)
+	}
+	if reason, ok := result["reason"]; ok && reason != nil {
+		d.Reason = fmt.Sprint(reason)
+	}
+	if message, ok := result["message"]; ok && message != nil {
+		d.Message = fmt.Sprint(message)
+	}
+
+	if amount, ok := result["amount"]; ok && amount != nil {
+		if d.Action != ScriptBid {
+			return d, fmt.Errorf("amount given with action %q, only \"bid\" takes one", d.Action)
+		}
+		var text string
+		switch v := amount.(type) {
+		case int64:
+			text = strconv.FormatInt(v, 10)
+		case float64:
+			text = strconv.FormatFloat(v, 'f', -1, 64)
+		case string:
+			text = strings.TrimPrefix(strings.TrimSpace(v), "$")
+		default:
+			return d, fmt.Errorf("amount is %T, expected a number", amount)
+		}
+		// Fractions of a cent round up so a computed minimum stays valid.
+		bid, err := parseMoneyAmount(text, RoundUp)
+		if err != nil {
+			return d, fmt.Errorf("invalid amount %q: %w", text, err)
+		}
+		if bid < facts.MinimumBid {
+			return d, fmt.Errorf("amount %s is below the minimum bid %s", bid, facts.MinimumBid)
+		}
+		if facts.MaximumBid > 0 && bid > facts.MaximumBid {
+			return d, fmt.Errorf("amount %s is above the maximum bid %s", bid, facts.MaximumBid)
+		}
+		d.Amount = bid
+	}
+	return d, nil
+}
+
+// decideWithScript runs the decision script for an order. A skip comes
+// back as an ErrScriptSkip PipelineError and a script error as an
+// ErrScriptFailed one: the order is left alone this time but not
+// remembered as skipped, so it is judged again once the script is fixed.
+func decideWithScript(facts OrderFacts, threadIndex int) (ScriptDecision, error) {
+	program, err := compiledScript()
+	var d ScriptDecision
+	if err == nil {
+		d, err = runScript(program, facts, scriptTimeout())
+	}
+	if err != nil {
+		reportScriptError(facts.URL, err)
+		return d, &PipelineError{Stage: StageBid, Class: ErrScriptFailed, Err: fmt.Errorf("script error: %w", err)}
+	}
+	scriptSucceeded()
+
+	debugLogger.Printf("Thread %d: Script decision for %s: %s %s %q", threadIndex, facts.URL, d.Action, d.Amount, d.Reason)
+	if d.Action == ScriptSkip {
+		reason := d.Reason
+		if reason == "" {
+			reason = "no reason given"
+		}
+		return d, &PipelineError{Stage: StageBid, Class: ErrScriptSkip, Err: errors.New(reason)}
+	}
+	return d, nil
+}
+
+// scriptBidPrice bids the amount the decision script picks once the bid
+// form has shown the limits, or the configured amount if it picks none.
+// use sees every decision the script makes.
+func scriptBidPrice(facts OrderFacts, threadIndex int, use func(ScriptDecision)) func(BidMessage) (Money, error) {
+	return func(limits BidMessage) (Money, error) {
+		facts.MinimumBid, facts.MaximumBid = limits.Minimum, limits.Maximum
+		d, err := decideWithScript(facts, threadIndex)
+		if err != nil {
+			return 0, err
+		}
+		use(d)
+		if d.Amount > 0 {
+			return d.Amount, nil
+		}
+		return bidAmount(limits), nil
+	}
+}
+
+// scriptSkipReason returns why the script skipped an order, if err is such
+// a skip.
+func scriptSkipReason(err error) (string, bool) {
+	var pe *PipelineError
+	if !errors.As(err, &pe) || pe.Class != ErrScriptSkip {
+		return "", false
+	}
+	return "decision script: " + pe.Err.Error(), true
+}
+
+func rememberScriptSkip(orderUrl string) {
+	scriptSkipped.Lock()
+	defer scriptSkipped.Unlock()
+	now := time.Now()
+	scriptSkipped.at[orderUrl] = now
+	for url, t := range scriptSkipped.at {
+		if now.Sub(t) > SEEN_ORDER_RETENTION {
+			delete(scriptSkipped.at, url)
+		}
+	}
+}
+
+func skippedByScript(orderUrl string) bool {
+	scriptSkipped.Lock()
+	defer scriptSkipped.Unlock()
+	t, ok := scriptSkipped.at[orderUrl]
+	return ok && time.Since(t) <= SEEN_ORDER_RETENTION
+}
+
+// reportScriptError logs a script error and alerts once per distinct error,
+// so a broken script does not raise a dialog for every order.
+func reportScriptError(orderUrl string, err error) {
+	stdLog.Printf("Decision script error on %s: %v", orderUrl, err)
+	debugLogger.Printf("Script error on %s: %v", orderUrl, err)
+
+	scriptState.Lock()
+	repeated := scriptState.lastError == err.Error()
+	scriptState.lastError = err.Error()
+	scriptState.errorAt = time.Now()
+	scriptState.Unlock()
+
+	if !repeated {
+		raiseAlert(EventScriptError, "Decision Script Error", fmt.Sprintf("%v\n\nOrders are skipped until the script is fixed.", err))
+	}
+}
+
+func scriptSucceeded() {
+	scriptState.Lock()
+	scriptState.decisions++
+	scriptState.lastError = ""
+	scriptState.Unlock()
+}
+
+func scriptStatusText() string {
+	scriptState.Lock()
+	defer scriptState.Unlock()
+	if scriptState.lastError != "" {
+		return fmt.Sprintf("Last error (%s): %s", scriptState.errorAt.Format("15:04:05"), scriptState.lastError)
+	}
+	return fmt.Sprintf("No errors, %d decisions since start.", scriptState.decisions)
+}
+
+// scriptSettingsContent builds the Script screen.
+func scriptSettingsContent(w fyne.Window) fyne.CanvasObject {
+	enabledCheck := widget.NewCheck("Use Decision Script", func(v bool) {})
+	enabledCheck.SetChecked(cfg.Script.Enabled)
+	fileEntry := widget.NewEntry()
+	fileEntry.SetPlaceHolder(DEFAULT_SCRIPT_FILE)
+	fileEntry.SetText(cfg.Script.File)
+	timeoutEntry := widget.NewEntry()
+	timeoutEntry.SetText(strconv.Itoa(cfg.Script.TimeoutMs))
+	pathLabel := widget.NewLabel("")
+	scriptStatusLabel := widget.NewLabel(scriptStatusText())
+	scriptStatusLabel.Wrapping = fyne.TextWrapWord
+
+	read := func() (ScriptConfig, error) {
+		timeout, err := strconv.Atoi(timeoutEntry.Text)
+		if err != nil || timeout <= 0 {
+			return cfg.Script, fmt.Errorf("invalid time limit")
+		}
+		return ScriptConfig{Enabled: enabledCheck.Checked, File: strings.TrimSpace(fileEntry.Text), TimeoutMs: timeout}, nil
+	}
+	showPath := func() { pathLabel.SetText("Script file: " + getScriptPath()) }
+	showPath()
+
+	saveButton := widget.NewButton("Save Script Settings", func() {
+		sc, err := read()
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		cfg.Script = sc
+		saveConfig()
+		showPath()
+		dialog.ShowInformation("Settings Saved", "Your script settings have been saved.", w)
+	})
+
+	exampleButton := widget.NewButton("Create Example Script", func() {
+		path := getScriptPath()
+		if _, err := os.Stat(path); err == nil {
+			dialog.ShowInformation("Script Exists", path+" already exists and was left unchanged.", w)
+			return
+		}
+		if err := os.WriteFile(path, []byte(ORDER_SCRIPT_EXAMPLE), 0644); err != nil {
+			dialog.ShowError(fmt.Errorf("error writing example script: %w", err), w)
+			return
+		}
+		dialog.ShowInformation("Script Created", "An example script was written to "+path+".", w)
+	})
+
+	// The test runs the saved script file on a sample order, with the time
+	// limit from the form.
+	testButton := widget.NewButton("Test Script", func() {
+		sc, err := read()
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		sample := OrderFacts{
+			URL: "https://essayshark.com/writer/orders/123456789.html", ServiceType: "Writing help or assignments",
+			Deadline: "2d 4h", DeadlineHours: 52, Pages: 3, MinimumBid: 1200, MaximumBid: 5000, DiscoveredAt: time.Now(),
+		}
+		program, err := compiledScript()
+		var d ScriptDecision
+		if err == nil {
+			d, err = runScript(program, sample, time.Duration(sc.TimeoutMs)*time.Millisecond)
+		}
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		amount := "the configured amount"
+		if d.Amount > 0 {
+			amount = d.Amount.String()
+		}
+		dialog.ShowInformation("Script Decision", fmt.Sprintf(
+			"Sample order: 3 pages, 52h deadline, minimum bid $12.00.\n\nAction: %s\nAmount: %s\nMessage: %q\nReason: %q",
+			d.Action, amount, d.Message, d.Reason), w)
+	})
+
+	refreshButton := widget.NewButton("Refresh Status", func() {
+		scriptStatusLabel.SetText(scriptStatusText())
+	})
+
+	return container.NewVBox(
+		enabledCheck,
+		widget.NewLabel("Script File (in the config folder):"), fileEntry,
+		pathLabel,
+		widget.NewLabel("Time Limit per Order (ms):"), timeoutEntry,
+		widget.NewLabel("If the script fails or runs too long, the order is skipped."),
+		container.NewHBox(saveButton, exampleButton, testButton),
+		widget.NewLabel("Script Status:"), scriptStatusLabel, refreshButton,
+	)
+}
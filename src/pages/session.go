@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdLog "log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	SESSION_FILE = "session.enc"
+	SITE_URL     = "https://essayshark.com/"
+
+	// The session file is encrypted with a key derived from the account
+	// password, so nothing on disk can unlock it by itself.
+	SESSION_KDF_ITERATIONS = 600000
+	SESSION_SALT_SIZE      = 16
+	SESSION_FILE_VERSION   = 1
+
+	// After MAX_LOGIN_ATTEMPTS failed logins in a row the bot stops trying
+	// and stays paused until it is restarted.
+	MAX_LOGIN_ATTEMPTS     = 3
+	RELOGIN_PAUSE_KEY      = "relogin"
+	LOGIN_FAILED_PAUSE_KEY = "login"
+)
+
+var (
+	errSessionLocked = errors.New("session file does not match these credentials")
+	errLoginGivenUp  = errors.New("login failed too many times, restart the bot to try again")
+)
+
+// storedCookie is a site cookie as kept in the session file.
+type storedCookie struct {
+	Name     string                 `json:"name"`
+	Value    string                 `json:"value"`
+	Domain   string                 `json:"domain"`
+	Path     string                 `json:"path"`
+	Expires  float64                `json:"expires,omitempty"` // Unix seconds, 0 for session cookies
+	HTTPOnly bool                   `json:"http_only"`
+	Secure   bool                   `json:"secure"`
+	SameSite network.CookieSameSite `json:"same_site,omitempty"`
+}
+
+// sessionFile is the JSON of SESSION_FILE. Data is the AES-GCM encrypted
+// sessionData.
+type sessionFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+type sessionData struct {
+	Email   string         `json:"email"`
+	SavedAt time.Time      `json:"saved_at"`
+	Cookies []storedCookie `json:"cookies"`
+}
+
+// SessionStore holds the cookies of the last successful login. Workers
+// take them instead of logging in themselves.
+type SessionStore struct {
+	mu       sync.Mutex
+	email    string
+	password string
+	salt     []byte
+	key      []byte
+	cookies  []storedCookie
+	savedAt  time.Time
+	// generation counts the sessions shared this run, so a worker can tell
+	// whether its cookies are the current ones.
+	generation   int
+	failedLogins int
+
+	// loginMu makes workers wait for a login in progress instead of
+	// starting their own.
+	loginMu sync.Mutex
+
+	// relogins counts the workers in relogin, including those waiting for
+	// loginMu. The bot stays paused while there are any.
+	reloginMu sync.Mutex
+	relogins  int
+}
+
+var session = &SessionStore{}
+
+func getSessionPath() string {
+	return filepath.Join(getSysfilesDir(), SESSION_FILE)
+}
+
+// Load sets the credentials and reads the saved session for them. A
+// missing or foreign session file just means the first worker logs in.
+func (s *SessionStore) Load(email, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.email, s.password = email, password
+	s.salt, s.key, s.cookies, s.savedAt = nil, nil, nil, time.Time{}
+	s.generation, s.failedLogins = 0, 0
+
+	data, err := os.ReadFile(getSessionPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debugLogger.Printf("Session file read error: %v", err)
+		}
+		return
+	}
+	saved, salt, key, err := decryptSession(data, password)
+	if err == nil && saved.Email != email {
+		err = errSessionLocked
+	}
+	if err != nil {
+		stdLog.Printf("Saved session not used: %v", err)
+		debugLogger.Printf("Session file error: %v", err)
+		return
+	}
+	s.salt, s.key = salt, key
+	s.cookies, s.savedAt = liveCookies(saved.Cookies, time.Now()), saved.SavedAt
+	s.generation++
+	stdLog.Printf("Saved session from %s loaded (%d cookies).", saved.SavedAt.Format("Jan 2 15:04"), len(s.cookies))
+	debugLogger.Printf("Session file loaded, %d cookies.", len(s.cookies))
+}
+
+// Current returns the generation and cookies of the current session; the
+// cookies are nil before the first login.
+func (s *SessionStore) Current() (int, []storedCookie) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation, s.cookies
+}
+
+func (s *SessionStore) Generation() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation
+}
+
+// Save keeps the cookies for the other workers and writes them to the
+// session file.
+func (s *SessionStore) Save(cookies []storedCookie) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cookies, s.savedAt = cookies, time.Now()
+	s.generation++
+
+	if s.key == nil {
+		s.salt = make([]byte, SESSION_SALT_SIZE)
+		if _, err := rand.Read(s.salt); err != nil {
+			return fmt.Errorf("error generating session salt: %w", err)
+		}
+		key, err := sessionKey(s.password, s.salt)
+		if err != nil {
+			return err
+		}
+		s.key = key
+	}
+	data, err := encryptSession(sessionData{Email: s.email, SavedAt: s.savedAt, Cookies: cookies}, s.salt, s.key)
+	if err != nil {
+		return err
+	}
+	tmp := getSessionPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("error writing session file: %w", err)
+	}
+	if err := os.Rename(tmp, getSessionPath()); err != nil {
+		return fmt.Errorf("error writing session file: %w", err)
+	}
+	return nil
+}
+
+func sessionKey(password string, salt []byte) ([]byte, error) {
+	key, err := pbkdf2.Key(sha256.New, password, salt, SESSION_KDF_ITERATIONS, 32)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving session key: %w", err)
+	}
+	return key, nil
+}
+
+func encryptSession(data sessionData, salt, key []byte) ([]byte, error) {
+	plain, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding session: %w", err)
+	}
+	gcm, err := sessionCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating session nonce: %w", err)
+	}
+	return json.Marshal(sessionFile{
+		Version: SESSION_FILE_VERSION,
+		Salt:    salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plain, salt),
+	})
+}
+
+// decryptSession opens a session file and returns its content with the
+// salt and key, which later saves reuse.
+func decryptSession(raw []byte, password string) (sessionData, []byte, []byte, error) {
+	var file sessionFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return sessionData{}, nil, nil, fmt.Errorf("error reading session file: %w", err)
+	}
+	if file.Version != SESSION_FILE_VERSION {
+		return sessionData{}, nil, nil, fmt.Errorf("unknown session file version %d", file.Version)
+	}
+	key, err := sessionKey(password, file.Salt)
+	if err != nil {
+		return sessionData{}, nil, nil, err
+	}
+	gcm, err := sessionCipher(key)
+	if err != nil {
+		return sessionData{}, nil, nil, err
+	}
+	if len(file.Nonce) != gcm.NonceSize() {
+		return sessionData{}, nil, nil, errSessionLocked
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, file.Salt)
+	if err != nil {
+		return sessionData{}, nil, nil, errSessionLocked
+	}
+	var data sessionData
+	if err := json.Unmarshal(plain, &data); err != nil {
+		return sessionData{}, nil, nil, fmt.Errorf("error decoding session: %w", err)
+	}
+	return data, file.Salt, key, nil
+}
+
+func sessionCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating session cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// liveCookies drops the cookies that have expired by now.
+func liveCookies(cookies []storedCookie, now time.Time) []storedCookie {
+	var live []storedCookie
+	for _, c := range cookies {
+		if c.Expires == 0 || c.Expires > float64(now.Unix()) {
+			live = append(live, c)
+		}
+	}
+	return live
+}
+
+// readSiteCookies returns the browser's cookies for the site. Cookies are
+// not part of Page, so this drives chromedp directly.
+func readSiteCookies(ctx context.Context) ([]storedCookie, error) {
+	var cookies []*network.Cookie
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = network.GetCookies().WithURLs([]string{SITE_URL}).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("error reading cookies: %w", err)
+	}
+	stored := make([]storedCookie, 0, len(cookies))
+	for _, c := range cookies {
+		sc := storedCookie{
+			Name: c.Name, Value: c.Value, Domain: c.Domain, Path: c.Path,
+			HTTPOnly: c.HTTPOnly, Secure: c.Secure, SameSite: c.SameSite,
+		}
+		if !c.Session && c.Expires > 0 {
+			sc.Expires = c.Expires
+		}
+		stored = append(stored, sc)
+	}
+	return stored, nil
+}
+
+// setSiteCookies puts cookies into the browser of ctx.
+func setSiteCookies(ctx context.Context, cookies []storedCookie) error {
+	params := make([]*network.CookieParam, 0, len(cookies))
+	for _, c := range cookies {
+		p := &network.CookieParam{
+			Name: c.Name, Value: c.Value, Domain: c.Domain, Path: c.Path,
+			HTTPOnly: c.HTTPOnly, Secure: c.Secure, SameSite: c.SameSite,
+		}
+		if c.Expires > 0 {
+			sec := int64(c.Expires)
+			expires := cdp.TimeSinceEpoch(time.Unix(sec, int64((c.Expires-float64(sec))*1e9)))
+			p.Expires = &expires
+		}
+		params = append(params, p)
+	}
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return network.SetCookies(params).Do(ctx)
+	}))
+	if err != nil {
+		return fmt.Errorf("error setting cookies: %w", err)
+	}
+	return nil
+}
+
+// ensureSession makes the browser of ctx logged in and returns the
+// generation of the session it now has. stale is the generation the caller
+// found logged out, or -1 at startup. The shared session is tried first
+// unless it is the stale one; only then does the worker log in, and it
+// shares the new cookies. Logins are serialized, so workers that start or
+// get logged out together log in once.
+func ensureSession(ctx context.Context, threadIndex int, stale int) (int, error) {
+	if !lockLogin(ctx) {
+		return stale, ctx.Err()
+	}
+	defer session.loginMu.Unlock()
+
+	gen, shared := session.Current()
+	if gen != stale {
+		if len(shared) > 0 {
+			if err := setSiteCookies(ctx, shared); err != nil {
+				debugLogger.Printf("Thread %d: %v", threadIndex, err)
+			}
+		}
+		if checkSession(ctx) {
+			if len(shared) > 0 {
+				stdLog.Printf("Thread %d: Using the shared session, no login required.", threadIndex)
+				debugLogger.Printf("Thread %d: Shared session %d valid.", threadIndex, gen)
+				return gen, nil
+			}
+			stdLog.Printf("Thread %d: Existing session found, no login required.", threadIndex)
+			debugLogger.Printf("Thread %d: Existing session confirmed.", threadIndex)
+			return shareSession(ctx, threadIndex), nil
+		}
+	}
+
+	if err := login(ctx, threadIndex); err != nil {
+		return gen, err
+	}
+	return shareSession(ctx, threadIndex), nil
+}
+
+// lockLogin takes loginMu, beating while it waits since a login may take
+// as long as the user needs for a verification step.
+func lockLogin(ctx context.Context) bool {
+	for !session.loginMu.TryLock() {
+		beat(ctx)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+	return true
+}
+
+// login logs in within the attempt limit. Its caller holds loginMu.
+func login(ctx context.Context, threadIndex int) error {
+	session.mu.Lock()
+	failed := session.failedLogins
+	session.mu.Unlock()
+	if failed >= MAX_LOGIN_ATTEMPTS {
+		return errLoginGivenUp
+	}
+
+	stdLog.Printf("Thread %d: No valid session found, attempting to log in.", threadIndex)
+	debugLogger.Printf("Thread %d: Session invalid, performing login (attempt %d/%d).", threadIndex, failed+1, MAX_LOGIN_ATTEMPTS)
+	err := performLogin(ctx, threadIndex, userEmail, userPassword)
+
+	session.mu.Lock()
+	if err != nil {
+		session.failedLogins++
+	} else {
+		session.failedLogins = 0
+	}
+	failed = session.failedLogins
+	session.mu.Unlock()
+
+	if err != nil {
+		if failed >= MAX_LOGIN_ATTEMPTS {
+			pauseBot(LOGIN_FAILED_PAUSE_KEY, fmt.Sprintf("login failed %d times", failed))
+			raiseAlert(EventLoginFailed, "Login Failed",
+				fmt.Sprintf("Login failed %d times in a row. The bot is paused; check the credentials and restart it.\n\nLast error: %v", failed, err))
+		}
+		return err
+	}
+	stdLog.Printf("Thread %d: Logged in successfully.", threadIndex)
+	debugLogger.Printf("Thread %d: Login successful.", threadIndex)
+	return nil
+}
+
+// shareSession saves the cookies of ctx as the new shared session and
+// returns its generation.
+func shareSession(ctx context.Context, threadIndex int) int {
+	cookies, err := readSiteCookies(ctx)
+	if err == nil {
+		err = session.Save(cookies)
+	}
+	if err != nil {
+		stdLog.Printf("Thread %d: Session not shared: %v", threadIndex, err)
+		debugLogger.Printf("Thread %d: Session save error: %v", threadIndex, err)
+	}
+	return session.Generation()
+}
+
+// relogin restores the session after a worker was logged out mid-run. The
+// other workers are held while it runs; those that were logged out as well
+// take the new session instead of logging in again.
+func relogin(ctx context.Context, threadIndex int, stale int) (int, error) {
+	defer session.holdForRelogin()()
+	return ensureSession(ctx, threadIndex, stale)
+}
+
+// holdForRelogin pauses the bot for a worker's relogin and returns the
+// function that ends the hold. The pause is lifted when the last worker
+// in relogin is done, not when the first one gives up.
+func (s *SessionStore) holdForRelogin() (release func()) {
+	s.reloginMu.Lock()
+	s.relogins++
+	if s.relogins == 1 {
+		pauseBot(RELOGIN_PAUSE_KEY, "session expired, logging in again")
+	}
+	s.reloginMu.Unlock()
+
+	return func() {
+		s.reloginMu.Lock()
+		s.relogins--
+		if s.relogins == 0 {
+			resumeBot(RELOGIN_PAUSE_KEY)
+		}
+		s.reloginMu.Unlock()
+	}
+}
+
+// refreshSession gives the browser of ctx the current shared session if
+// it still has an older one, and returns the generation it has now.
+func refreshSession(ctx context.Context, threadIndex int, have int) int {
+	gen, cookies := session.Current()
+	if gen == have || len(cookies) == 0 {
+		return have
+	}
+	if err := setSiteCookies(ctx, cookies); err != nil {
+		debugLogger.Printf("Thread %d: %v", threadIndex, err)
+		return have
+	}
+	debugLogger.Printf("Thread %d: Took shared session %d.", threadIndex, gen)
+	return gen
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+var testCookies = []storedCookie{
+	{Name: "sessionid", Value: "abc123", Domain: ".essayshark.com", Path: "/", HTTPOnly: true, Secure: true},
+	{Name: "csrftoken", Value: "xyz", Domain: ".essayshark.com", Path: "/", Expires: float64(time.Now().Add(time.Hour).Unix())},
+}
+
+// sealTestSession encrypts a session for writer@example.com with password.
+func sealTestSession(t *testing.T, password string) []byte {
+	t.Helper()
+	salt := bytes.Repeat([]byte{7}, SESSION_SALT_SIZE)
+	key, err := sessionKey(password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := encryptSession(sessionData{Email: "writer@example.com", SavedAt: time.Now(), Cookies: testCookies}, salt, key)
+	if err != nil {
+		t.Fatalf("encryptSession: %v", err)
+	}
+	return raw
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	raw := sealTestSession(t, "hunter2")
+	if bytes.Contains(raw, []byte("abc123")) {
+		t.Fatal("session file contains a cookie value in the clear")
+	}
+
+	data, salt, key, err := decryptSession(raw, "hunter2")
+	if err != nil {
+		t.Fatalf("decryptSession: %v", err)
+	}
+	if data.Email != "writer@example.com" || len(data.Cookies) != 2 || data.Cookies[0] != testCookies[0] {
+		t.Errorf("session = %+v, want the one encrypted", data)
+	}
+	if want, _ := sessionKey("hunter2", salt); !bytes.Equal(key, want) {
+		t.Error("returned key does not belong to the returned salt")
+	}
+}
+
+func TestSessionWrongPassword(t *testing.T) {
+	raw := sealTestSession(t, "hunter2")
+	if _, _, _, err := decryptSession(raw, "hunter3"); !errors.Is(err, errSessionLocked) {
+		t.Errorf("decryptSession with the wrong password = %v, want %v", err, errSessionLocked)
+	}
+
+	// A changed salt no longer authenticates either.
+	var file sessionFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		t.Fatal(err)
+	}
+	file.Salt[0]++
+	tampered, _ := json.Marshal(file)
+	if _, _, _, err := decryptSession(tampered, "hunter2"); !errors.Is(err, errSessionLocked) {
+		t.Errorf("decryptSession of a changed file = %v, want %v", err, errSessionLocked)
+	}
+}
+
+func TestSessionStoreSaveLoad(t *testing.T) {
+	setupOrderTest(t)
+	ensureFolders()
+	expired := storedCookie{Name: "old", Value: "1", Domain: ".essayshark.com", Path: "/", Expires: 1}
+
+	store := &SessionStore{}
+	store.Load("writer@example.com", "hunter2")
+	if err := store.Save(append([]storedCookie{expired}, testCookies...)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if info, err := os.Stat(getSessionPath()); err != nil || info.Mode().Perm()&0077 != 0 {
+		t.Errorf("session file = %v, %v; want it readable by its owner only", info, err)
+	}
+
+	loaded := &SessionStore{}
+	loaded.Load("writer@example.com", "hunter2")
+	if generation, cookies := loaded.Current(); generation != 1 || len(cookies) != 2 {
+		t.Errorf("loaded generation %d with %d cookies, want 1 with the 2 live ones", generation, len(cookies))
+	}
+
+	for _, creds := range [][2]string{{"writer@example.com", "wrong"}, {"other@example.com", "hunter2"}} {
+		other := &SessionStore{}
+		other.Load(creds[0], creds[1])
+		if _, cookies := other.Current(); cookies != nil {
+			t.Errorf("session loaded for %s with password %q", creds[0], creds[1])
+		}
+	}
+}
+
+func TestSessionReloginHold(t *testing.T) {
+	setupOrderTest(t)
+	t.Cleanup(clearPauses)
+	store := &SessionStore{}
+
+	// The first worker's login fails while a second one still waits for
+	// its turn.
+	first := store.holdForRelogin()
+	second := store.holdForRelogin()
+	first()
+	if !isPaused() {
+		t.Fatal("bot resumed while a worker was still logging in again")
+	}
+	second()
+	if isPaused() {
+		t.Errorf("bot still paused after every relogin ended: %v", pauseReasons())
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	stdLog "log"
+	"strings"
+	"sync/atomic"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+var (
+	mainWindow  fyne.Window
+	statusLabel *widget.Label
+	botRunning  int32
+)
+
+// statusText describes what the bot is doing right now.
+func statusText() string {
+	if atomic.LoadInt32(&botRunning) == 0 {
+		return "Status: Stopped"
+	}
+	text := "Status: Running"
+	if isDryRun() {
+		text = "Status: Running (dry run)"
+	}
+	if reasons := pauseReasons(); len(reasons) > 0 {
+		text = "Status: Paused - " + strings.Join(reasons, "; ")
+	}
+	if caps := bidCapStatusText(); caps != "" {
+		text += "\n" + caps
+	}
+	if schedule := scheduleStatusText(); schedule != "" {
+		text += "\n" + schedule
+	}
+	if workers := supervisorStatusText(); workers != "" {
+		text += "\n" + workers
+	}
+	return text
+}
+
+// refreshStatus updates the status label on the home screen. Safe to call
+// from any goroutine.
+func refreshStatus() {
+	if statusLabel == nil {
+		return
+	}
+	text := statusText()
+	fyne.Do(func() {
+		statusLabel.SetText(text)
+	})
+}
+
+// raiseAlert shows an error dialog in the main window and sends the event
+// as a notification so the alert is seen even when the window is hidden.
+func raiseAlert(kind EventKind, title, message string) {
+	stdLog.Printf("ALERT: %s: %s", title, message)
+	debugLogger.Printf("Alert raised: %s: %s", title, message)
+
+	notify(kind, title, message)
+	if mainWindow == nil {
+		return
+	}
+	fyne.Do(func() {
+		dialog.ShowInformation(title, message, mainWindow)
+	})
+}
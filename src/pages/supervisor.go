@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	stdLog "log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// A worker that has not beaten its heartbeat for this long is hung.
+	// Every wait in the pipeline beats, so only a stuck browser call gets
+	// this far.
+	WORKER_HEARTBEAT_TIMEOUT = 3 * time.Minute
+	WORKER_CHECK_INTERVAL    = 5 * time.Second
+	// WORKER_EXIT_GRACE is how long a hung worker gets to return after its
+	// browser is closed before it is abandoned.
+	WORKER_EXIT_GRACE = 30 * time.Second
+	// A worker is given up on after WORKER_RESTART_BUDGET restarts within
+	// WORKER_RESTART_WINDOW.
+	WORKER_RESTART_BUDGET = 5
+	WORKER_RESTART_WINDOW = time.Hour
+)
+
+// workerRestartPolicy spaces restarts of the same worker.
+var workerRestartPolicy = RetryPolicy{InitialDelayMs: 5000, MaxDelayMs: 300000, Multiplier: 2}
+
+var (
+	errWorkerHung   = errors.New("no heartbeat")
+	errWorkerExited = errors.New("worker exited unexpectedly")
+)
+
+// WorkerState is what a supervised worker is doing.
+type WorkerState string
+
+const (
+	WorkerStarting   WorkerState = "starting"
+	WorkerRunning    WorkerState = "running"
+	WorkerRestarting WorkerState = "restarting"
+	WorkerStopped    WorkerState = "stopped"
+	WorkerFailed     WorkerState = "failed"
+)
+
+// WorkerMetrics describes one worker for the status line and the Workers
+// screen.
+type WorkerMetrics struct {
+	Thread    int
+	State     WorkerState
+	StartedAt time.Time // of the current run
+	LastBeat  time.Time
+	Restarts  int
+	Crashes   int // runs that ended with an error
+	Hangs     int // runs stopped for missing heartbeats
+	LastError string
+	RestartAt time.Time // while restarting
+}
+
+// heartbeat is carried in a worker's context; waits and loop iterations
+// beat it.
+type heartbeat struct {
+	last int64 // UnixNano
+}
+
+func (h *heartbeat) beat() {
+	atomic.StoreInt64(&h.last, time.Now().UnixNano())
+}
+
+func (h *heartbeat) since() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&h.last)))
+}
+
+type heartbeatKey struct{}
+
+func withHeartbeat(ctx context.Context, h *heartbeat) context.Context {
+	return context.WithValue(ctx, heartbeatKey{}, h)
+}
+
+// beat marks the worker running on ctx as alive. It is a no-op outside a
+// supervised worker.
+func beat(ctx context.Context) {
+	if h, ok := ctx.Value(heartbeatKey{}).(*heartbeat); ok {
+		h.beat()
+	}
+}
+
+type workerKey struct{}
+
+// workerContext returns pageCtx carrying the worker values of workerCtx,
+// for pages that do not derive from the worker's context.
+func workerContext(pageCtx, workerCtx context.Context) context.Context {
+	pageCtx = context.WithValue(pageCtx, workerKey{}, workerCtx)
+	if h, ok := workerCtx.Value(heartbeatKey{}).(*heartbeat); ok {
+		return withHeartbeat(pageCtx, h)
+	}
+	return pageCtx
+}
+
+// stopRequested reports whether the bot was stopped or the worker running
+// on ctx was cancelled, by the user or by the supervisor. A page context
+// also ends when its browser dies, so only the worker's own context
+// counts; outside a worker, ctx itself being cancelled does.
+func stopRequested(ctx context.Context) bool {
+	if atomic.LoadInt32(&stopFlag) != 0 {
+		return true
+	}
+	if workerCtx, ok := ctx.Value(workerKey{}).(context.Context); ok {
+		ctx = workerCtx
+	}
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+type supervisedWorker struct {
+	WorkerMetrics
+	heartbeat *heartbeat
+	recent    []time.Time // restarts within WORKER_RESTART_WINDOW
+}
+
+// Supervisor runs the workers, restarts the ones that die or hang with a
+// fresh browser, and gives up on a worker that keeps failing.
+type Supervisor struct {
+	mu      sync.Mutex
+	workers []*supervisedWorker
+}
+
+var supervisor = &Supervisor{}
+
+// Start launches count supervised workers. Each is counted in executorWG
+// until it is stopped or given up on.
+func (s *Supervisor) Start(allocCtx context.Context, count int, chromePath string) {
+	s.mu.Lock()
+	s.workers = make([]*supervisedWorker, count)
+	for i := range s.workers {
+		s.workers[i] = &supervisedWorker{WorkerMetrics: WorkerMetrics{Thread: i, State: WorkerStarting}, heartbeat: &heartbeat{}}
+	}
+	s.mu.Unlock()
+
+	for i := 0; i < count; i++ {
+		executorWG.Add(1)
+		go s.supervise(allocCtx, i, chromePath)
+	}
+}
+
+func (s *Supervisor) supervise(allocCtx context.Context, threadIndex int, chromePath string) {
+	defer executorWG.Done()
+	w := s.worker(threadIndex)
+
+	for {
+		runCtx, cancel := context.WithCancel(allocCtx)
+		s.update(func() {
+			w.State, w.StartedAt, w.RestartAt = WorkerRunning, time.Now(), time.Time{}
+			w.heartbeat.beat()
+		})
+		refreshStatus()
+
+		done := make(chan error, 1)
+		go func() {
+			done <- runWorker(withHeartbeat(runCtx, w.heartbeat), threadIndex, chromePath)
+		}()
+		err := s.watch(w, done, cancel)
+		cancel()
+
+		if atomic.LoadInt32(&stopFlag) != 0 || allocCtx.Err() != nil {
+			s.update(func() { w.State = WorkerStopped })
+			refreshStatus()
+			return
+		}
+		if err == nil {
+			err = errWorkerExited
+		}
+
+		delay, ok := s.recordFailure(w, err)
+		if !ok {
+			stdLog.Printf("Thread %d: Worker failed %d times within %v, giving up: %v", threadIndex, WORKER_RESTART_BUDGET, WORKER_RESTART_WINDOW, err)
+			debugLogger.Printf("Thread %d: Restart budget exhausted: %v", threadIndex, err)
+			refreshStatus()
+			raiseAlert(EventWorkerFailed, "Worker Stopped",
+				fmt.Sprintf("Thread %d failed %d times within %v and will not be restarted.\n\nLast error: %v",
+					threadIndex, WORKER_RESTART_BUDGET, WORKER_RESTART_WINDOW, err))
+			return
+		}
+		stdLog.Printf("Thread %d: Worker stopped (%v), restarting in %v.", threadIndex, err, delay)
+		debugLogger.Printf("Thread %d: Worker restart scheduled in %v after: %v", threadIndex, delay, err)
+		emitWebhook(HookWorkerRestarted, map[string]string{
+			"thread": fmt.Sprint(threadIndex), "error": err.Error(), "delay": delay.String(),
+		})
+		refreshStatus()
+		if !sleepUnlessStopped(allocCtx, delay) {
+			s.update(func() { w.State = WorkerStopped })
+			refreshStatus()
+			return
+		}
+	}
+}
+
+// watch waits for the worker run to end. A run that stops beating is
+// cancelled, which closes its browser, and reported as hung.
+func (s *Supervisor) watch(w *supervisedWorker, done <-chan error, cancel context.CancelFunc) error {
+	ticker := time.NewTicker(WORKER_CHECK_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+		}
+
+		silent := w.heartbeat.since()
+		s.update(func() { w.LastBeat = time.Now().Add(-silent) })
+		if silent < WORKER_HEARTBEAT_TIMEOUT || atomic.LoadInt32(&stopFlag) != 0 {
+			continue
+		}
+
+		stdLog.Printf("Thread %d: No heartbeat for %v, closing its browser.", w.Thread, silent.Round(time.Second))
+		debugLogger.Printf("Thread %d: Worker hung for %v.", w.Thread, silent)
+		s.update(func() { w.Hangs++ })
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(WORKER_EXIT_GRACE):
+			// The goroutine is left to finish on its own. It may have been
+			// the breaker's half-open probe, which would otherwise never
+			// be released.
+			debugLogger.Printf("Thread %d: Hung worker did not exit, abandoning it.", w.Thread)
+			breaker.Release()
+		}
+		return fmt.Errorf("%w for %v", errWorkerHung, silent.Round(time.Second))
+	}
+}
+
+// recordFailure counts a failed run and returns the delay before the
+// restart, or false once the restart budget is spent.
+func (s *Supervisor) recordFailure(w *supervisedWorker, err error) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if !errors.Is(err, errWorkerHung) {
+		w.Crashes++
+	}
+	w.LastError = err.Error()
+
+	kept := w.recent[:0]
+	for _, t := range w.recent {
+		if now.Sub(t) < WORKER_RESTART_WINDOW {
+			kept = append(kept, t)
+		}
+	}
+	w.recent = kept
+	if len(w.recent) >= WORKER_RESTART_BUDGET {
+		w.State = WorkerFailed
+		return 0, false
+	}
+
+	w.recent = append(w.recent, now)
+	w.Restarts++
+	delay := workerRestartPolicy.Delay(len(w.recent))
+	w.State, w.RestartAt = WorkerRestarting, now.Add(delay)
+	return delay, true
+}
+
+func (s *Supervisor) worker(threadIndex int) *supervisedWorker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.workers[threadIndex]
+}
+
+func (s *Supervisor) update(fn func()) {
+	s.mu.Lock()
+	fn()
+	s.mu.Unlock()
+}
+
+// Metrics returns a copy of every worker's figures.
+func (s *Supervisor) Metrics() []WorkerMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	metrics := make([]WorkerMetrics, len(s.workers))
+	for i, w := range s.workers {
+		metrics[i] = w.WorkerMetrics
+		if w.State == WorkerRunning || w.State == WorkerStarting {
+			metrics[i].LastBeat = time.Now().Add(-w.heartbeat.since())
+		}
+	}
+	return metrics
+}
+
+// supervisorStatusText summarizes the workers for the status line. It is
+// empty while every worker runs and none has been restarted.
+func supervisorStatusText() string {
+	metrics := supervisor.Metrics()
+	running, restarts := 0, 0
+	var failed []string
+	for _, m := range metrics {
+		restarts += m.Restarts
+		switch m.State {
+		case WorkerRunning, WorkerStarting:
+			running++
+		case WorkerFailed:
+			failed = append(failed, fmt.Sprint(m.Thread))
+		}
+	}
+	if running == len(metrics) && restarts == 0 {
+		return ""
+	}
+	text := fmt.Sprintf("Workers: %d/%d running, %d restarts", running, len(metrics), restarts)
+	if len(failed) > 0 {
+		text += ", given up on thread " + strings.Join(failed, ", ")
+	}
+	return text
+}
+
+// workersContent lists the supervised workers with their restart figures.
+func workersContent() fyne.CanvasObject {
+	text := widget.NewLabel("")
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+	refresh := func() {
+		metrics := supervisor.Metrics()
+		if len(metrics) == 0 {
+			text.SetText("The bot has not been started.")
+			return
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "%-6s %-11s %-9s %-9s %8s %7s %5s  %s\n", "Thread", "State", "Uptime", "Heartbeat", "Restarts", "Crashes", "Hangs", "Last error")
+		for _, m := range metrics {
+			uptime, beatAge := "-", "-"
+			if m.State == WorkerRunning {
+				uptime = time.Since(m.StartedAt).Round(time.Second).String()
+				beatAge = time.Since(m.LastBeat).Round(time.Second).String() + " ago"
+			}
+			state := string(m.State)
+			if m.State == WorkerRestarting {
+				state += " in " + time.Until(m.RestartAt).Round(time.Second).String()
+			}
+			fmt.Fprintf(&b, "%-6d %-11s %-9s %-9s %8d %7d %5d  %s\n", m.Thread, state, uptime, beatAge, m.Restarts, m.Crashes, m.Hangs, m.LastError)
+		}
+		text.SetText(b.String())
+	}
+	refresh()
+
+	// Reading process memory can take a moment on Windows, so it is only
+	// done on request.
+	memoryLabel := widget.NewLabel("Memory: not measured.")
+	var memoryButton *widget.Button
+	memoryButton = widget.NewButton("Measure Memory", func() {
+		memoryButton.Disable()
+		go func() {
+			m := measureBrowserMemory()
+			stdLog.Printf("Browser memory: %s", m)
+			fyne.Do(func() {
+				memoryLabel.SetText("Memory: " + m.String())
+				memoryButton.Enable()
+			})
+		}()
+	})
+	// The address of the Chrome the bot works in, to watch it from
+	// another DevTools client.
+	debugLabel := widget.NewLabel("")
+	debugButton := widget.NewButton("Copy Debugging URL", func() {
+		go func() {
+			wsURL, err := debuggingURL(context.Background())
+			fyne.Do(func() {
+				if err != nil {
+					debugLabel.SetText(err.Error())
+					return
+				}
+				fyne.CurrentApp().Clipboard().SetContent(wsURL)
+				debugLabel.SetText("Copied " + wsURL)
+			})
+		}()
+	})
+	if browserMode() != BROWSER_MODE_REMOTE {
+		debugButton.Disable()
+		debugLabel.SetText("Only available in remote browser mode.")
+	}
+	return container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("Dead or hung workers are restarted with a fresh browser, at most %d times per %v.",
+			WORKER_RESTART_BUDGET, WORKER_RESTART_WINDOW)),
+		widget.NewButton("Refresh", refresh),
+		text,
+		container.NewHBox(memoryButton, memoryLabel),
+		container.NewHBox(debugButton, debugLabel),
+	)
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	stdLog "log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/systeminfo"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	BROWSER_MODE_PER_THREAD = "per_thread"
+	BROWSER_MODE_SHARED     = "shared"
+	MEMORY_PROBE_TIMEOUT    = 10 * time.Second
+)
+
+// sharedPool is the tab pool of the shared browser while the bot runs in
+// BROWSER_MODE_SHARED or BROWSER_MODE_REMOTE; nil in per-thread mode.
+var sharedPool *TabPool
+
+func browserMode() string {
+	switch cfg.BrowserMode {
+	case BROWSER_MODE_SHARED, BROWSER_MODE_REMOTE:
+		return cfg.BrowserMode
+	}
+	return BROWSER_MODE_PER_THREAD
+}
+
+// tabPoolSize is the number of tabs of the shared browser.
+func tabPoolSize() int {
+	if cfg.TabPoolSize > 0 {
+		return cfg.TabPoolSize
+	}
+	return cfg.ThreadCount
+}
+
+type pooledTab struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// TabPool lends the pages of a Browser to workers. At most size pages are
+// leased at once; a returned page stays open for the next lease.
+type TabPool struct {
+	browser Browser
+	base    context.Context // pages are opened on it
+	slots   chan struct{}
+
+	mu   sync.Mutex
+	idle []pooledTab
+	open int
+}
+
+func newTabPool(base context.Context, browser Browser, size int) *TabPool {
+	if size < 1 {
+		size = 1
+	}
+	return &TabPool{browser: browser, base: base, slots: make(chan struct{}, size)}
+}
+
+// Lease waits for a free slot and returns a page context. Ending ctx
+// closes the page, since that is the only way to stop a call stuck in it.
+// release must be called once the caller is done with the page; broken
+// closes it instead of keeping it for the next lease.
+func (p *TabPool) Lease(ctx context.Context) (context.Context, func(broken bool), error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	tab, err := p.take()
+	if err != nil {
+		<-p.slots
+		return nil, nil, err
+	}
+
+	stop := context.AfterFunc(ctx, tab.cancel)
+	var once sync.Once
+	release := func(broken bool) {
+		once.Do(func() {
+			if !stop() || broken || tab.ctx.Err() != nil {
+				p.discard(tab)
+			} else {
+				p.mu.Lock()
+				p.idle = append(p.idle, tab)
+				p.mu.Unlock()
+			}
+			<-p.slots
+		})
+	}
+	return tab.ctx, release, nil
+}
+
+func (p *TabPool) take() (pooledTab, error) {
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		tab := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if tab.ctx.Err() == nil {
+			p.mu.Unlock()
+			return tab, nil
+		}
+		tab.cancel()
+		p.open--
+	}
+	p.mu.Unlock()
+
+	ctx, cancel, err := p.browser.NewPage(p.base)
+	if err != nil {
+		return pooledTab{}, err
+	}
+	p.mu.Lock()
+	p.open++
+	p.mu.Unlock()
+	return pooledTab{ctx: ctx, cancel: cancel}, nil
+}
+
+func (p *TabPool) discard(tab pooledTab) {
+	tab.cancel()
+	p.mu.Lock()
+	p.open--
+	p.mu.Unlock()
+}
+
+// Stats returns how many pages are open and how many of them are leased.
+func (p *TabPool) Stats() (open, leased int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.open, len(p.slots)
+}
+
+// Close closes the idle pages, and the browser if the pool owns one.
+func (p *TabPool) Close() {
+	p.mu.Lock()
+	for _, tab := range p.idle {
+		tab.cancel()
+		p.open--
+	}
+	p.idle = nil
+	p.mu.Unlock()
+	if c, ok := p.browser.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
+
+// sharedChrome is a single Chrome process whose pages are tabs. It is
+// started by the first NewPage and started again if it died.
+type sharedChrome struct {
+	opts []chromedp.ExecAllocatorOption
+
+	mu         sync.Mutex
+	browserCtx context.Context // the first tab, kept open to hold the browser
+	cancel     context.CancelFunc
+}
+
+func (b *sharedChrome) NewPage(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	for attempt := 0; ; attempt++ {
+		browserCtx, err := b.start(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		tabCtx, cancel, err := openTab(browserCtx)
+		if err == nil {
+			return tabCtx, cancel, nil
+		}
+		if attempt > 0 || ctx.Err() != nil {
+			return nil, nil, fmt.Errorf("error opening tab: %w", err)
+		}
+		stdLog.Printf("Shared browser is not responding, restarting it: %v", err)
+		debugLogger.Printf("Shared browser tab error: %v", err)
+		b.Close()
+	}
+}
+
+func (b *sharedChrome) start(ctx context.Context) (context.Context, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.browserCtx != nil && b.browserCtx.Err() == nil {
+		return b.browserCtx, nil
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(ctx, b.opts...)
+	browserCtx, browserCancel, err := openTab(allocCtx)
+	if err != nil {
+		allocCancel()
+		return nil, fmt.Errorf("error starting Chrome: %w", err)
+	}
+	unregister := registerBrowser(browserCtx)
+	b.browserCtx = browserCtx
+	b.cancel = func() {
+		unregister()
+		browserCancel()
+		allocCancel()
+	}
+	stdLog.Println("Shared browser started.")
+	debugLogger.Println("Shared browser started.")
+	return browserCtx, nil
+}
+
+// Close stops the browser and every tab in it.
+func (b *sharedChrome) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.browserCtx, b.cancel = nil, nil
+}
+
+// liveBrowsers holds a page of every running Chrome process the bot
+// started, for measuring memory.
+var liveBrowsers = struct {
+	sync.Mutex
+	pages map[*chromedp.Browser]context.Context
+}{pages: make(map[*chromedp.Browser]context.Context)}
+
+func registerBrowser(pageCtx context.Context) (unregister func()) {
+	c := chromedp.FromContext(pageCtx)
+	if c == nil || c.Browser == nil {
+		return func() {}
+	}
+	liveBrowsers.Lock()
+	liveBrowsers.pages[c.Browser] = pageCtx
+	liveBrowsers.Unlock()
+	return func() {
+		liveBrowsers.Lock()
+		delete(liveBrowsers.pages, c.Browser)
+		liveBrowsers.Unlock()
+	}
+}
+
+// BrowserMemory is the memory used by the bot's Chrome processes. RSS is
+// summed over processes, so memory they share is counted more than once.
+type BrowserMemory struct {
+	Mode       string
+	Browsers   int
+	Tabs       int // open pages in shared and remote mode
+	Processes  int
+	RSS        int64 // bytes
+	Unreadable int   // processes whose memory could not be read
+}
+
+func (m BrowserMemory) String() string {
+	text := fmt.Sprintf("%s mode: %d browser(s)", strings.ReplaceAll(m.Mode, "_", "-"), m.Browsers)
+	if m.Mode != BROWSER_MODE_PER_THREAD {
+		text += fmt.Sprintf(", %d tab(s)", m.Tabs)
+	}
+	text += fmt.Sprintf(", %d processes, %.0f MB resident", m.Processes, float64(m.RSS)/(1<<20))
+	if m.Browsers > 0 {
+		text += fmt.Sprintf(" (%.0f MB per browser)", float64(m.RSS)/(1<<20)/float64(m.Browsers))
+	}
+	if m.Unreadable > 0 {
+		text += fmt.Sprintf(", %d unreadable", m.Unreadable)
+	}
+	return text
+}
+
+// measureBrowserMemory adds up the resident memory of every process of
+// every browser the bot runs.
+func measureBrowserMemory() BrowserMemory {
+	m := BrowserMemory{Mode: BROWSER_MODE_PER_THREAD}
+	if sharedPool != nil {
+		m.Mode = browserMode()
+		m.Tabs, _ = sharedPool.Stats()
+	}
+
+	liveBrowsers.Lock()
+	pages := make([]context.Context, 0, len(liveBrowsers.pages))
+	for _, ctx := range liveBrowsers.pages {
+		pages = append(pages, ctx)
+	}
+	liveBrowsers.Unlock()
+
+	for _, pageCtx := range pages {
+		pids, err := browserProcesses(pageCtx)
+		if err != nil {
+			debugLogger.Printf("Memory probe error: %v", err)
+			continue
+		}
+		m.Browsers++
+		for _, pid := range pids {
+			m.Processes++
+			rss, err := processRSS(pid)
+			if err != nil {
+				debugLogger.Printf("Memory of process %d: %v", pid, err)
+				m.Unreadable++
+				continue
+			}
+			m.RSS += rss
+		}
+	}
+	return m
+}
+
+// browserProcesses returns the browser process and its renderer, GPU and
+// utility processes.
+func browserProcesses(pageCtx context.Context) ([]int, error) {
+	c := chromedp.FromContext(pageCtx)
+	var pids []int
+	if proc := c.Browser.Process(); proc != nil {
+		pids = append(pids, proc.Pid)
+	}
+	ctx, cancel := context.WithTimeout(pageCtx, MEMORY_PROBE_TIMEOUT)
+	defer cancel()
+	infos, err := systeminfo.GetProcessInfo().Do(cdp.WithExecutor(ctx, c.Browser))
+	if err != nil {
+		return nil, fmt.Errorf("error listing browser processes: %w", err)
+	}
+	for _, info := range infos {
+		if int(info.ID) != 0 {
+			pids = append(pids, int(info.ID))
+		}
+	}
+	return pids, nil
+}
+
+// processRSS returns the resident memory of a process in bytes.
+func processRSS(pid int) (int64, error) {
+	switch runtime.GOOS {
+	case "linux":
+		f, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if fields := strings.Fields(scanner.Text()); len(fields) >= 2 && fields[0] == "VmRSS:" {
+				kb, err := strconv.ParseInt(fields[1], 10, 64)
+				return kb * 1024, err
+			}
+		}
+		return 0, fmt.Errorf("no VmRSS for process %d", pid)
+	case "windows":
+		// "chrome.exe","1234","Console","1","123,456 K"
+		out, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/FO", "CSV", "/NH").Output()
+		if err != nil {
+			return 0, err
+		}
+		fields := strings.Split(strings.TrimSpace(string(out)), `","`)
+		if len(fields) < 5 {
+			return 0, fmt.Errorf("unexpected tasklist output %q", out)
+		}
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, fields[4])
+		kb, err := strconv.ParseInt(digits, 10, 64)
+		return kb * 1024, err
+	default:
+		out, err := exec.Command("ps", "-o", "rss=", "-p", strconv.Itoa(pid)).Output()
+		if err != nil {
+			return 0, err
+		}
+		kb, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+		return kb * 1024, err
+	}
+}
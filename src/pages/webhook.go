@@ -0,0 +1,505 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdLog "log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	WEBHOOK_QUEUE_FILE  = "webhook_queue.json"
+	WEBHOOK_FAILED_FILE = "webhook_failed.jsonl"
+	WEBHOOK_TIMEOUT     = 10 * time.Second
+
+	// Receivers verify a delivery by computing HMAC-SHA256 of the raw body
+	// with the target's secret and comparing it to the signature header,
+	// which has the form "sha256=<hex>".
+	WEBHOOK_SIGNATURE_HEADER = "X-Bot-Signature"
+	WEBHOOK_DELIVERY_HEADER  = "X-Bot-Delivery"
+	WEBHOOK_EVENT_HEADER     = "X-Bot-Event"
+
+	// Webhook secrets are credentials, so like the SMTP password they are
+	// read from the environment. A target names its variable with
+	// secret_env, or uses this one.
+	WEBHOOK_SECRET_ENV = "BIDBOT_WEBHOOK_SECRET"
+)
+
+// Webhook event names.
+const (
+	HookOrderDiscovered = "order.discovered"
+	HookOrderFiltered   = "order.filtered"
+	HookBidPlaced       = "bid.placed"
+	HookMessageSent     = "message.sent"
+	HookOrderWon        = "order.won"
+	HookWorkerRestarted = "worker.restarted"
+	HookError           = "error"
+	HookTest            = "test"
+)
+
+var webhookEvents = []string{HookOrderDiscovered, HookOrderFiltered, HookBidPlaced, HookMessageSent, HookOrderWon, HookWorkerRestarted, HookError}
+
+// Failed deliveries are retried on this schedule, then moved to
+// sysfiles/webhook_failed.jsonl.
+var webhookRetryPolicy = RetryPolicy{MaxAttempts: 15, InitialDelayMs: 10000, MaxDelayMs: 3600000, Multiplier: 2}
+
+var errWebhookTargetGone = errors.New("webhook target no longer configured")
+
+// WebhookTarget is an endpoint that receives bot events.
+type WebhookTarget struct {
+	URL       string   `json:"url"`
+	SecretEnv string   `json:"secret_env,omitempty"` // empty means WEBHOOK_SECRET_ENV
+	Events    []string `json:"events,omitempty"`     // empty means every event
+}
+
+// secretEnv is the environment variable holding the target's secret.
+func (t WebhookTarget) secretEnv() string {
+	return orEmpty(t.SecretEnv, WEBHOOK_SECRET_ENV)
+}
+
+func (t WebhookTarget) secret() string {
+	return os.Getenv(t.secretEnv())
+}
+
+func (t WebhookTarget) wants(event string) bool {
+	if len(t.Events) == 0 || event == HookTest {
+		return true
+	}
+	for _, e := range t.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookPayload is the JSON body of every delivery.
+type webhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// webhookDelivery is one payload bound for one target. The body is signed
+// when it is sent, so queued deliveries never hold the secret.
+type webhookDelivery struct {
+	ID          string          `json:"id"`
+	URL         string          `json:"url"`
+	Event       string          `json:"event"`
+	Body        json.RawMessage `json:"body"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"next_attempt"`
+	LastError   string          `json:"last_error,omitempty"`
+}
+
+// WebhookDispatcher sends deliveries from a single goroutine. Every
+// delivery is added to the queue persisted to sysfiles/webhook_queue.json
+// before it is first tried, and stays there until it is delivered or
+// given up on, so none is lost when the app exits.
+type WebhookDispatcher struct {
+	mu     sync.Mutex
+	queue  []webhookDelivery
+	wake   chan struct{} // a delivery was queued
+	client *http.Client
+	once   sync.Once
+}
+
+var webhooks = &WebhookDispatcher{
+	wake:   make(chan struct{}, 1),
+	client: &http.Client{Timeout: WEBHOOK_TIMEOUT},
+}
+
+func getWebhookQueuePath() string {
+	return filepath.Join(getSysfilesDir(), WEBHOOK_QUEUE_FILE)
+}
+
+// Start loads the retry queue and starts delivering. It runs for the life
+// of the app so test events work while the bot is stopped.
+func (d *WebhookDispatcher) Start() {
+	d.once.Do(func() {
+		data, err := os.ReadFile(getWebhookQueuePath())
+		if err == nil {
+			if err := json.Unmarshal(data, &d.queue); err != nil {
+				debugLogger.Printf("Webhook queue parse error: %v", err)
+			}
+		} else if !os.IsNotExist(err) {
+			debugLogger.Printf("Webhook queue read error: %v", err)
+		}
+		if len(d.queue) > 0 {
+			stdLog.Printf("%d webhook deliveries waiting for retry.", len(d.queue))
+		}
+		go d.run()
+	})
+}
+
+func (d *WebhookDispatcher) run() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.wake:
+		case <-ticker.C:
+		}
+		for _, job := range d.due(time.Now()) {
+			d.attempt(job)
+		}
+	}
+}
+
+// Pending returns the number of deliveries waiting for a retry.
+func (d *WebhookDispatcher) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.queue)
+}
+
+// emitWebhook queues event with data for every target that wants it.
+func emitWebhook(event string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		debugLogger.Printf("Webhook payload marshal error: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, target := range cfg.Webhooks {
+		if !target.wants(event) {
+			continue
+		}
+		id := newDeliveryID()
+		body, err := json.Marshal(webhookPayload{ID: id, Event: event, CreatedAt: now.UTC(), Data: json.RawMessage(raw)})
+		if err != nil {
+			debugLogger.Printf("Webhook payload marshal error: %v", err)
+			continue
+		}
+		webhooks.upsert(webhookDelivery{ID: id, URL: target.URL, Event: event, Body: body, NextAttempt: now})
+	}
+	select {
+	case webhooks.wake <- struct{}{}:
+	default:
+	}
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *WebhookDispatcher) attempt(job webhookDelivery) {
+	err := d.send(job)
+	switch {
+	case err == nil:
+		debugLogger.Printf("Webhook %s delivered to %s (%s).", job.Event, job.URL, job.ID)
+		d.remove(job.ID)
+		return
+	case errors.Is(err, errWebhookTargetGone):
+		debugLogger.Printf("Webhook %s dropped: %v", job.ID, err)
+		d.remove(job.ID)
+		return
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	if job.Attempts >= webhookRetryPolicy.MaxAttempts {
+		stdLog.Printf("Webhook %s to %s failed %d times, giving up: %v", job.Event, job.URL, job.Attempts, err)
+		d.remove(job.ID)
+		d.recordFailed(job)
+		return
+	}
+	job.NextAttempt = time.Now().Add(webhookRetryPolicy.Delay(job.Attempts))
+	debugLogger.Printf("Webhook %s to %s failed (attempt %d), retrying at %s: %v",
+		job.Event, job.URL, job.Attempts, job.NextAttempt.Format("15:04:05"), err)
+	d.upsert(job)
+}
+
+func (d *WebhookDispatcher) send(job webhookDelivery) error {
+	for _, target := range cfg.Webhooks {
+		if target.URL == job.URL {
+			return d.post(target, job)
+		}
+	}
+	return fmt.Errorf("%w: %s", errWebhookTargetGone, job.URL)
+}
+
+func (d *WebhookDispatcher) post(target WebhookTarget, job webhookDelivery) error {
+	secret := target.secret()
+	if secret == "" {
+		return fmt.Errorf("no webhook secret in %s", target.secretEnv())
+	}
+	req, err := http.NewRequest(http.MethodPost, job.URL, bytes.NewReader(job.Body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errWebhookTargetGone, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "BiddingBot-Webhook/1")
+	req.Header.Set(WEBHOOK_EVENT_HEADER, job.Event)
+	req.Header.Set(WEBHOOK_DELIVERY_HEADER, job.ID)
+	req.Header.Set(WEBHOOK_SIGNATURE_HEADER, signWebhook(secret, job.Body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook target answered %s", resp.Status)
+	}
+	return nil
+}
+
+// due returns the queued deliveries whose retry time has come.
+func (d *WebhookDispatcher) due(now time.Time) []webhookDelivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var due []webhookDelivery
+	for _, job := range d.queue {
+		if !job.NextAttempt.After(now) {
+			due = append(due, job)
+		}
+	}
+	return due
+}
+
+func (d *WebhookDispatcher) upsert(job webhookDelivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range d.queue {
+		if d.queue[i].ID == job.ID {
+			d.queue[i] = job
+			d.persist()
+			return
+		}
+	}
+	d.queue = append(d.queue, job)
+	d.persist()
+}
+
+func (d *WebhookDispatcher) remove(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range d.queue {
+		if d.queue[i].ID == id {
+			d.queue = append(d.queue[:i], d.queue[i+1:]...)
+			d.persist()
+			return
+		}
+	}
+}
+
+// persist writes the queue atomically. Callers hold d.mu.
+func (d *WebhookDispatcher) persist() {
+	data, err := json.MarshalIndent(d.queue, "", "  ")
+	if err != nil {
+		debugLogger.Printf("Webhook queue marshal error: %v", err)
+		return
+	}
+	path := getWebhookQueuePath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		debugLogger.Printf("Webhook queue write error: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		debugLogger.Printf("Webhook queue rename error: %v", err)
+	}
+}
+
+func (d *WebhookDispatcher) recordFailed(job webhookDelivery) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(getSysfilesDir(), WEBHOOK_FAILED_FILE), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		debugLogger.Printf("Webhook failure log error: %v", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+// sendTestWebhook posts a test event to one target right away and returns
+// the result, without queueing a retry.
+func sendTestWebhook(target WebhookTarget) error {
+	id := newDeliveryID()
+	body, err := json.Marshal(webhookPayload{ID: id, Event: HookTest, CreatedAt: time.Now().UTC(),
+		Data: map[string]string{"message": "Test event from the bidding bot."}})
+	if err != nil {
+		return err
+	}
+	return webhooks.post(target, webhookDelivery{ID: id, URL: target.URL, Event: HookTest, Body: body})
+}
+
+// emitErrorWebhook reports a failure of the given worker. Bids held back
+// by a cap and stages cut short by a stop are not failures and are left
+// out.
+func emitErrorWebhook(threadIndex int, orderUrl string, err error) {
+	if errors.Is(err, ErrCapReached) || errors.Is(err, ErrStopped) {
+		return
+	}
+	data := map[string]interface{}{
+		"thread": threadIndex,
+		"error":  err.Error(),
+	}
+	if orderUrl != "" {
+		data["order_url"] = orderUrl
+	}
+	var pe *PipelineError
+	if errors.As(err, &pe) {
+		data["stage"] = pe.Stage
+		data["class"] = pe.Class.Error()
+		if pe.Selector != "" {
+			data["selector"] = pe.Selector
+		}
+	}
+	emitWebhook(HookError, data)
+}
+
+// formatWebhookTargets renders targets one per line for the settings
+// screen, e.g.
+//
+//	https://hooks.example.com/bot secret_env=HOOKS_SECRET events=bid.placed,error
+func formatWebhookTargets(targets []WebhookTarget) string {
+	var lines []string
+	for _, t := range targets {
+		line := t.URL
+		if t.SecretEnv != "" {
+			line += " secret_env=" + t.SecretEnv
+		}
+		if len(t.Events) > 0 {
+			line += " events=" + strings.Join(t.Events, ",")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseWebhookTargets is the inverse of formatWebhookTargets.
+func parseWebhookTargets(text string) ([]WebhookTarget, error) {
+	var targets []WebhookTarget
+	for n, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		t := WebhookTarget{URL: fields[0]}
+		if !strings.HasPrefix(t.URL, "https://") && !strings.HasPrefix(t.URL, "http://") {
+			return nil, fmt.Errorf("line %d: URL must start with http:// or https://", n+1)
+		}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "secret":
+				return nil, fmt.Errorf("line %d: secrets are not saved in the config; put it in an environment variable and name it with secret_env=", n+1)
+			case "secret_env":
+				t.SecretEnv = value
+			case "events":
+				for _, e := range strings.Split(value, ",") {
+					if !isWebhookEvent(e) {
+						return nil, fmt.Errorf("line %d: unknown event %q", n+1, e)
+					}
+					t.Events = append(t.Events, e)
+				}
+			default:
+				return nil, fmt.Errorf("line %d: unknown option %q", n+1, field)
+			}
+		}
+		if t.secret() == "" {
+			return nil, fmt.Errorf("line %d: a secret is required to sign deliveries; set %s", n+1, t.secretEnv())
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+func isWebhookEvent(event string) bool {
+	for _, e := range webhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookSettingsContent builds the Webhooks screen.
+func webhookSettingsContent(w fyne.Window) fyne.CanvasObject {
+	targetsArea := widget.NewMultiLineEntry()
+	targetsArea.SetPlaceHolder("https://hooks.example.com/bot secret_env=HOOKS_SECRET events=bid.placed,error")
+	targetsArea.SetText(formatWebhookTargets(cfg.Webhooks))
+
+	pendingLabel := widget.NewLabel("")
+	refreshPending := func() {
+		pendingLabel.SetText("Deliveries waiting for retry: " + strconv.Itoa(webhooks.Pending()))
+	}
+	refreshPending()
+
+	saveButton := widget.NewButton("Save Webhooks", func() {
+		targets, err := parseWebhookTargets(targetsArea.Text)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid webhook targets: %w", err), w)
+			return
+		}
+		cfg.Webhooks = targets
+		saveConfig()
+		refreshPending()
+		dialog.ShowInformation("Settings Saved", "Your webhook targets have been saved.", w)
+	})
+
+	testButton := widget.NewButton("Send Test Event", func() {
+		targets, err := parseWebhookTargets(targetsArea.Text)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid webhook targets: %w", err), w)
+			return
+		}
+		if len(targets) == 0 {
+			dialog.ShowInformation("No Targets", "Add a webhook target first.", w)
+			return
+		}
+		go func() {
+			var results []string
+			for _, t := range targets {
+				result := "OK"
+				if err := sendTestWebhook(t); err != nil {
+					result = err.Error()
+				}
+				results = append(results, t.URL+": "+result)
+			}
+			fyne.Do(func() {
+				refreshPending()
+				dialog.ShowInformation("Test Event", strings.Join(results, "\n"), w)
+			})
+		}()
+	})
+
+	return container.NewVBox(
+		widget.NewLabel("Webhook Targets (one per line: URL [secret_env=...] [events=...]):"), targetsArea,
+		widget.NewLabel("Deliveries are signed with the secret in the named environment variable, "+WEBHOOK_SECRET_ENV+" if none is named."),
+		widget.NewLabel("Events: "+strings.Join(webhookEvents, ", ")+". Leave out events= for all."),
+		pendingLabel,
+		container.NewHBox(saveButton, testButton),
+	)
+}
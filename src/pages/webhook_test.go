@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// setupWebhookTest points a fresh dispatcher at one target served by
+// handler, signed with the default secret variable.
+func setupWebhookTest(t *testing.T, handler http.HandlerFunc) *WebhookDispatcher {
+	setupOrderTest(t)
+	ensureFolders()
+	t.Setenv(WEBHOOK_SECRET_ENV, "s3cr3t")
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg.Webhooks = []WebhookTarget{{URL: srv.URL}}
+	saved := webhooks
+	webhooks = &WebhookDispatcher{wake: make(chan struct{}, 1), client: srv.Client()}
+	t.Cleanup(func() { webhooks = saved })
+	return webhooks
+}
+
+// deliverDue makes one delivery attempt for every queued delivery, due or
+// not.
+func deliverDue(d *WebhookDispatcher) {
+	for _, job := range d.due(time.Now().Add(24 * time.Hour)) {
+		d.attempt(job)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	// RFC 4231, test case 2.
+	got := signWebhook("Jefe", []byte("what do ya want for nothing?"))
+	want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("signWebhook = %s, want %s", got, want)
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	d := setupWebhookTest(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received, bodies = append(received, r), append(bodies, body)
+		mu.Unlock()
+	})
+
+	emitWebhook(HookOrderWon, map[string]string{"order_url": testOrderURL})
+
+	// Queued on disk before the first attempt.
+	data, err := os.ReadFile(getWebhookQueuePath())
+	if err != nil || !strings.Contains(string(data), HookOrderWon) {
+		t.Fatalf("queue file = %q, %v; want the delivery persisted", data, err)
+	}
+	deliverDue(d)
+
+	if len(received) != 1 {
+		t.Fatalf("received %d deliveries, want 1", len(received))
+	}
+	r, body := received[0], bodies[0]
+	if got, want := r.Header.Get(WEBHOOK_SIGNATURE_HEADER), signWebhook("s3cr3t", body); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+	if got := r.Header.Get(WEBHOOK_EVENT_HEADER); got != HookOrderWon {
+		t.Errorf("event header = %q, want %q", got, HookOrderWon)
+	}
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if payload.ID != r.Header.Get(WEBHOOK_DELIVERY_HEADER) || payload.Event != HookOrderWon {
+		t.Errorf("payload = %+v, want the delivery ID and event of the headers", payload)
+	}
+	if d.Pending() != 0 {
+		t.Errorf("pending = %d after delivery, want 0", d.Pending())
+	}
+}
+
+func TestWebhookRetriesThenGivesUp(t *testing.T) {
+	var posts int32
+	d := setupWebhookTest(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&posts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	saved := webhookRetryPolicy
+	webhookRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialDelayMs: 10000, MaxDelayMs: 15000, Multiplier: 2}
+	t.Cleanup(func() { webhookRetryPolicy = saved })
+
+	emitWebhook(HookError, map[string]string{"error": "boom"})
+	for attempt := 1; attempt < webhookRetryPolicy.MaxAttempts; attempt++ {
+		start := time.Now()
+		deliverDue(d)
+		job := d.queue[0]
+		if job.Attempts != attempt {
+			t.Fatalf("attempts = %d, want %d", job.Attempts, attempt)
+		}
+		// Delay is the backoff for this attempt, capped, with ±20% jitter.
+		base := []time.Duration{10 * time.Second, 15 * time.Second}[attempt-1]
+		if wait := job.NextAttempt.Sub(start); wait < base*8/10 || wait > base*12/10+time.Second {
+			t.Errorf("attempt %d: next try in %s, want about %s", attempt, wait, base)
+		}
+	}
+	deliverDue(d)
+
+	if posts != 3 {
+		t.Errorf("posts = %d, want 3", posts)
+	}
+	if d.Pending() != 0 {
+		t.Errorf("pending = %d, want the delivery given up on", d.Pending())
+	}
+	data, err := os.ReadFile(filepath.Join(getSysfilesDir(), WEBHOOK_FAILED_FILE))
+	if err != nil || strings.Count(string(data), "\n") != 1 {
+		t.Errorf("failed file = %q, %v; want one delivery", data, err)
+	}
+}
+
+func TestWebhookNeedsSecret(t *testing.T) {
+	d := setupWebhookTest(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("delivered without a secret")
+	})
+	t.Setenv(WEBHOOK_SECRET_ENV, "")
+
+	emitWebhook(HookError, map[string]string{"error": "boom"})
+	deliverDue(d)
+	if d.Pending() != 1 || !strings.Contains(d.queue[0].LastError, WEBHOOK_SECRET_ENV) {
+		t.Errorf("queue = %+v, want the delivery waiting for the secret", d.queue)
+	}
+}
+
+func TestParseWebhookTargets(t *testing.T) {
+	t.Setenv(WEBHOOK_SECRET_ENV, "s3cr3t")
+	t.Setenv("HOOKS_SECRET", "other")
+	t.Setenv("UNSET_SECRET", "")
+
+	targets, err := parseWebhookTargets("https://a.example.com\nhttps://b.example.com secret_env=HOOKS_SECRET events=bid.placed,error\n")
+	if err != nil {
+		t.Fatalf("parseWebhookTargets: %v", err)
+	}
+	if len(targets) != 2 || targets[0].secret() != "s3cr3t" || targets[1].secret() != "other" {
+		t.Errorf("targets = %+v, want each signed with its own variable", targets)
+	}
+	if got := formatWebhookTargets(targets); strings.Contains(got, "s3cr3t") || strings.Contains(got, "other") {
+		t.Errorf("formatted targets %q contain a secret", got)
+	}
+
+	for _, text := range []string{
+		"https://a.example.com secret=s3cr3t",
+		"https://a.example.com secret_env=UNSET_SECRET",
+		"https://a.example.com events=nope",
+	} {
+		if _, err := parseWebhookTargets(text); err == nil {
+			t.Errorf("parseWebhookTargets(%q) succeeded", text)
+		}
+	}
+}
+
+func TestDryRunBidSendsNoWebhook(t *testing.T) {
+	d := setupWebhookTest(t, func(w http.ResponseWriter, r *http.Request) {})
+	atomic.StoreInt32(&dryRunActive, 1)
+	doc := newFakeDocument().
+		text("body", "Your bid: This field is disabled for fixed-price orders.").
+		text("#apply_order", "Apply")
+	page := newFakePage(testOrderURL, doc)
+
+	if err := handleOrder(withPage(context.Background(), page), orderListing{URL: testOrderURL}, 0); err != nil {
+		t.Fatalf("handleOrder: %v", err)
+	}
+	for _, job := range d.queue {
+		if job.Event == HookBidPlaced {
+			t.Errorf("simulated bid sent %s", HookBidPlaced)
+		}
+	}
+}